@@ -4,6 +4,24 @@ go install GopherGL/src/window
 go install GopherGL/src/gfx
 go install GopherGL/src/camera
 go install GopherGL/src/input
+go install GopherGL/src/particle
+go install GopherGL/src/mesh
+go install GopherGL/src/skeleton
+go install GopherGL/src/gltf
+go install GopherGL/src/tween
+go install GopherGL/src/terrain
+go install GopherGL/src/lod
+go install GopherGL/src/spatial
+go install GopherGL/src/raster
+go install GopherGL/src/render
+go install GopherGL/src/prof
+go install GopherGL/src/glsl
+go install GopherGL/src/watch
+go install GopherGL/src/std140
+go install GopherGL/src/gputex
+go install GopherGL/src/atlas
+go install GopherGL/src/cmd/gophergl-shadercheck
+go install GopherGL/src/cmd/gophergl-atlas
 go build -o build/GopherGL.exe src/main.go
 
 pushd build
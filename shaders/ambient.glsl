@@ -1,24 +1,7 @@
 #vertex
 #version 330
 
-layout(location = 0) in vec4 position;
-layout(location = 1) in vec2 vertTexCoords;
-layout(location = 2) in vec3 normals;
-
-out vec2 fragTexCoords;
-out vec3 fragPos;
-out vec3 fragNormal;
-
-uniform mat4 model;
-uniform mat4 projection;
-uniform mat4 view;
-
-void main() {
-    gl_Position = projection * view * model * position;
-    fragPos = vec3(model * position);
-    fragTexCoords = vertTexCoords;
-    fragNormal = mat3(transpose(inverse(model))) * normals;
-}
+#include "mesh.glsl"
 
 #fragment
 #version 330
@@ -29,13 +12,9 @@ in vec3 fragNormal;
 
 out vec4 result;
 
-struct material {
-    sampler2D diffTex;
-    sampler2D specTex;
-    float shininess;
-};
+#include "common.glsl"
 
-uniform material mat;
+uniform Material mat;
 
 void main() { 
     // Color of the texture
@@ -1,24 +1,7 @@
 #vertex
 #version 330
 
-layout(location = 0) in vec4 position;
-layout(location = 1) in vec2 vertTexCoords;
-layout(location = 2) in vec3 normals;
-
-out vec2 fragTexCoords;
-out vec3 fragPos;
-out vec3 fragNormal;
-
-uniform mat4 model;
-uniform mat4 projection;
-uniform mat4 view;
-
-void main() {
-    gl_Position = projection * view * model * position;
-    fragPos = vec3(model * position);
-    fragTexCoords = vertTexCoords;
-    fragNormal = mat3(transpose(inverse(model))) * normals;
-}
+#include "mesh.glsl"
 
 #fragment
 #version 330
@@ -29,38 +12,63 @@ in vec3 fragNormal;
 
 out vec4 result;
 
-struct Material {
-    sampler2D diffTex;
-    sampler2D specTex;
-    float shininess;
-};
+#include "common.glsl"
+#include "frame.glsl"
+#include "lights.glsl"
 
-struct Light {
-    float intensity;
-    vec3 direction;
-};
-
-uniform Light sun;
 uniform Material mat;
-uniform vec3 viewPos;
+
+// Used to cross-fade between levels of detail, see drawMesh in renderer.go.
+uniform float lodFade;
+
+// Ambient occlusion from the SSAO pass, only used when useAO is set.
+uniform sampler2D aoTex;
+uniform bool useAO;
+
+// A 4x4 ordered dither pattern, with thresholds from 0 to 1.
+const float dither[16] = float[16](
+    0.0 / 16.0,  8.0 / 16.0,  2.0 / 16.0, 10.0 / 16.0,
+    12.0 / 16.0, 4.0 / 16.0, 14.0 / 16.0,  6.0 / 16.0,
+    3.0 / 16.0, 11.0 / 16.0,  1.0 / 16.0,  9.0 / 16.0,
+    15.0 / 16.0, 7.0 / 16.0, 13.0 / 16.0,  5.0 / 16.0
+);
 
 void main() { 
+    // A positive fade keeps the pixels below the threshold, a negative one the pixels above it.
+    ivec2 p = ivec2(gl_FragCoord.xy) % 4;
+    float threshold = dither[p.y * 4 + p.x];
+    if (lodFade >= 0.0 ? threshold >= lodFade : threshold < 1.0 + lodFade) {
+        discard;
+    }
+
     // Color of the texture
     vec4 albedo = texture(mat.diffTex, fragTexCoords);
     // Minimum light.
     vec3 ambient = 0.1 * vec3(texture(mat.diffTex, fragTexCoords));
+    if (useAO) {
+        ambient *= texelFetch(aoTex, ivec2(gl_FragCoord.xy), 0).r;
+    }
 
     // Diffuse lighting.
     vec3 lightDir = normalize(-sun.direction);
     vec3 norm = normalize(fragNormal);
     float diff = max(dot(norm, lightDir), 0.0);
-    vec3 diffuse = diff * vec3(texture(mat.diffTex, fragTexCoords));
+    vec3 diffuse = sun.color * sun.intensity * diff * vec3(texture(mat.diffTex, fragTexCoords));
 
     // Specularity, the shiny effect when right in the light.
     vec3 viewDir = normalize(viewPos - fragPos);
     vec3 reflectDir = reflect(-lightDir, norm);
     float spec = pow(max(dot(viewDir, reflectDir), 0.0), 32);
     vec3 specular = mat.shininess * spec * vec3(texture(mat.specTex, fragTexCoords));
+
+    // The point lights, the same but fading with the distance.
+    for (int i = 0; i < pointLightCount; i++) {
+        vec3 dir = normalize(pointLights[i].position - fragPos);
+        vec3 light = attenuation(pointLights[i], fragPos) * pointLights[i].color;
+        diffuse += light * max(dot(norm, dir), 0.0) * vec3(texture(mat.diffTex, fragTexCoords));
+        spec = pow(max(dot(viewDir, reflect(-dir, norm)), 0.0), 32);
+        specular += light * mat.shininess * spec * vec3(texture(mat.specTex, fragTexCoords));
+    }
     
     result = vec4(ambient + diffuse + specular, 1.0);
 }
\ No newline at end of file
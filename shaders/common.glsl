@@ -0,0 +1,7 @@
+// The material the lighting shaders share, include it in the fragment stage. The lights are in lights.glsl.
+
+struct Material {
+    sampler2D diffTex;
+    sampler2D specTex;
+    float shininess;
+};
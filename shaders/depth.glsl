@@ -0,0 +1,19 @@
+#vertex
+#version 330
+
+layout(location = 0) in vec4 position;
+
+#include "frame.glsl"
+
+uniform mat4 model;
+
+void main() {
+    gl_Position = projection * view * model * position;
+}
+
+#fragment
+#version 330
+
+// Only the depth is written.
+void main() {
+}
@@ -0,0 +1,8 @@
+// The camera and the time in seconds, uploaded by setCamera in uniforms.go. Has to match frameBlock there.
+
+layout(std140) uniform Frame {
+    mat4 view;
+    mat4 projection;
+    vec3 viewPos;
+    float time;
+};
@@ -0,0 +1,30 @@
+// The lights, uploaded by setLights in uniforms.go. Has to match lightsBlock there.
+
+// Has to be the same as MaxPointLights in uniforms.go.
+const int maxPointLights = 8;
+
+struct DirectionalLight {
+    vec3 direction;
+    float intensity;
+    vec3 color;
+};
+
+struct PointLight {
+    vec3 position;
+    float constant;
+    vec3 color;
+    float linear;
+    float quadratic;
+};
+
+layout(std140) uniform Lights {
+    DirectionalLight sun;
+    PointLight pointLights[maxPointLights];
+    int pointLightCount;
+};
+
+// attenuation returns how much of a point light reaches the position.
+float attenuation(PointLight l, vec3 pos) {
+    float d = length(l.position - pos);
+    return 1.0 / (l.constant + l.linear * d + l.quadratic * d * d);
+}
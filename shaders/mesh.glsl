@@ -0,0 +1,45 @@
+// The vertex stage of the meshes, it passes the world position, texture coordinates and normal on to the
+// fragment stage. Define SKINNED to deform the mesh by its joints first.
+
+layout(location = 0) in vec4 position;
+layout(location = 1) in vec2 vertTexCoords;
+layout(location = 2) in vec3 normals;
+#ifdef SKINNED
+layout(location = 3) in uvec4 joints;
+layout(location = 4) in vec4 weights;
+#endif
+
+out vec2 fragTexCoords;
+out vec3 fragPos;
+out vec3 fragNormal;
+
+#include "frame.glsl"
+
+uniform mat4 model;
+// Moves the texture coordinates into a region of a texture atlas, 0 and 1 for textures of their own.
+uniform vec2 uvOffset;
+uniform vec2 uvScale;
+
+#ifdef SKINNED
+// Has to be the same as MaxJoints in skinned.go.
+const int maxJoints = 64;
+uniform mat4 jointMats[maxJoints];
+#endif
+
+void main() {
+#ifdef SKINNED
+    // Mix the matrices of the joints this vertex is attached to.
+    mat4 skin = weights.x * jointMats[joints.x] +
+                weights.y * jointMats[joints.y] +
+                weights.z * jointMats[joints.z] +
+                weights.w * jointMats[joints.w];
+    mat4 world = model * skin;
+#else
+    mat4 world = model;
+#endif
+
+    gl_Position = projection * view * world * vec4(position.xyz, 1.0);
+    fragPos = vec3(world * vec4(position.xyz, 1.0));
+    fragTexCoords = uvOffset + vertTexCoords * uvScale;
+    fragNormal = mat3(transpose(inverse(world))) * normals;
+}
@@ -0,0 +1,29 @@
+#vertex
+#version 330
+
+// A corner of the unit square.
+layout(location = 0) in vec2 corner;
+// Per rectangle: the position and size in pixels, from the top left of the screen, and the color.
+layout(location = 1) in vec4 rect;
+layout(location = 2) in vec4 color;
+
+out vec4 fragColor;
+
+uniform vec2 screenSize;
+
+void main() {
+    vec2 pixel = rect.xy + corner * rect.zw;
+    gl_Position = vec4(pixel / screenSize * vec2(2.0, -2.0) + vec2(-1.0, 1.0), 0.0, 1.0);
+    fragColor = color;
+}
+
+#fragment
+#version 330
+
+in vec4 fragColor;
+
+out vec4 result;
+
+void main() {
+    result = fragColor;
+}
@@ -0,0 +1,38 @@
+#vertex
+#version 330
+
+layout(location = 0) in vec2 corner;
+layout(location = 1) in vec4 center;
+layout(location = 2) in vec4 color;
+
+out vec2 fragCorner;
+out vec4 fragColor;
+
+#include "frame.glsl"
+
+void main() {
+    // The right and up vectors of the camera, so the quad always faces it.
+    vec3 right = vec3(view[0][0], view[1][0], view[2][0]);
+    vec3 up = vec3(view[0][1], view[1][1], view[2][1]);
+
+    // The w component of the center holds the size.
+    vec3 pos = center.xyz + (right * corner.x + up * corner.y) * center.w;
+    gl_Position = projection * view * vec4(pos, 1.0);
+
+    fragCorner = corner;
+    fragColor = color;
+}
+
+#fragment
+#version 330
+
+in vec2 fragCorner;
+in vec4 fragColor;
+
+out vec4 result;
+
+void main() {
+    // Make the particles round with soft edges.
+    float alpha = 1.0 - smoothstep(0.25, 0.5, length(fragCorner));
+    result = vec4(fragColor.rgb, fragColor.a * alpha);
+}
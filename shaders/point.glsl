@@ -1,24 +1,7 @@
 #vertex
 #version 330
 
-layout(location = 0) in vec4 position;
-layout(location = 1) in vec2 vertTexCoords;
-layout(location = 2) in vec3 normals;
-
-out vec2 fragTexCoords;
-out vec3 fragPos;
-out vec3 fragNormal;
-
-uniform mat4 model;
-uniform mat4 projection;
-uniform mat4 view;
-
-void main() {
-    gl_Position = projection * view * model * position;
-    fragPos = vec3(model * position);
-    fragTexCoords = vertTexCoords;
-    fragNormal = mat3(transpose(inverse(model))) * normals;
-}
+#include "mesh.glsl"
 
 #fragment
 #version 330
@@ -29,49 +12,30 @@ in vec3 fragNormal;
 
 out vec4 result;
 
-struct Material {
-    sampler2D diffTex;
-    sampler2D specTex;
-    float shininess;
-};
-
-struct Light {
-    vec3 position;  
-  
-    vec3 diffuse;
-	
-    float constant;
-    float linear;
-    float quadratic;
-}; 
+#include "common.glsl"
+#include "frame.glsl"
+#include "lights.glsl"
 
-uniform Light pl;
 uniform Material mat;
-uniform vec3 viewPos;
 
 void main() { 
-    // Color of the texture
-    vec4 albedo = texture(mat.diffTex, fragTexCoords);
-
-    // Diffuse lighting.
-    vec3 lightDir = normalize(pl.position - fragPos);
     vec3 norm = normalize(fragNormal);
-    float diff = max(dot(norm, lightDir), 0.0);
-    vec3 diffuse = diff * vec3(texture(mat.diffTex, fragTexCoords));
-
-    // Specularity, the shiny effect when right in the light.
     vec3 viewDir = normalize(viewPos - fragPos);
-    vec3 reflectDir = reflect(-lightDir, norm);
-    float spec = pow(max(dot(viewDir, reflectDir), 0.0), 32);
-    vec3 specular = mat.shininess * spec * vec3(texture(mat.specTex, fragTexCoords));
+    vec3 color = vec3(0.0);
+
+    for (int i = 0; i < pointLightCount; i++) {
+        // Diffuse lighting.
+        vec3 lightDir = normalize(pointLights[i].position - fragPos);
+        float diff = max(dot(norm, lightDir), 0.0);
+        vec3 diffuse = diff * vec3(texture(mat.diffTex, fragTexCoords));
 
-    float distance    = length(pl.position - fragPos);
-    float attenuation = 1.0 / (pl.constant + pl.linear * distance + 
-    		    pl.quadratic * (distance * distance));
+        // Specularity, the shiny effect when right in the light.
+        vec3 reflectDir = reflect(-lightDir, norm);
+        float spec = pow(max(dot(viewDir, reflectDir), 0.0), 32);
+        vec3 specular = mat.shininess * spec * vec3(texture(mat.specTex, fragTexCoords));
 
-    diffuse *= attenuation;
-    specular *= attenuation;
+        color += attenuation(pointLights[i], fragPos) * pointLights[i].color * (diffuse + specular);
+    }
 
-    vec3 color = diffuse + specular;
     result = vec4(color, 1.0);
 }
\ No newline at end of file
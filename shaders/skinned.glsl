@@ -0,0 +1,41 @@
+#vertex
+#version 330
+
+#define SKINNED
+#include "mesh.glsl"
+
+#fragment
+#version 330
+
+in vec3 fragPos;
+in vec2 fragTexCoords;
+in vec3 fragNormal;
+
+out vec4 result;
+
+#include "common.glsl"
+#include "frame.glsl"
+#include "lights.glsl"
+
+uniform Material mat;
+
+void main() { 
+    // Color of the texture
+    vec4 albedo = texture(mat.diffTex, fragTexCoords);
+    // Minimum light.
+    vec3 ambient = 0.1 * vec3(texture(mat.diffTex, fragTexCoords));
+
+    // Diffuse lighting.
+    vec3 lightDir = normalize(-sun.direction);
+    vec3 norm = normalize(fragNormal);
+    float diff = max(dot(norm, lightDir), 0.0);
+    vec3 diffuse = sun.color * diff * vec3(texture(mat.diffTex, fragTexCoords));
+
+    // Specularity, the shiny effect when right in the light.
+    vec3 viewDir = normalize(viewPos - fragPos);
+    vec3 reflectDir = reflect(-lightDir, norm);
+    float spec = pow(max(dot(viewDir, reflectDir), 0.0), 32);
+    vec3 specular = mat.shininess * spec * vec3(texture(mat.specTex, fragTexCoords));
+    
+    result = vec4(ambient + diffuse + specular, 1.0);
+}
\ No newline at end of file
@@ -0,0 +1,79 @@
+#vertex
+#version 330
+
+out vec2 fragTexCoords;
+
+// A triangle that covers the whole screen, made from the vertex id so no buffers are needed.
+void main() {
+    fragTexCoords = vec2((gl_VertexID << 1) & 2, gl_VertexID & 2);
+    gl_Position = vec4(fragTexCoords * 2.0 - 1.0, 0.0, 1.0);
+}
+
+#fragment
+#version 330
+
+in vec2 fragTexCoords;
+
+out float result;
+
+uniform sampler2D depthTex;
+uniform sampler2D noiseTex;
+
+uniform mat4 projection;
+uniform mat4 invProjection;
+
+// The hemisphere kernel, see createKernel in ssao.go.
+uniform vec3 samples[64];
+uniform int sampleCount;
+uniform float radius;
+uniform float bias;
+uniform float intensity;
+
+// Turns a depth buffer coordinate back into a view space position.
+vec3 viewPos(vec2 uv) {
+    float depth = texture(depthTex, uv).r;
+    vec4 p = invProjection * vec4(vec3(uv, depth) * 2.0 - 1.0, 1.0);
+    return p.xyz / p.w;
+}
+
+// Picks the neighbour closest in depth, so edges don't get a normal facing sideways.
+vec3 closest(vec3 p, vec3 a, vec3 b) {
+    return abs(a.z - p.z) < abs(b.z - p.z) ? a - p : p - b;
+}
+
+void main() {
+    // Nothing was drawn here.
+    if (texture(depthTex, fragTexCoords).r == 1.0) {
+        result = 1.0;
+        return;
+    }
+
+    // The normal is reconstructed from the positions of the neighbouring pixels.
+    vec2 texel = 1.0 / vec2(textureSize(depthTex, 0));
+    vec3 pos = viewPos(fragTexCoords);
+    vec3 dx = closest(pos, viewPos(fragTexCoords + vec2(texel.x, 0.0)), viewPos(fragTexCoords - vec2(texel.x, 0.0)));
+    vec3 dy = closest(pos, viewPos(fragTexCoords + vec2(0.0, texel.y)), viewPos(fragTexCoords - vec2(0.0, texel.y)));
+    vec3 normal = normalize(cross(dx, dy));
+
+    // Rotate the kernel randomly around the normal, the blur pass hides the noise pattern.
+    vec2 noiseScale = vec2(textureSize(depthTex, 0)) / vec2(textureSize(noiseTex, 0));
+    vec3 random = texture(noiseTex, fragTexCoords * noiseScale).xyz;
+    vec3 tangent = normalize(random - normal * dot(random, normal));
+    mat3 tbn = mat3(tangent, cross(normal, tangent), normal);
+
+    float occlusion = 0.0;
+    for (int i = 0; i < sampleCount; i++) {
+        vec3 s = pos + tbn * samples[i] * radius;
+
+        // Find where the sample is on the screen, and what's actually there.
+        vec4 offset = projection * vec4(s, 1.0);
+        offset.xy = offset.xy / offset.w * 0.5 + 0.5;
+        float sceneDepth = viewPos(offset.xy).z;
+
+        // Geometry far in front of the sample shouldn't darken it.
+        float rangeCheck = smoothstep(0.0, 1.0, radius / abs(pos.z - sceneDepth));
+        occlusion += (sceneDepth >= s.z + bias ? 1.0 : 0.0) * rangeCheck;
+    }
+
+    result = clamp(1.0 - intensity * occlusion / float(sampleCount), 0.0, 1.0);
+}
@@ -0,0 +1,31 @@
+#vertex
+#version 330
+
+out vec2 fragTexCoords;
+
+void main() {
+    fragTexCoords = vec2((gl_VertexID << 1) & 2, gl_VertexID & 2);
+    gl_Position = vec4(fragTexCoords * 2.0 - 1.0, 0.0, 1.0);
+}
+
+#fragment
+#version 330
+
+in vec2 fragTexCoords;
+
+out float result;
+
+uniform sampler2D aoTex;
+
+// Averages a 4x4 block, the same size as the noise texture, so the noise pattern disappears.
+void main() {
+    vec2 texel = 1.0 / vec2(textureSize(aoTex, 0));
+    float sum = 0.0;
+    for (int x = -2; x < 2; x++) {
+        for (int y = -2; y < 2; y++) {
+            sum += texture(aoTex, fragTexCoords + vec2(float(x), float(y)) * texel).r;
+        }
+    }
+
+    result = sum / 16.0;
+}
@@ -0,0 +1,64 @@
+#vertex
+#version 330
+
+layout(location = 0) in vec4 position;
+layout(location = 1) in vec2 vertTexCoords;
+layout(location = 2) in vec3 normals;
+
+out vec2 fragTexCoords;
+out vec3 fragPos;
+out vec3 fragNormal;
+
+#include "frame.glsl"
+
+void main() {
+    // Terrain is already in world space.
+    gl_Position = projection * view * position;
+    fragPos = position.xyz;
+    fragTexCoords = vertTexCoords;
+    fragNormal = normals;
+}
+
+#fragment
+#version 330
+
+in vec3 fragPos;
+in vec2 fragTexCoords;
+in vec3 fragNormal;
+
+out vec4 result;
+
+struct TerrainMaterial {
+    sampler2D splat;
+    sampler2D layer0;
+    sampler2D layer1;
+    sampler2D layer2;
+    sampler2D layer3;
+    float tiling;
+};
+
+#include "lights.glsl"
+
+uniform TerrainMaterial mat;
+
+void main() {
+    // The splat map says how much of every layer there is, red is layer 0 and alpha is layer 3.
+    vec4 weights = texture(mat.splat, fragTexCoords);
+    weights /= max(weights.r + weights.g + weights.b + weights.a, 0.0001);
+
+    vec2 tiled = fragTexCoords * mat.tiling;
+    vec3 albedo = weights.r * texture(mat.layer0, tiled).rgb +
+                  weights.g * texture(mat.layer1, tiled).rgb +
+                  weights.b * texture(mat.layer2, tiled).rgb +
+                  weights.a * texture(mat.layer3, tiled).rgb;
+
+    // Minimum light.
+    vec3 ambient = 0.1 * albedo;
+
+    // Diffuse lighting.
+    vec3 lightDir = normalize(-sun.direction);
+    float diff = max(dot(normalize(fragNormal), lightDir), 0.0);
+    vec3 diffuse = diff * sun.intensity * sun.color * albedo;
+
+    result = vec4(ambient + diffuse, 1.0);
+}
@@ -0,0 +1,221 @@
+// Package atlas packs many small images into a few big ones, the pages. Textures that share a page can be drawn
+// without binding another texture in between. Pack does it while the game runs, the gophergl-atlas command
+// before, writing the pages and a manifest with Write that ReadManifest and gfx.LoadAtlas read.
+package atlas
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Options is how the images are packed.
+type Options struct {
+	// Size is the width and height of the pages in pixels, 2048 when it's 0.
+	Size int
+	// Padding is the pixels around every image, filled with its edge pixels. Without it filtering blends in the
+	// image next to it.
+	Padding int
+	// Align puts the images on a multiple of this many pixels, with the padding, it's 1 when it's 0. Mipmaps
+	// average 2x2 pixels of the level before, so with Padding and Align of 2^n the first n mipmaps don't mix
+	// images. 4 also keeps the images in their own blocks when the pages are BC compressed.
+	Align int
+}
+
+// Image is an image to pack, the name is how its region is found.
+type Image struct {
+	Name  string
+	Image image.Image
+}
+
+// Region is where an image ended up. X, Y, Width and Height are the pixels on the page, without the padding, from
+// the top left. UV is the same as texture coordinates: the u and v of the bottom left corner, then of the top right
+// corner. v goes up like in OpenGL, for pages loaded with gfx.LoadTexture, which flips them.
+type Region struct {
+	Page   int        `json:"page"`
+	X      int        `json:"x"`
+	Y      int        `json:"y"`
+	Width  int        `json:"width"`
+	Height int        `json:"height"`
+	UV     [4]float32 `json:"uv"`
+}
+
+// Atlas is what Pack made.
+type Atlas struct {
+	Pages   []*image.RGBA
+	Regions map[string]Region
+}
+
+// Pack packs the images into as few pages as it can, the biggest images first. It's an error when an image is
+// bigger than a page, or two have the same name.
+func Pack(images []Image, o Options) (*Atlas, error) {
+	if o.Size == 0 {
+		o.Size = 2048
+	}
+	if o.Align < 1 {
+		o.Align = 1
+	}
+	if o.Size < 1 || o.Padding < 0 {
+		return nil, fmt.Errorf("pages can't be %vx%v with %v pixels of padding", o.Size, o.Size, o.Padding)
+	}
+
+	// Sorted by height, then width and name, so the same images always make the same atlas.
+	sorted := append([]Image{}, images...)
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i].Image.Bounds().Size(), sorted[j].Image.Bounds().Size()
+		if a.Y != b.Y {
+			return a.Y > b.Y
+		}
+		if a.X != b.X {
+			return a.X > b.X
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	a := &Atlas{Regions: make(map[string]Region)}
+	var packers []*Packer
+	for _, img := range sorted {
+		if _, ok := a.Regions[img.Name]; ok {
+			return nil, fmt.Errorf("there are two images called %q", img.Name)
+		}
+		size := img.Image.Bounds().Size()
+		if size.X == 0 || size.Y == 0 {
+			return nil, fmt.Errorf("%v is empty", img.Name)
+		}
+		w, h := align(size.X+2*o.Padding, o.Align), align(size.Y+2*o.Padding, o.Align)
+		if w > o.Size || h > o.Size {
+			return nil, fmt.Errorf("%v is %vx%v with padding, the pages are %vx%v", img.Name, w, h, o.Size, o.Size)
+		}
+
+		page, x, y := -1, 0, 0
+		for i, p := range packers {
+			if px, py, ok := p.Pack(w, h); ok {
+				page, x, y = i, px, py
+				break
+			}
+		}
+		if page < 0 {
+			page = len(packers)
+			packers = append(packers, CreatePacker(o.Size, o.Size))
+			a.Pages = append(a.Pages, image.NewRGBA(image.Rect(0, 0, o.Size, o.Size)))
+			x, y, _ = packers[page].Pack(w, h)
+		}
+
+		x, y = x+o.Padding, y+o.Padding
+		draw.Draw(a.Pages[page], image.Rect(x, y, x+size.X, y+size.Y), img.Image, img.Image.Bounds().Min, draw.Src)
+		extrude(a.Pages[page], image.Rect(x, y, x+size.X, y+size.Y), o.Padding)
+
+		s := float32(o.Size)
+		a.Regions[img.Name] = Region{
+			Page: page, X: x, Y: y, Width: size.X, Height: size.Y,
+			UV: [4]float32{float32(x) / s, 1 - float32(y+size.Y)/s, float32(x+size.X) / s, 1 - float32(y)/s},
+		}
+	}
+
+	return a, nil
+}
+
+// align rounds up to a multiple of a.
+func align(v, a int) int {
+	return (v + a - 1) / a * a
+}
+
+// extrude fills the padding around r with the pixels on its edges.
+func extrude(page *image.RGBA, r image.Rectangle, padding int) {
+	for y := r.Min.Y - padding; y < r.Max.Y+padding; y++ {
+		for x := r.Min.X - padding; x < r.Max.X+padding; x++ {
+			if (image.Point{x, y}).In(r) {
+				continue
+			}
+			sx, sy := clamp(x, r.Min.X, r.Max.X-1), clamp(y, r.Min.Y, r.Max.Y-1)
+			copy(page.Pix[page.PixOffset(x, y):][:4], page.Pix[page.PixOffset(sx, sy):][:4])
+		}
+	}
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// Manifest is the file Write makes, the pages are PNG files next to it:
+//
+//	{
+//		"pages": ["props0.png"],
+//		"regions": {
+//			"crate": {"page": 0, "x": 2, "y": 2, "width": 64, "height": 64, "uv": [0.001, 0.968, 0.032, 0.999]}
+//		}
+//	}
+type Manifest struct {
+	// Pages are the files of the pages. ReadManifest makes them relative to the working directory, like the
+	// manifest file.
+	Pages   []string          `json:"pages"`
+	Regions map[string]Region `json:"regions"`
+}
+
+// Write saves the pages as PNG files next to the manifest file, named like it with the number of the page. A
+// manifest file props.json gets props0.png, props1.png and so on.
+func (a *Atlas) Write(file string) error {
+	dir, base := filepath.Dir(file), strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	m := Manifest{Pages: make([]string, len(a.Pages)), Regions: a.Regions}
+	for i, page := range a.Pages {
+		m.Pages[i] = fmt.Sprintf("%v%v.png", base, i)
+		if err := writePNG(filepath.Join(dir, m.Pages[i]), page); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, append(data, '\n'), 0644)
+}
+
+// writePNG saves an image.
+func writePNG(file string, img image.Image) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadManifest reads a manifest file, see Manifest. It's an error when a region is on a page that isn't there.
+func ReadManifest(file string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%v: %v", file, err)
+	}
+	for name, r := range m.Regions {
+		if r.Page < 0 || r.Page >= len(m.Pages) {
+			return nil, fmt.Errorf("%v: region %q is on page %v, there are %v pages", file, name, r.Page, len(m.Pages))
+		}
+	}
+	for i, p := range m.Pages {
+		m.Pages[i] = filepath.Join(filepath.Dir(file), p)
+	}
+
+	return &m, nil
+}
@@ -0,0 +1,190 @@
+package atlas
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// solid returns an image of one color.
+func solid(w, h int, c color.RGBA) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+var (
+	red   = color.RGBA{255, 0, 0, 255}
+	green = color.RGBA{0, 255, 0, 255}
+	blue  = color.RGBA{0, 0, 255, 255}
+)
+
+func TestPack(t *testing.T) {
+	images := []Image{
+		{"red", solid(10, 20, red)},
+		{"green", solid(30, 10, green)},
+		{"blue", solid(7, 7, blue)},
+	}
+	colors := map[string]color.RGBA{"red": red, "green": green, "blue": blue}
+	o := Options{Size: 64, Padding: 2, Align: 4}
+
+	a, err := Pack(images, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Pages) != 1 {
+		t.Fatalf("got %v pages, want 1", len(a.Pages))
+	}
+
+	var padded []image.Rectangle
+	for _, img := range images {
+		r := a.Regions[img.Name]
+		size := img.Image.Bounds().Size()
+		if r.Width != size.X || r.Height != size.Y {
+			t.Errorf("%v is %vx%v, want %v", img.Name, r.Width, r.Height, size)
+		}
+		if (r.X-o.Padding)%o.Align != 0 || (r.Y-o.Padding)%o.Align != 0 {
+			t.Errorf("%v is at %v, %v, it isn't aligned with its padding", img.Name, r.X, r.Y)
+		}
+
+		// The padding is filled with the edges, so it's the same color all around.
+		p := image.Rect(r.X-o.Padding, r.Y-o.Padding, r.X+r.Width+o.Padding, r.Y+r.Height+o.Padding)
+		for y := p.Min.Y; y < p.Max.Y; y++ {
+			for x := p.Min.X; x < p.Max.X; x++ {
+				if c := a.Pages[0].RGBAAt(x, y); c != colors[img.Name] {
+					t.Fatalf("%v has %v at %v, %v", img.Name, c, x, y)
+				}
+			}
+		}
+		if !p.In(a.Pages[0].Bounds()) {
+			t.Errorf("%v is outside the page", img.Name)
+		}
+		for _, other := range padded {
+			if p.Overlaps(other) {
+				t.Errorf("%v overlaps another image", img.Name)
+			}
+		}
+		padded = append(padded, p)
+	}
+}
+
+func TestPackUV(t *testing.T) {
+	a, err := Pack([]Image{{"a", solid(16, 8, red)}, {"b", solid(8, 8, blue)}}, Options{Size: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// v goes up, the top left of the page is u 0 and v 1.
+	want := map[string]Region{
+		"a": {Page: 0, X: 0, Y: 0, Width: 16, Height: 8, UV: [4]float32{0.0, 0.875, 0.25, 1.0}},
+		"b": {Page: 0, X: 16, Y: 0, Width: 8, Height: 8, UV: [4]float32{0.25, 0.875, 0.375, 1.0}},
+	}
+	if !reflect.DeepEqual(a.Regions, want) {
+		t.Errorf("got %+v, want %+v", a.Regions, want)
+	}
+}
+
+func TestPackPages(t *testing.T) {
+	// Only one fits on a page.
+	images := []Image{{"a", solid(20, 20, red)}, {"b", solid(20, 20, green)}, {"c", solid(20, 20, blue)}}
+	a, err := Pack(images, Options{Size: 32})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Pages) != 3 {
+		t.Fatalf("got %v pages, want 3", len(a.Pages))
+	}
+	// Sorted by name when they're the same size.
+	for i, name := range []string{"a", "b", "c"} {
+		if r := a.Regions[name]; r.Page != i || r.X != 0 || r.Y != 0 {
+			t.Errorf("%v is on page %v at %v, %v, want page %v at 0, 0", name, r.Page, r.X, r.Y, i)
+		}
+	}
+}
+
+func TestPackErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		images []Image
+		o      Options
+		err    string
+	}{
+		{"duplicate", []Image{{"a", solid(4, 4, red)}, {"a", solid(8, 8, red)}}, Options{}, `two images called "a"`},
+		{"too big", []Image{{"a", solid(15, 4, red)}}, Options{Size: 16, Padding: 1}, "a is 17x6 with padding"},
+		{"aligned too big", []Image{{"a", solid(9, 4, red)}}, Options{Size: 12, Align: 8}, "a is 16x8"},
+		{"empty", []Image{{"a", solid(0, 4, red)}}, Options{}, "a is empty"},
+		{"padding", nil, Options{Padding: -1}, "-1 pixels of padding"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Pack(test.images, test.o)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("got %v, want an error with %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestWriteReadManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "atlas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	images := []Image{{"a", solid(20, 20, red)}, {"b", solid(20, 20, green)}}
+	a, err := Pack(images, Options{Size: 32, Padding: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "props.json")
+	if err := a.Write(file); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := ReadManifest(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages := []string{filepath.Join(dir, "props0.png"), filepath.Join(dir, "props1.png")}
+	if !reflect.DeepEqual(m.Pages, pages) || !reflect.DeepEqual(m.Regions, a.Regions) {
+		t.Errorf("read %+v, want pages %v and regions %+v", m, pages, a.Regions)
+	}
+
+	for i, page := range m.Pages {
+		f, err := os.Open(page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The page has transparent parts, so it's read as an NRGBA.
+		got := image.NewRGBA(img.Bounds())
+		draw.Draw(got, got.Bounds(), img, image.Point{}, draw.Src)
+		if !reflect.DeepEqual(got, a.Pages[i]) {
+			t.Errorf("page %v isn't the same after reading it", i)
+		}
+	}
+
+	// A region on a page that isn't there.
+	bad := filepath.Join(dir, "bad.json")
+	if err := ioutil.WriteFile(bad, []byte(`{"pages": [], "regions": {"a": {"page": 0}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadManifest(bad); err == nil {
+		t.Errorf("a region without a page was read")
+	}
+}
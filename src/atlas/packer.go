@@ -0,0 +1,103 @@
+package atlas
+
+// Packer places rectangles in a page with the skyline algorithm: it keeps the top edge of what's placed so far,
+// and puts every rectangle as low as it fits. It's fast and packs well when the rectangles are sorted by height.
+type Packer struct {
+	width, height int
+	// skyline are the segments of the top edge, from left to right, they cover the whole width.
+	skyline []segment
+}
+
+// segment is a part of the skyline, y is where the free space above it starts.
+type segment struct {
+	x, y, width int
+}
+
+// CreatePacker returns a packer for an empty page of the size.
+func CreatePacker(width, height int) *Packer {
+	return &Packer{width: width, height: height, skyline: []segment{{0, 0, width}}}
+}
+
+// Pack finds a place for a rectangle, ok is false when the page doesn't have room for it. Rectangles are placed
+// from the top of the page down, x and y are the top left corner.
+func (p *Packer) Pack(width, height int) (x, y int, ok bool) {
+	if width <= 0 || height <= 0 {
+		return 0, 0, false
+	}
+
+	// The lowest place wins, then the one that leaves the narrowest gap.
+	best, bestY, bestWaste := -1, 0, 0
+	for i := range p.skyline {
+		y, waste, fits := p.fit(i, width, height)
+		if fits && (best < 0 || y < bestY || y == bestY && waste < bestWaste) {
+			best, bestY, bestWaste = i, y, waste
+		}
+	}
+	if best < 0 {
+		return 0, 0, false
+	}
+
+	x = p.skyline[best].x
+	p.place(best, x, bestY+height, width)
+	return x, bestY, true
+}
+
+// fit returns the y where a rectangle starting at segment i fits, and the area it leaves empty below it.
+func (p *Packer) fit(i, width, height int) (y, waste int, ok bool) {
+	x := p.skyline[i].x
+	if x+width > p.width {
+		return 0, 0, false
+	}
+
+	// The rectangle rests on the highest segment it covers.
+	left := width
+	for j := i; left > 0; j++ {
+		if p.skyline[j].y > y {
+			y = p.skyline[j].y
+		}
+		left -= p.skyline[j].width
+	}
+	if y+height > p.height {
+		return 0, 0, false
+	}
+
+	left = width
+	for j := i; left > 0; j++ {
+		w := p.skyline[j].width
+		if w > left {
+			w = left
+		}
+		waste += (y - p.skyline[j].y) * w
+		left -= w
+	}
+	return y, waste, true
+}
+
+// place adds a segment for the top of a rectangle at segment i, and cuts away the segments below it.
+func (p *Packer) place(i, x, top, width int) {
+	s := append([]segment{}, p.skyline[:i]...)
+	s = append(s, segment{x, top, width})
+
+	end := x + width
+	for _, seg := range p.skyline[i:] {
+		if seg.x+seg.width <= end {
+			continue
+		}
+		if seg.x < end {
+			seg.width -= end - seg.x
+			seg.x = end
+		}
+		s = append(s, seg)
+	}
+
+	// Neighbors at the same height are one segment.
+	p.skyline = s[:1]
+	for _, seg := range s[1:] {
+		last := &p.skyline[len(p.skyline)-1]
+		if last.y == seg.y {
+			last.width += seg.width
+		} else {
+			p.skyline = append(p.skyline, seg)
+		}
+	}
+}
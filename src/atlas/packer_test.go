@@ -0,0 +1,60 @@
+package atlas
+
+import (
+	"image"
+	"math/rand"
+	"testing"
+)
+
+func TestPackerNoOverlap(t *testing.T) {
+	const size = 256
+	r := rand.New(rand.NewSource(1))
+	p := CreatePacker(size, size)
+
+	var placed []image.Rectangle
+	for i := 0; i < 200; i++ {
+		w, h := 1+r.Intn(40), 1+r.Intn(40)
+		x, y, ok := p.Pack(w, h)
+		if !ok {
+			continue
+		}
+
+		rect := image.Rect(x, y, x+w, y+h)
+		if !rect.In(image.Rect(0, 0, size, size)) {
+			t.Fatalf("%v is outside the page", rect)
+		}
+		for _, other := range placed {
+			if rect.Overlaps(other) {
+				t.Fatalf("%v overlaps %v", rect, other)
+			}
+		}
+		placed = append(placed, rect)
+	}
+	if len(placed) < 50 {
+		t.Errorf("only %v rectangles fit", len(placed))
+	}
+}
+
+func TestPackerFull(t *testing.T) {
+	p := CreatePacker(64, 64)
+
+	// Four quarters fill the page, the lowest place first and then the leftmost.
+	for _, want := range []image.Point{{0, 0}, {32, 0}, {0, 32}, {32, 32}} {
+		x, y, ok := p.Pack(32, 32)
+		if !ok || x != want.X || y != want.Y {
+			t.Errorf("got %v, %v %v, want %v", x, y, ok, want)
+		}
+	}
+	if len(p.skyline) != 1 || p.skyline[0] != (segment{0, 64, 64}) {
+		t.Errorf("the skyline of a full page is %v", p.skyline)
+	}
+
+	for _, size := range [][2]int{{1, 1}, {0, 1}, {1, -1}} {
+		if _, _, ok := p.Pack(size[0], size[1]); ok {
+			t.Errorf("%vx%v was packed", size[0], size[1])
+		}
+	}
+	if _, _, ok := CreatePacker(64, 64).Pack(65, 1); ok {
+		t.Errorf("a rectangle wider than the page was packed")
+	}
+}
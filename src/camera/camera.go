@@ -9,7 +9,7 @@ import (
 type Camera struct {
 	Pos, Target mgl32.Vec3
 	View, Proj  mgl32.Mat4
-	Fov         float32
+	Fov, Aspect float32
 }
 
 // CreateCamera creates a FPP camera and sets up the view matrix.
@@ -17,6 +17,7 @@ func CreateCamera(pos mgl32.Vec3, aspect, fov float32) *Camera {
 	c := Camera{}
 
 	c.Fov = fov
+	c.Aspect = aspect
 	c.Pos = pos
 	c.Target = mgl32.Vec3{pos.X(), pos.Y(), pos.Z() - 1.0}
 	c.View = mgl32.LookAt(c.Pos.X(), c.Pos.Y(), c.Pos.Z(),
@@ -38,5 +39,12 @@ func (c *Camera) Update() {
 
 // SetProjection takes the new fov and aspect ratio and creates a new projection matrix.
 func (c *Camera) SetProjection(aspect, fov float32) {
+	c.Aspect = aspect
+	c.Fov = fov
 	c.Proj = mgl32.Perspective(mgl32.DegToRad(fov), aspect, 0.1, 1000.0)
-}
\ No newline at end of file
+}
+
+// SetFov changes the field of view in degrees, and keeps the aspect ratio.
+func (c *Camera) SetFov(fov float32) {
+	c.SetProjection(c.Aspect, fov)
+}
@@ -0,0 +1,94 @@
+// Command gophergl-atlas packs the images of a directory into texture atlas pages, PNG files, with a JSON manifest
+// that gfx.LoadAtlas reads:
+//
+//	gophergl-atlas -in res/props -out res/props.json -padding 4 -align 4
+//
+// The images are found in the subdirectories too. They're called by their path in the directory without the
+// extension, like "crates/wood", which is how materials ask for them: "props.json#crates/wood".
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"GopherGL/src/atlas"
+)
+
+func main() {
+	in := flag.String("in", "", "the directory with the images")
+	out := flag.String("out", "atlas.json", "the manifest file, the pages are written next to it")
+	size := flag.Int("size", 2048, "the width and height of the pages")
+	padding := flag.Int("padding", 2, "the pixels around every image, filled with its edges")
+	align := flag.Int("align", 1, "put the images on a multiple of this many pixels, 2^n keeps n mipmaps apart")
+	flag.Parse()
+
+	if *in == "" {
+		fmt.Fprintln(os.Stderr, "gophergl-atlas: -in is the directory with the images")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	images, err := readImages(*in)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gophergl-atlas:", err)
+		os.Exit(2)
+	}
+
+	a, err := atlas.Pack(images, atlas.Options{Size: *size, Padding: *padding, Align: *align})
+	if err == nil {
+		err = a.Write(*out)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gophergl-atlas:", err)
+		os.Exit(2)
+	}
+
+	fmt.Printf("packed %v images into %v pages of %vx%v\n", len(images), len(a.Pages), *size, *size)
+}
+
+// readImages decodes the PNG and JPEG files in the directory and its subdirectories.
+func readImages(dir string) ([]atlas.Image, error) {
+	var images []atlas.Image
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".png", ".jpg", ".jpeg":
+		default:
+			return nil
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		img, _, err := image.Decode(f)
+		if err != nil {
+			return fmt.Errorf("%v: %v", file, err)
+		}
+
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel)))
+		images = append(images, atlas.Image{Name: name, Image: img})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(images) == 0 {
+		return nil, fmt.Errorf("there are no PNG or JPEG files in %v", dir)
+	}
+
+	return images, nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"GopherGL/src/glsl"
+)
+
+// setterTypes are the GLSL types the setters of gfx.Shader accept, a type ending in * matches every type starting
+// with the rest.
+var setterTypes = map[string][]string{
+	"SetUniformVec2":      {"vec2"},
+	"SetUniformVec3":      {"vec3"},
+	"SetUniformVec3Array": {"vec3"},
+	"SetUniformFloat":     {"float"},
+	"SetUniformMat4":      {"mat4"},
+	"SetUniformMat4Array": {"mat4"},
+	"SetUniformInt32":     {"int", "bool", "sampler*"},
+}
+
+// call is a uniform the Go code sets.
+type call struct {
+	File, Setter, Receiver, Uniform string
+	Line                            int
+}
+
+// readGo finds the uniforms the Go code sets with a string literal, and which variables the shader files are
+// loaded into, by the base name of the file.
+func readGo(dir string) ([]call, map[string]string, error) {
+	var calls []call
+	bindings := make(map[string]string)
+	fset := token.NewFileSet()
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".go") {
+			return err
+		}
+
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.CallExpr:
+				if c, ok := setterCall(fset, n); ok {
+					calls = append(calls, c)
+				}
+			case *ast.AssignStmt:
+				// pointShader, err = createShader("../shaders/point.glsl")
+				if len(n.Rhs) == 1 && len(n.Lhs) > 0 {
+					if c, ok := n.Rhs[0].(*ast.CallExpr); ok && len(c.Args) > 0 {
+						bind(bindings, n.Lhs[0], c.Args[0])
+					}
+				}
+			case *ast.CompositeLit:
+				// {&pointShader, "../shaders/point.glsl"}
+				if len(n.Elts) == 2 {
+					if u, ok := n.Elts[0].(*ast.UnaryExpr); ok && u.Op == token.AND {
+						bind(bindings, u.X, n.Elts[1])
+					}
+				}
+			}
+			return true
+		})
+
+		return nil
+	})
+
+	return calls, bindings, err
+}
+
+// bind remembers the variable a shader file is loaded into.
+func bind(bindings map[string]string, variable, file ast.Expr) {
+	id, ok := variable.(*ast.Ident)
+	if !ok {
+		return
+	}
+	if f, ok := stringLit(file); ok && strings.HasSuffix(f, ".glsl") {
+		bindings[id.Name] = filepath.Base(f)
+	}
+}
+
+// setterCall returns the uniform set by a call like shader.SetUniformFloat("name", f).
+func setterCall(fset *token.FileSet, n *ast.CallExpr) (call, bool) {
+	sel, ok := n.Fun.(*ast.SelectorExpr)
+	if !ok || setterTypes[sel.Sel.Name] == nil || len(n.Args) != 2 {
+		return call{}, false
+	}
+	name, ok := stringLit(n.Args[0])
+	if !ok {
+		return call{}, false
+	}
+
+	receiver := ""
+	if id, ok := sel.X.(*ast.Ident); ok {
+		receiver = id.Name
+	}
+	pos := fset.Position(n.Pos())
+
+	return call{pos.Filename, sel.Sel.Name, receiver, name, pos.Line}, true
+}
+
+// stringLit returns the value of a string literal.
+func stringLit(e ast.Expr) (string, bool) {
+	lit, ok := e.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
+
+// element matches a uniform name with an index at the end, like "samples[3]".
+var element = regexp.MustCompile(`^(.*)\[(\d+)\]$`)
+
+// checkCalls checks that the uniforms are declared with a type the setter accepts. Calls on a variable a shader
+// file is loaded into are checked against that shader, others against all shaders.
+func checkCalls(shaders []*shader, calls []call, bindings map[string]string) []problem {
+	byBase := make(map[string]*shader)
+	for _, s := range shaders {
+		byBase[filepath.Base(s.File)] = s
+	}
+	for variable, base := range bindings {
+		if s, ok := byBase[base]; ok {
+			s.Variables = append(s.Variables, variable)
+		}
+	}
+	for _, s := range shaders {
+		sort.Strings(s.Variables)
+	}
+
+	var problems []problem
+	for _, c := range calls {
+		candidates := shaders
+		where := "any shader"
+		if s, ok := byBase[bindings[c.Receiver]]; ok {
+			candidates = []*shader{s}
+			where = s.File
+		}
+
+		var mismatch string
+		found := false
+		for _, s := range candidates {
+			u, ok := lookup(s, c.Uniform)
+			if !ok {
+				continue
+			}
+			if u.Block != "" {
+				mismatch = fmt.Sprintf("%v has %v in the uniform block %v, it can't be set on its own", s.File,
+					c.Uniform, u.Block)
+				continue
+			}
+			if accepts(c.Setter, u.Type) {
+				found, mismatch = true, ""
+				break
+			}
+			mismatch = fmt.Sprintf("%v declares %v as a %v, %v can't set it", s.File, c.Uniform, u.Type, c.Setter)
+		}
+
+		switch {
+		case mismatch != "":
+			problems = append(problems, problem{Error, c.File, c.Line, mismatch})
+		case !found:
+			problems = append(problems, problem{Error, c.File, c.Line,
+				fmt.Sprintf("%v sets the uniform %v, %v doesn't declare it", c.Setter, c.Uniform, where)})
+		}
+	}
+
+	return problems
+}
+
+// lookup returns a uniform, an element of an array is found by the name of the array.
+func lookup(s *shader, name string) (glsl.Var, bool) {
+	if u, ok := s.uniforms[name]; ok {
+		return u, true
+	}
+	if m := element.FindStringSubmatch(name); m != nil {
+		i, _ := strconv.Atoi(m[2])
+		if u, ok := s.uniforms[m[1]]; ok && i < u.Size {
+			return u, true
+		}
+	}
+
+	return glsl.Var{}, false
+}
+
+// accepts returns whether a setter can set a uniform of the type.
+func accepts(setter, t string) bool {
+	for _, want := range setterTypes[setter] {
+		if t == want || strings.HasSuffix(want, "*") && strings.HasPrefix(t, want[:len(want)-1]) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,109 @@
+// Command gophergl-shadercheck checks the shaders without a GPU. It reads every shader file with #vertex and
+// #fragment stages, and looks for:
+//
+//   - variables used with a . that aren't declared, like a struct uniform with a typo in its name
+//   - inputs of the fragment stage that the stage before doesn't output
+//   - uniforms the Go code sets that the shader doesn't declare, or declares with another type
+//
+// It prints a JSON report of the uniforms, structs and attributes of every shader, with the problems it found.
+// The exit status is 1 when there are errors, so it can be used before committing:
+//
+//	gophergl-shadercheck -shaders shaders -src src
+//
+// It doesn't evaluate #if, so everything behind an #ifdef counts as declared. The Go code is matched to the
+// shader files by the name of the file it loads.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Error is the severity of a problem that makes the check fail.
+const Error = "error"
+
+// problem is something wrong in a shader or in the Go code using it.
+type problem struct {
+	Severity string `json:"severity"`
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message"`
+}
+
+// report is what's printed.
+type report struct {
+	Shaders  []*shader `json:"shaders"`
+	Problems []problem `json:"problems"`
+	Errors   int       `json:"errors"`
+}
+
+func main() {
+	shaderDir := flag.String("shaders", "shaders", "the directory with the shader files")
+	srcDir := flag.String("src", "src", "the directory with the Go code that sets the uniforms")
+	indent := flag.Bool("indent", true, "indent the JSON")
+	flag.Parse()
+
+	r, err := check(*shaderDir, *srcDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gophergl-shadercheck:", err)
+		os.Exit(2)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	if *indent {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(r); err != nil {
+		fmt.Fprintln(os.Stderr, "gophergl-shadercheck:", err)
+		os.Exit(2)
+	}
+
+	if r.Errors > 0 {
+		os.Exit(1)
+	}
+}
+
+// check reads the shaders and the Go code, and cross-checks them.
+func check(shaderDir, srcDir string) (*report, error) {
+	files, err := filepath.Glob(filepath.Join(shaderDir, "*.glsl"))
+	if err != nil {
+		return nil, err
+	}
+
+	r := &report{Shaders: []*shader{}, Problems: []problem{}}
+	for _, f := range files {
+		s, problems, err := readShader(f)
+		if err != nil {
+			return nil, err
+		}
+		if s != nil {
+			r.Shaders = append(r.Shaders, s)
+		}
+		r.Problems = append(r.Problems, problems...)
+	}
+
+	calls, bindings, err := readGo(srcDir)
+	if err != nil {
+		return nil, err
+	}
+	r.Problems = append(r.Problems, checkCalls(r.Shaders, calls, bindings)...)
+
+	sort.SliceStable(r.Problems, func(i, j int) bool {
+		a, b := r.Problems[i], r.Problems[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	for _, p := range r.Problems {
+		if p.Severity == Error {
+			r.Errors++
+		}
+	}
+
+	return r, nil
+}
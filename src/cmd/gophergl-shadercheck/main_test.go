@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestMain runs the command instead of the tests when SHADERCHECK_ARGS is set, so the tests can see its exit status.
+func TestMain(m *testing.M) {
+	if args := os.Getenv("SHADERCHECK_ARGS"); args != "" {
+		os.Args = append([]string{"gophergl-shadercheck"}, strings.Fields(args)...)
+		main()
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+// run runs the command on a directory in testdata, and returns the report and the exit status.
+func run(t *testing.T, dir string) (*report, int) {
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	cmd.Env = append(os.Environ(), "SHADERCHECK_ARGS=-shaders "+filepath.Join("testdata", dir, "shaders")+
+		" -src "+filepath.Join("testdata", dir, "src"))
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	status := 0
+	if err := cmd.Run(); err != nil {
+		exit, ok := err.(*exec.ExitError)
+		if !ok {
+			t.Fatal(err)
+		}
+		status = exit.ExitCode()
+	}
+
+	r := &report{}
+	if err := json.Unmarshal(stdout.Bytes(), r); err != nil {
+		t.Fatalf("%v in %q", err, stdout.String())
+	}
+
+	return r, status
+}
+
+func TestProblems(t *testing.T) {
+	shader := filepath.FromSlash("testdata/bad/shaders/bad.glsl")
+	code := filepath.FromSlash("testdata/bad/src/bad.go")
+
+	// The problems are sorted by file, shaders comes before src.
+	r, status := run(t, "bad")
+	want := []problem{
+		{Error, shader, 14, "the fragment stage reads normal, the stage before doesn't output it"},
+		{Error, shader, 22, "the fragment stage uses pl, which isn't declared"},
+		{Error, code, 6, shader + " declares model as a mat4, SetUniformFloat can't set it"},
+		{Error, code, 7, "SetUniformVec3 sets the uniform tint, " + shader + " doesn't declare it"},
+	}
+	if !reflect.DeepEqual(r.Problems, want) {
+		t.Errorf("got %+v\nwant %+v", r.Problems, want)
+	}
+	if r.Errors != 4 || status != 1 {
+		t.Errorf("got %v errors and exit status %v, want 4 and 1", r.Errors, status)
+	}
+}
+
+func TestNoProblems(t *testing.T) {
+	r, status := run(t, "good")
+	if len(r.Problems) != 0 || r.Errors != 0 || status != 0 {
+		t.Errorf("got %+v and exit status %v, want no problems and 0", r.Problems, status)
+	}
+
+	if len(r.Shaders) != 1 {
+		t.Fatalf("got %v shaders, want 1", len(r.Shaders))
+	}
+	s := r.Shaders[0]
+	if !reflect.DeepEqual(s.Variables, []string{"goodShader"}) || len(s.Attributes) != 1 || len(s.Uniforms) != 2 {
+		t.Errorf("the shader is %+v", s)
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"GopherGL/src/glsl"
+)
+
+// variable is a uniform, attribute or struct field in the report.
+type variable struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Size     int    `json:"size,omitempty"`
+	Location *int   `json:"location,omitempty"`
+	Block    string `json:"block,omitempty"`
+}
+
+// shader is what a shader file declares.
+type shader struct {
+	File       string                `json:"file"`
+	Uniforms   []variable            `json:"uniforms"`
+	Attributes []variable            `json:"attributes"`
+	Structs    map[string][]variable `json:"structs"`
+	// Variables are the Go variables the shader is loaded into.
+	Variables []string `json:"goVariables,omitempty"`
+
+	uniforms map[string]glsl.Var
+}
+
+var (
+	// stageMarker finds the files with stages, the others are included.
+	stageMarker  = regexp.MustCompile(`(?m)^\s*#vertex\s*$`)
+	lineComment  = regexp.MustCompile(`//[^\n]*`)
+	blockComment = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	directive    = regexp.MustCompile(`(?m)^\s*#[^\n]*`)
+	// interfaceVar is an in or out variable of a stage.
+	interfaceVar = regexp.MustCompile(`(?m)^\s*(?:layout\s*\([^)]*\)\s*)?(?:(?:flat|smooth|noperspective)\s+)?(in|out)\s+(?:(?:flat|smooth|noperspective)\s+)?(\w+)\s+(\w+)\s*(\[[^\]]*\])?\s*;`)
+	// memberAccess is a variable followed by a ., like "light.pos".
+	memberAccess = regexp.MustCompile(`([A-Za-z_]\w*)\s*\.\s*[A-Za-z_]`)
+)
+
+// builtinTypes are the types a variable can be declared with, besides structs.
+var builtinTypes = []string{
+	"void", "bool", "int", "uint", "float", "double",
+	"vec2", "vec3", "vec4", "bvec2", "bvec3", "bvec4", "ivec2", "ivec3", "ivec4", "uvec2", "uvec3", "uvec4",
+	"dvec2", "dvec3", "dvec4", "mat2", "mat3", "mat4", "mat2x2", "mat2x3", "mat2x4", "mat3x2", "mat3x3",
+	"mat3x4", "mat4x2", "mat4x3", "mat4x4", "sampler1D", "sampler2D", "sampler3D", "samplerCube",
+	"sampler2DShadow", "samplerCubeShadow", "sampler2DArray", "sampler2DArrayShadow", "isampler2D",
+	"usampler2D", "sampler2DMS", "samplerBuffer",
+}
+
+// readShader reads a shader file, it returns nil for files without stages, which are only included.
+func readShader(file string) (*shader, []problem, error) {
+	src, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !stageMarker.Match(src) {
+		return nil, nil, nil
+	}
+
+	p, err := glsl.Load(file)
+	if err != nil {
+		if e, ok := err.(*glsl.Error); ok {
+			return nil, []problem{{Error, e.Origin.File, e.Origin.Line, e.Err.Error()}}, nil
+		}
+		return nil, nil, err
+	}
+
+	s := &shader{File: file, Structs: make(map[string][]variable), uniforms: make(map[string]glsl.Var)}
+	var problems []problem
+	var previous []glsl.Var
+
+	for _, st := range []struct {
+		name  string
+		stage *glsl.Stage
+	}{{"vertex", p.Vertex}, {"geometry", p.Geometry}, {"fragment", p.Fragment}} {
+		if st.stage == nil {
+			continue
+		}
+
+		uniforms, _, err := glsl.Declarations(st.stage.Source)
+		if err != nil {
+			return nil, []problem{{Error, file, 0, fmt.Sprintf("%v stage: %v", st.name, err)}}, nil
+		}
+		for _, u := range uniforms {
+			if _, ok := s.uniforms[u.Name]; !ok {
+				s.uniforms[u.Name] = u
+				s.Uniforms = append(s.Uniforms, toVariable(u))
+			}
+		}
+
+		structs, err := glsl.Structs(st.stage.Source)
+		if err != nil {
+			return nil, []problem{{Error, file, 0, fmt.Sprintf("%v stage: %v", st.name, err)}}, nil
+		}
+		for name, fields := range structs {
+			if _, ok := s.Structs[name]; ok {
+				continue
+			}
+			for _, f := range fields {
+				s.Structs[name] = append(s.Structs[name], toVariable(f))
+			}
+		}
+
+		source := stripComments(st.stage.Source)
+		ins, outs := interfaceVars(source)
+		if st.name == "vertex" {
+			for _, in := range ins {
+				s.Attributes = append(s.Attributes, toVariable(in))
+			}
+		} else {
+			problems = append(problems, checkInputs(st.name, st.stage, ins, previous)...)
+		}
+		previous = outs
+
+		problems = append(problems, checkAccess(st.name, st.stage, source, structs)...)
+	}
+
+	return s, problems, nil
+}
+
+// toVariable converts a declaration for the report.
+func toVariable(v glsl.Var) variable {
+	out := variable{Name: v.Name, Type: v.Type, Size: v.Size, Block: v.Block}
+	if v.Location >= 0 {
+		loc := v.Location
+		out.Location = &loc
+	}
+
+	return out
+}
+
+// stripComments removes the comments and directives, but keeps the lines where they are.
+func stripComments(source string) string {
+	blank := func(s string) string {
+		return strings.Repeat("\n", strings.Count(s, "\n"))
+	}
+	source = blockComment.ReplaceAllStringFunc(source, blank)
+	source = lineComment.ReplaceAllString(source, "")
+	return directive.ReplaceAllString(source, "")
+}
+
+// lineAt returns the line of an offset in the source, counting from 1.
+func lineAt(source string, offset int) int {
+	return strings.Count(source[:offset], "\n") + 1
+}
+
+// interfaceVars returns the in and out variables of a stage, Location is the line they're on.
+func interfaceVars(source string) (ins, outs []glsl.Var) {
+	for _, m := range interfaceVar.FindAllStringSubmatchIndex(source, -1) {
+		v := glsl.Var{Name: source[m[6]:m[7]], Type: source[m[4]:m[5]], Size: 1, Location: lineAt(source, m[0])}
+		if source[m[2]:m[3]] == "in" {
+			ins = append(ins, v)
+		} else {
+			outs = append(outs, v)
+		}
+	}
+
+	return ins, outs
+}
+
+// checkInputs finds inputs the stage before doesn't output, or outputs with another type.
+func checkInputs(stage string, s *glsl.Stage, ins, previous []glsl.Var) []problem {
+	outs := make(map[string]glsl.Var)
+	for _, o := range previous {
+		outs[o.Name] = o
+	}
+
+	var problems []problem
+	for _, in := range ins {
+		o, _ := s.Origin(in.Location)
+		out, ok := outs[in.Name]
+		switch {
+		case !ok:
+			problems = append(problems, problem{Error, o.File, o.Line,
+				fmt.Sprintf("the %v stage reads %v, the stage before doesn't output it", stage, in.Name)})
+		case out.Type != in.Type:
+			problems = append(problems, problem{Error, o.File, o.Line,
+				fmt.Sprintf("the %v stage reads %v as a %v, the stage before outputs a %v", stage, in.Name, in.Type, out.Type)})
+		}
+	}
+
+	return problems
+}
+
+// checkAccess finds variables used with a . that aren't declared anywhere in the stage. Scopes are ignored, a
+// variable declared in one function counts as declared in all of them.
+func checkAccess(stage string, s *glsl.Stage, source string, structs map[string][]glsl.Var) []problem {
+	types := append([]string(nil), builtinTypes...)
+	for name := range structs {
+		types = append(types, name)
+	}
+	declaration := regexp.MustCompile(`\b(?:` + strings.Join(types, "|") + `)\s+(\w+)`)
+
+	declared := make(map[string]bool)
+	for _, m := range declaration.FindAllStringSubmatch(source, -1) {
+		declared[m[1]] = true
+	}
+	// Lists like "vec3 a, b;" declare b too.
+	for _, m := range regexp.MustCompile(`,\s*(\w+)\s*(?:\[[^\]]*\]\s*)?[;,=]`).FindAllStringSubmatch(source, -1) {
+		declared[m[1]] = true
+	}
+
+	var problems []problem
+	reported := make(map[string]bool)
+	for _, m := range memberAccess.FindAllStringSubmatchIndex(source, -1) {
+		// What comes after a . is a field, not a variable.
+		if before := strings.TrimRight(source[:m[2]], " \t\n"); strings.HasSuffix(before, ".") || m[2] > 0 && isWord(source[m[2]-1]) {
+			continue
+		}
+		name := source[m[2]:m[3]]
+		if declared[name] || strings.HasPrefix(name, "gl_") || reported[name] {
+			continue
+		}
+		reported[name] = true
+
+		o, _ := s.Origin(lineAt(source, m[2]))
+		problems = append(problems, problem{Error, o.File, o.Line,
+			fmt.Sprintf("the %v stage uses %v, which isn't declared", stage, name)})
+	}
+
+	return problems
+}
+
+// isWord returns whether the byte can be part of a name.
+func isWord(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
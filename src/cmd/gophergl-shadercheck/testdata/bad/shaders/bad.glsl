@@ -0,0 +1,23 @@
+#vertex
+#version 330 core
+layout (location = 0) in vec3 aPos;
+out vec2 uv;
+uniform mat4 model;
+void main() {
+	gl_Position = model * vec4(aPos, 1.0);
+	uv = aPos.xy;
+}
+
+#fragment
+#version 330 core
+in vec2 uv;
+in vec3 normal;
+out vec4 color;
+struct Light {
+	vec3 color;
+};
+uniform Light light;
+void main() {
+	// pl was renamed to light.
+	color = vec4(light.color * pl.color * normal, uv.x);
+}
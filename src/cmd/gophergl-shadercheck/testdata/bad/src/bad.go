@@ -0,0 +1,8 @@
+package fixture
+
+func load() {
+	badShader, err = createShader("../shaders/bad.glsl")
+	badShader.SetUniformMat4("model", model)
+	badShader.SetUniformFloat("model", 1.0)
+	badShader.SetUniformVec3("tint", tint)
+}
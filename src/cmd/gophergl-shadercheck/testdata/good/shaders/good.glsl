@@ -0,0 +1,18 @@
+#vertex
+#version 330 core
+layout (location = 0) in vec3 aPos;
+out vec2 uv;
+uniform mat4 model;
+void main() {
+	gl_Position = model * vec4(aPos, 1.0);
+	uv = aPos.xy;
+}
+
+#fragment
+#version 330 core
+in vec2 uv;
+out vec4 color;
+uniform sampler2D tex;
+void main() {
+	color = texture(tex, uv);
+}
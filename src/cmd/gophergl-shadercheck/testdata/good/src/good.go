@@ -0,0 +1,7 @@
+package fixture
+
+func load() {
+	goodShader, err = createShader("../shaders/good.glsl")
+	goodShader.SetUniformMat4("model", model)
+	goodShader.SetUniformInt32("tex", 0)
+}
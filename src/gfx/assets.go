@@ -0,0 +1,404 @@
+package gfx
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"GopherGL/src/glsl"
+	"GopherGL/src/gltf"
+)
+
+// asset is something loaded from a file that's shared, see Handle.
+type asset struct {
+	key, kind, file, options string
+	// refs is how many handles weren't released, it's only used on the GL thread.
+	refs  int
+	bytes int64
+	value interface{}
+	// free deletes the asset, it's nil when the asset isn't the cache's to delete.
+	free func()
+}
+
+var (
+	// assets are the loaded assets by their key, see assetKey.
+	assets = make(map[string]*asset)
+	// assetsMu guards assets, loads on other goroutines look in it.
+	assetsMu sync.Mutex
+)
+
+// assetKey is the kind, the absolute path and the options of an asset. The same file loaded with other options is
+// another asset.
+func assetKey(kind, file, options string) (string, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+	return kind + " " + abs + " " + options, nil
+}
+
+// assetLoaded returns whether the asset is loaded, so a load on another goroutine can skip reading it.
+func assetLoaded(kind, file, options string) bool {
+	key, err := assetKey(kind, file, options)
+	if err != nil {
+		return false
+	}
+
+	assetsMu.Lock()
+	defer assetsMu.Unlock()
+	_, ok := assets[key]
+	return ok
+}
+
+// acquire returns a handle to the asset, calling load when it isn't loaded yet. load returns the asset, about how
+// much memory it uses and how to free it.
+func acquire(kind, file, options string, load func() (interface{}, int64, func(), error)) (*Handle, error) {
+	key, err := assetKey(kind, file, options)
+	if err != nil {
+		return nil, err
+	}
+
+	assetsMu.Lock()
+	a, ok := assets[key]
+	assetsMu.Unlock()
+	if ok {
+		a.refs++
+		return &Handle{a}, nil
+	}
+
+	value, bytes, free, err := load()
+	if err != nil {
+		return nil, err
+	}
+	a = &asset{key: key, kind: kind, file: file, options: options, refs: 1, bytes: bytes, value: value, free: free}
+	assetsMu.Lock()
+	assets[key] = a
+	assetsMu.Unlock()
+
+	return &Handle{a}, nil
+}
+
+// Handle is a reference to an asset that's loaded once, however many times it's acquired. Release it when it
+// isn't used anymore, the asset is freed when the last handle to it is released.
+type Handle struct {
+	a *asset
+}
+
+// Acquire returns another handle to the asset, for something else that uses it. It's nil when the handle was
+// already released.
+func (h *Handle) Acquire() *Handle {
+	if h.a == nil {
+		return nil
+	}
+	h.a.refs++
+	return &Handle{h.a}
+}
+
+// Release lets go of the asset, it can't be used with this handle after this. Releasing twice does nothing.
+func (h *Handle) Release() {
+	a := h.a
+	if a == nil {
+		return
+	}
+	h.a = nil
+
+	a.refs--
+	if a.refs > 0 {
+		return
+	}
+	assetsMu.Lock()
+	delete(assets, a.key)
+	assetsMu.Unlock()
+	if a.free != nil {
+		a.free()
+	}
+}
+
+// TextureHandle is a handle to a texture, see AcquireTexture.
+type TextureHandle struct {
+	Handle
+}
+
+// Texture returns the texture, until the handle is released. Don't Delete it, release the handle.
+func (h *TextureHandle) Texture() *Texture {
+	return h.a.value.(*Texture)
+}
+
+// AcquireTexture is LoadTexture, but a file that's already loaded with the same options isn't loaded again. With
+// hot reloading on the texture is uploaded again when its file changes.
+func AcquireTexture(file string, o TextureOptions) (*TextureHandle, error) {
+	return acquireTexture(file, o, nil)
+}
+
+// acquireTexture is AcquireTexture with the file already read, or d is nil to read it.
+func acquireTexture(file string, o TextureOptions, d *textureData) (*TextureHandle, error) {
+	h, err := acquire("texture", file, textureKey(o), func() (interface{}, int64, func(), error) {
+		var err error
+		if d == nil {
+			if d, err = readTexture(file, o, device.SupportsFormat); err != nil {
+				return nil, 0, nil, err
+			}
+		}
+		t, err := d.upload()
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		return t, t.bytes, t.Delete, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if h.a.refs == 1 {
+		// It was just loaded. It stops reloading when it's freed.
+		a := h.a
+		stop := OnChange(file, func() error { return reloadTextureAsset(a, o) })
+		free := a.free
+		a.free = func() {
+			stop()
+			free()
+		}
+	}
+	return &TextureHandle{*h}, nil
+}
+
+// textureKey is the part of the key of a texture asset for the options.
+func textureKey(o TextureOptions) string {
+	return fmt.Sprintf("%+v", o)
+}
+
+// reloadTextureAsset uploads a texture again and swaps it in, so everything using it gets the new one. The old one
+// stays when the file can't be read.
+func reloadTextureAsset(a *asset, o TextureOptions) error {
+	if a.refs == 0 {
+		// It was released by something else reloading in the same frame.
+		return nil
+	}
+
+	tex, err := LoadTexture(a.file, o)
+	if err != nil {
+		return err
+	}
+	t := a.value.(*Texture)
+	device.DeleteTexture(t.id)
+	tex.sampler = t.sampler
+	*t = *tex
+	a.bytes = t.bytes
+
+	return nil
+}
+
+// splitRef splits a file like "props.json#crate" into the file and the name after the #. It's false when there's
+// no #.
+func splitRef(file string) (string, string, bool) {
+	for i := len(file) - 1; i >= 0 && file[i] != '/' && file[i] != filepath.Separator; i-- {
+		if file[i] == '#' {
+			return file[:i], file[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+// AtlasHandle is a handle to an atlas, see AcquireAtlas.
+type AtlasHandle struct {
+	Handle
+}
+
+// Atlas returns the atlas, until the handle is released.
+func (h *AtlasHandle) Atlas() *Atlas {
+	return h.a.value.(*Atlas)
+}
+
+// AcquireAtlas is LoadAtlas with AtlasTextureOptions, but an atlas that's already loaded isn't loaded again.
+// Materials using regions of an atlas file share it like this.
+func AcquireAtlas(file string) (*AtlasHandle, error) {
+	return acquireAtlas(file, nil)
+}
+
+// acquireAtlas is AcquireAtlas with the atlas already read, or d is nil to read it.
+func acquireAtlas(file string, d *atlasData) (*AtlasHandle, error) {
+	h, err := acquire("atlas", file, "", func() (interface{}, int64, func(), error) {
+		var err error
+		if d == nil {
+			if d, err = readAtlas(file, AtlasTextureOptions(), device.SupportsFormat); err != nil {
+				return nil, 0, nil, err
+			}
+		}
+		a, err := d.upload()
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		var bytes int64
+		for _, t := range a.pages {
+			bytes += t.bytes
+		}
+		return a, bytes, a.Delete, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &AtlasHandle{*h}, nil
+}
+
+// MeshHandle is a handle to a mesh, see AcquireMesh.
+type MeshHandle struct {
+	Handle
+}
+
+// Mesh returns the mesh, until the handle is released. Draw it with CreateEntityFromMesh.
+func (h *MeshHandle) Mesh() *Mesh {
+	return h.a.value.(*Mesh)
+}
+
+// AcquireMesh loads a mesh of a glTF file and uploads it, once for everything acquiring it. A file like
+// "robot.glb#arm" is the mesh called arm, without a # it's the first mesh. The error is a *NotFoundError or
+// *DecodeError for the file, or says the mesh isn't there.
+func AcquireMesh(file string) (*MeshHandle, error) {
+	h, err := acquire("mesh", file, "", func() (interface{}, int64, func(), error) {
+		path, name, named := splitRef(file)
+		if !named {
+			path = file
+		}
+		f, err := gltf.Load(path)
+		if err != nil {
+			return nil, 0, nil, fileError(path, err)
+		}
+
+		i := 0
+		if named {
+			if i = f.MeshIndex(name); i < 0 {
+				return nil, 0, nil, fmt.Errorf("%v: there's no mesh %q", path, name)
+			}
+		}
+		m, err := f.Mesh(i)
+		if err != nil {
+			return nil, 0, nil, &DecodeError{File: path, Err: err}
+		}
+		gm, err := createMesh(m)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("%v: %v", file, err)
+		}
+		return gm, gm.bytes, gm.delete, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &MeshHandle{*h}, nil
+}
+
+// ShaderHandle is a handle to a shader, see AcquireShader.
+type ShaderHandle struct {
+	Handle
+}
+
+// Shader returns the shader, until the handle is released.
+func (h *ShaderHandle) Shader() *Shader {
+	return h.a.value.(*Shader)
+}
+
+// AcquireShader compiles the variant of a shader file with the features, once for everything acquiring it. The
+// shaders of the renderer, like basic.glsl, are shared but never freed. The error is a *NotFoundError,
+// *DecodeError, *CompileError or *LinkError.
+func AcquireShader(file string, features ...string) (*ShaderHandle, error) {
+	return acquireShader(file, features, nil)
+}
+
+// acquireShader is AcquireShader with the file already preprocessed, or p is nil to do it.
+func acquireShader(file string, features []string, p *glsl.Program) (*ShaderHandle, error) {
+	key := glsl.VariantKey(features)
+	h, err := acquire("shader", file, key, func() (interface{}, int64, func(), error) {
+		var err error
+		existing := findShader(file)
+		if existing != nil {
+			if v, ok := existing.variants[key]; ok {
+				// Compiled by something else, like the renderer.
+				return v, 0, nil, nil
+			}
+		} else if p == nil {
+			if p, err = preprocess(file, features); err != nil {
+				return nil, 0, nil, err
+			}
+		}
+
+		s, err := loadShader(file, features, p)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		return s, 0, s.delete, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ShaderHandle{*h}, nil
+}
+
+// MaterialHandle is a handle to a material, see AcquireMaterial.
+type MaterialHandle struct {
+	Handle
+}
+
+// Material returns the material, until the handle is released. Don't Delete it, release the handle.
+func (h *MaterialHandle) Material() *Material {
+	return h.a.value.(*Material)
+}
+
+// AcquireMaterial is LoadMaterial, but a material file that's already loaded isn't loaded again. Its textures and
+// shader are acquired too, so materials using the same image share one texture.
+func AcquireMaterial(file string) (*MaterialHandle, error) {
+	h, err := acquire("material", file, "", func() (interface{}, int64, func(), error) {
+		m, err := LoadMaterial(file)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		return m, 0, m.Delete, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &MaterialHandle{*h}, nil
+}
+
+// AssetInfo is an asset that's loaded, see LoadedAssets.
+type AssetInfo struct {
+	// Kind is "texture", "atlas", "mesh", "shader" or "material".
+	Kind string
+	File string
+	// Options are what the asset was loaded with, like the texture options or the shader features.
+	Options string
+	// Refs is how many handles to it weren't released.
+	Refs int
+	// Bytes estimates the memory it uses on the GPU. It's 0 for shaders and materials, the textures of a material
+	// are assets of their own.
+	Bytes int64
+}
+
+// LoadedAssets lists the assets that are loaded, by kind and file.
+func LoadedAssets() []AssetInfo {
+	assetsMu.Lock()
+	list := make([]AssetInfo, 0, len(assets))
+	for _, a := range assets {
+		list = append(list, AssetInfo{a.kind, a.file, a.options, a.refs, a.bytes})
+	}
+	assetsMu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Kind != list[j].Kind {
+			return list[i].Kind < list[j].Kind
+		}
+		if list[i].File != list[j].File {
+			return list[i].File < list[j].File
+		}
+		return list[i].Options < list[j].Options
+	})
+	return list
+}
+
+// AssetBytes returns the memory all loaded assets use, see AssetInfo.Bytes.
+func AssetBytes() int64 {
+	var bytes int64
+	for _, a := range LoadedAssets() {
+		bytes += a.Bytes
+	}
+	return bytes
+}
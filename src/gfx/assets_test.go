@@ -0,0 +1,77 @@
+package gfx
+
+import (
+	"testing"
+)
+
+func TestTextureAssetStopsWatching(t *testing.T) {
+	const file = "../res/containerSpec.png"
+	before := watching(t, file)
+
+	h, err := AcquireTexture(file, DefaultTextureOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, err := AcquireTexture(file, DefaultTextureOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := watching(t, file); n != before+1 {
+		t.Errorf("%v reloaders, want %v for both handles", n, before+1)
+	}
+
+	h.Release()
+	if n := watching(t, file); n != before+1 {
+		t.Errorf("%v reloaders while a handle is left, want %v", n, before+1)
+	}
+	h2.Release()
+	if n := watching(t, file); n != before {
+		t.Errorf("%v reloaders after the texture was freed, want %v", n, before)
+	}
+}
+
+func TestMaterialStopsWatching(t *testing.T) {
+	files := []string{testdata + "container.mat.json", "../res/containerTex.png", "../res/containerSpec.png"}
+	before := make([]int, len(files))
+	for i, file := range files {
+		before[i] = watching(t, file)
+	}
+
+	// Loading it again in between doesn't leave the old reloaders behind.
+	for i := 0; i < 3; i++ {
+		h, err := AcquireMaterial(files[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := h.Material().reload(); err != nil {
+			t.Fatal(err)
+		}
+		if n := watching(t, files[0]); n != before[0]+1 {
+			t.Errorf("the material has %v reloaders, want %v", n, before[0]+1)
+		}
+		h.Release()
+	}
+
+	for i, file := range files {
+		if n := watching(t, file); n != before[i] {
+			t.Errorf("%v has %v reloaders after the material was deleted, want %v", file, n, before[i])
+		}
+	}
+}
+
+func TestAcquireReleased(t *testing.T) {
+	h, err := AcquireTexture("../res/containerTex.png", DefaultTextureOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2 := h.Acquire()
+	h.Release()
+	h.Release()
+	if h.Acquire() != nil {
+		t.Errorf("a released handle acquired the texture")
+	}
+	if h2.a == nil || h2.a.refs != 1 {
+		t.Errorf("the other handle lost the texture")
+	}
+	h2.Release()
+}
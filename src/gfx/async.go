@@ -0,0 +1,308 @@
+package gfx
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"GopherGL/src/mesh"
+)
+
+// Load is an asset loading in the background. The files are read and decoded on other goroutines, then BeginFrame
+// uploads what's ready on the GL thread, for as long as the upload budget allows. Everything on the GPU has to be
+// made on the thread CreateWindow locked, so the load is only done after an upload in BeginFrame, or FinishLoads.
+type Load struct {
+	file string
+
+	mu sync.Mutex
+	// parts are the steps of the load, read is how many of them were done. The upload is one more.
+	parts, read int
+	done        bool
+	err         error
+}
+
+// File returns what's being loaded.
+func (l *Load) File() string {
+	return l.file
+}
+
+// Done returns whether the load is done, or failed.
+func (l *Load) Done() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.done
+}
+
+// Err returns why the load failed, nil while it's loading or when it worked.
+func (l *Load) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// Progress returns how much of the load is done, from 0 to 1.
+func (l *Load) Progress() float32 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.done {
+		return 1.0
+	}
+	return float32(l.read) / float32(l.parts+1)
+}
+
+// setRead sets how many of the parts were read, on the goroutine reading them.
+func (l *Load) setRead(read, parts int) {
+	l.mu.Lock()
+	l.read, l.parts = read, parts
+	l.mu.Unlock()
+}
+
+// finish marks the load as done, on the GL thread.
+func (l *Load) finish(err error) {
+	l.mu.Lock()
+	l.read, l.done, l.err = l.parts, true, err
+	l.mu.Unlock()
+}
+
+var (
+	// uploadBudget is how long BeginFrame uploads, see SetUploadBudget.
+	uploadBudget = 4 * time.Millisecond
+
+	// uploads are what the GL thread still has to do, added by the goroutines reading the files.
+	uploads   []func()
+	uploadsMu sync.Mutex
+	// uploadReady has a value when an upload was added, for FinishLoads to wait on.
+	uploadReady = make(chan struct{}, 1)
+
+	// readers limits the files read at the same time to the number of CPUs.
+	readers = make(chan struct{}, runtime.NumCPU())
+
+	// pendingLoads are the loads that aren't done. loadsStarted and loadsDone count the loads since the last time
+	// nothing was loading, for LoadingProgress. They're only used on the GL thread.
+	pendingLoads, loadsStarted, loadsDone int
+)
+
+// SetUploadBudget sets how long BeginFrame spends uploading loaded assets every frame, 4 ms by default. At least
+// one is uploaded every frame, however long it takes.
+func SetUploadBudget(d time.Duration) {
+	uploadBudget = d
+}
+
+// startLoad calls read on another goroutine, and then the upload it returns on the GL thread. The upload isn't
+// called when reading fails.
+func startLoad(l *Load, read func() (upload func() error, err error)) {
+	if pendingLoads == 0 {
+		loadsStarted, loadsDone = 0, 0
+	}
+	pendingLoads++
+	loadsStarted++
+
+	go func() {
+		readers <- struct{}{}
+		upload, err := read()
+		<-readers
+
+		queueUpload(func() {
+			if err == nil {
+				err = upload()
+			}
+			l.finish(err)
+			pendingLoads--
+			loadsDone++
+		})
+	}()
+}
+
+// queueUpload adds an upload for the GL thread.
+func queueUpload(upload func()) {
+	uploadsMu.Lock()
+	uploads = append(uploads, upload)
+	uploadsMu.Unlock()
+
+	select {
+	case uploadReady <- struct{}{}:
+	default:
+	}
+}
+
+// nextUpload takes the oldest upload, nil when there's none.
+func nextUpload() func() {
+	uploadsMu.Lock()
+	defer uploadsMu.Unlock()
+	if len(uploads) == 0 {
+		return nil
+	}
+
+	upload := uploads[0]
+	uploads = uploads[1:]
+	return upload
+}
+
+// runUploads uploads what was loaded until the budget is used up, it's called on the GL thread by BeginFrame.
+func runUploads() {
+	start := time.Now()
+	for upload := nextUpload(); upload != nil; upload = nextUpload() {
+		upload()
+		if time.Since(start) >= uploadBudget {
+			return
+		}
+	}
+}
+
+// FinishLoads waits for every load to be done, and uploads them right away. Call it on the GL thread, when the
+// loading screen isn't needed.
+func FinishLoads() {
+	for pendingLoads > 0 {
+		if upload := nextUpload(); upload != nil {
+			upload()
+			continue
+		}
+		<-uploadReady
+	}
+}
+
+// LoadingProgress returns how many loads are done, of the ones started since nothing was loading.
+func LoadingProgress() (done, total int) {
+	return loadsDone, loadsStarted
+}
+
+// RenderLoadingProgress draws how many loads are done, in pixels from the top left of the screen. It draws nothing
+// when nothing is loading. Draw it last.
+func RenderLoadingProgress(x, y float32) {
+	if pendingLoads == 0 {
+		return
+	}
+
+	line := fmt.Sprintf("loading %v of %v", loadsDone+1, loadsStarted)
+	addTextBox(x, y, []string{line}, mgl32.Vec4{1.0, 1.0, 1.0, 1.0})
+	flushRects()
+}
+
+// formatSupport asks the device which formats it supports now, so textures can be read on other goroutines.
+func formatSupport() func(TextureFormat) bool {
+	supported := make(map[TextureFormat]bool)
+	for f := range formatNames {
+		supported[f] = device.SupportsFormat(f)
+	}
+	return func(f TextureFormat) bool { return supported[f] }
+}
+
+// TextureLoad is a texture loading in the background.
+type TextureLoad struct {
+	Load
+	tex *Texture
+}
+
+// Texture returns the texture, nil until the load is done.
+func (l *TextureLoad) Texture() *Texture {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.tex
+}
+
+// LoadTextureAsync is LoadTexture in the background, the image is decoded on another goroutine. Call it on the GL
+// thread.
+func LoadTextureAsync(file string, o TextureOptions) *TextureLoad {
+	l := &TextureLoad{Load: Load{file: file}}
+	supports := formatSupport()
+	startLoad(&l.Load, func() (func() error, error) {
+		d, err := readTexture(file, o, supports)
+		if err != nil {
+			return nil, err
+		}
+		l.setRead(1, 1)
+
+		return func() error {
+			tex, err := d.upload()
+			l.mu.Lock()
+			l.tex = tex
+			l.mu.Unlock()
+			return err
+		}, nil
+	})
+
+	return l
+}
+
+// MaterialLoad is a material loading in the background.
+type MaterialLoad struct {
+	Load
+	mat *Material
+}
+
+// Material returns the material, nil until the load is done.
+func (l *MaterialLoad) Material() *Material {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.mat
+}
+
+// LoadMaterialAsync is LoadMaterial in the background. The material file and shader files are read, and the
+// textures decoded, on another goroutine. Only compiling the shader and uploading the textures is done on the GL
+// thread. Call it on the GL thread.
+func LoadMaterialAsync(file string) *MaterialLoad {
+	l := &MaterialLoad{Load: Load{file: file}}
+	supports := formatSupport()
+	startLoad(&l.Load, func() (func() error, error) {
+		d, err := readMaterialData(file, supports, func(done, total int) { l.setRead(done, total) })
+		if err != nil {
+			return nil, err
+		}
+		l.setRead(len(d.textures), len(d.textures))
+
+		return func() error {
+			m, err := d.create()
+			if err != nil {
+				return err
+			}
+			m.watch()
+
+			l.mu.Lock()
+			l.mat = m
+			l.mu.Unlock()
+			return nil
+		}, nil
+	})
+
+	return l
+}
+
+// EntityLoad is an entity whose mesh is loading in the background.
+type EntityLoad struct {
+	Load
+	e *Entity
+}
+
+// Entity returns the entity, nil until the load is done.
+func (l *EntityLoad) Entity() *Entity {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.e
+}
+
+// CreateEntityAsync is CreateEntity with a mesh that's loaded in the background: load is called on another
+// goroutine, to parse a mesh file for example. The name is what File returns. The material can be the placeholder
+// while the real one is loading, see Entity.SetMaterial. Call it on the GL thread.
+func CreateEntityAsync(name string, load func() (*mesh.Mesh, error), mat *Material) *EntityLoad {
+	l := &EntityLoad{Load: Load{file: name}}
+	startLoad(&l.Load, func() (func() error, error) {
+		m, err := load()
+		if err != nil {
+			return nil, err
+		}
+		l.setRead(1, 1)
+
+		return func() error {
+			e, err := CreateEntity(m, mat)
+			l.mu.Lock()
+			l.e = e
+			l.mu.Unlock()
+			return err
+		}, nil
+	})
+
+	return l
+}
@@ -0,0 +1,100 @@
+package gfx
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForUploads waits until n uploads are queued, the files are read on other goroutines.
+func waitForUploads(t *testing.T, n int) {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		uploadsMu.Lock()
+		queued := len(uploads)
+		uploadsMu.Unlock()
+		if queued >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("the loads weren't read in time")
+}
+
+func TestLoadTextureAsync(t *testing.T) {
+	recorder.Reset()
+	l := LoadTextureAsync("../res/containerTex.png", DefaultTextureOptions())
+
+	// Reading is done on another goroutine, but the texture is only made on the GL thread.
+	waitForUploads(t, 1)
+	if l.Done() || l.Texture() != nil || recorder.Count("CreateTexture") != 0 {
+		t.Fatalf("the texture was made before it was uploaded")
+	}
+
+	FinishLoads()
+	if !l.Done() || l.Err() != nil || l.Texture() == nil || l.Progress() != 1.0 {
+		t.Fatalf("after FinishLoads the load is done %v with %v and texture %v", l.Done(), l.Err(), l.Texture())
+	}
+	if n := recorder.Count("CreateTexture"); n != 1 {
+		t.Errorf("%v textures were made, want 1", n)
+	}
+	l.Texture().Delete()
+}
+
+func TestLoadAsyncError(t *testing.T) {
+	recorder.Reset()
+	l := LoadTextureAsync("../res/nothere.png", DefaultTextureOptions())
+	FinishLoads()
+
+	if !l.Done() || l.Err() == nil || !strings.Contains(l.Err().Error(), "nothere.png") {
+		t.Errorf("the load is done %v with %v, want an error about the file", l.Done(), l.Err())
+	}
+	if l.Texture() != nil || recorder.Count("CreateTexture") != 0 {
+		t.Errorf("a texture was made for a file that couldn't be read")
+	}
+}
+
+func TestUploadBudget(t *testing.T) {
+	defer SetUploadBudget(uploadBudget)
+	SetUploadBudget(0)
+
+	a := LoadTextureAsync("../res/containerTex.png", DefaultTextureOptions())
+	b := LoadTextureAsync("../res/containerSpec.png", DefaultTextureOptions())
+	waitForUploads(t, 2)
+
+	// Without a budget every frame still uploads one.
+	runUploads()
+	if a.Done() == b.Done() {
+		t.Errorf("the first frame uploaded %v and %v, want one of them", a.Done(), b.Done())
+	}
+	runUploads()
+	if !a.Done() || !b.Done() {
+		t.Errorf("the second frame didn't upload the other one")
+	}
+	a.Texture().Delete()
+	b.Texture().Delete()
+}
+
+func TestLoadingProgress(t *testing.T) {
+	var loads []*TextureLoad
+	for _, file := range []string{"../res/containerTex.png", "../res/containerSpec.png"} {
+		loads = append(loads, LoadTextureAsync(file, DefaultTextureOptions()))
+	}
+	if done, total := LoadingProgress(); done != 0 || total != 2 {
+		t.Errorf("%v of %v are done after starting, want 0 of 2", done, total)
+	}
+	FinishLoads()
+	if done, total := LoadingProgress(); done != 2 || total != 2 {
+		t.Errorf("%v of %v are done after FinishLoads, want 2 of 2", done, total)
+	}
+
+	// Nothing was loading, so the counts start over.
+	loads = append(loads, LoadTextureAsync("../res/containerTex.png", DefaultTextureOptions()))
+	if done, total := LoadingProgress(); done != 0 || total != 1 {
+		t.Errorf("%v of %v are done after starting again, want 0 of 1", done, total)
+	}
+	FinishLoads()
+
+	for _, l := range loads {
+		l.Texture().Delete()
+	}
+}
@@ -0,0 +1,151 @@
+package gfx
+
+import (
+	"os"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"GopherGL/src/atlas"
+)
+
+// Atlas is a texture atlas on the GPU: the pages, and the regions of the images on them. Materials with textures
+// on the same page are drawn without binding another texture. Atlas textures can't repeat, so the meshes need
+// texture coordinates from 0 to 1.
+type Atlas struct {
+	pages   []*Texture
+	regions map[string]atlas.Region
+}
+
+// AtlasRegion is an image in an atlas. The texture coordinates of the image are Offset + uv * Scale, for uv from
+// 0 to 1.
+type AtlasRegion struct {
+	Texture       *Texture
+	Offset, Scale mgl32.Vec2
+}
+
+// AtlasTextureOptions returns the options for atlas pages: like DefaultTextureOptions, but clamped to the edge.
+// The pages of an atlas file are often sRGB, set SRGB for those.
+func AtlasTextureOptions() TextureOptions {
+	o := DefaultTextureOptions()
+	o.Wrap = ClampToEdge
+	return o
+}
+
+// LoadAtlas reads an atlas manifest, made with the gophergl-atlas command, and loads its pages. The error is a
+// *NotFoundError or *DecodeError for the manifest and the pages, or about the options.
+func LoadAtlas(file string, o TextureOptions) (*Atlas, error) {
+	d, err := readAtlas(file, o, device.SupportsFormat)
+	if err != nil {
+		return nil, err
+	}
+	return d.upload()
+}
+
+// atlasData is an atlas file with its pages read, see textureData.
+type atlasData struct {
+	regions map[string]atlas.Region
+	pages   []*textureData
+}
+
+// readAtlas reads the manifest and the pages of an atlas.
+func readAtlas(file string, o TextureOptions, supports func(TextureFormat) bool) (*atlasData, error) {
+	m, err := atlas.ReadManifest(file)
+	if os.IsNotExist(err) {
+		return nil, fileError(file, err)
+	} else if err != nil {
+		return nil, &DecodeError{File: file, Err: err}
+	}
+
+	d := &atlasData{regions: m.Regions}
+	for _, page := range m.Pages {
+		t, err := readTexture(page, o, supports)
+		if err != nil {
+			return nil, err
+		}
+		d.pages = append(d.pages, t)
+	}
+
+	return d, nil
+}
+
+// upload uploads the pages.
+func (d *atlasData) upload() (*Atlas, error) {
+	a := &Atlas{regions: d.regions}
+	for _, page := range d.pages {
+		t, err := page.upload()
+		if err != nil {
+			a.Delete()
+			return nil, err
+		}
+		a.pages = append(a.pages, t)
+	}
+
+	return a, nil
+}
+
+// CreateAtlas packs the images while the game runs and uploads the pages.
+func CreateAtlas(images []atlas.Image, ao atlas.Options, o TextureOptions) (*Atlas, error) {
+	packed, err := atlas.Pack(images, ao)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Atlas{regions: packed.Regions}
+	for _, page := range packed.Pages {
+		// The rows go from the bottom up, like LoadTexture flips them.
+		size := page.Bounds().Size()
+		stride := size.X * 4
+		pix := make([]uint8, len(page.Pix))
+		for y := 0; y < size.Y; y++ {
+			copy(pix[y*stride:(y+1)*stride], page.Pix[(size.Y-1-y)*page.Stride:])
+		}
+
+		t, err := CreateTexture(int32(size.X), int32(size.Y), pix, o)
+		if err != nil {
+			a.Delete()
+			return nil, err
+		}
+		a.pages = append(a.pages, t)
+	}
+
+	return a, nil
+}
+
+// Region returns where an image is, false when the atlas doesn't have it.
+func (a *Atlas) Region(name string) (AtlasRegion, bool) {
+	r, ok := a.regions[name]
+	if !ok {
+		return AtlasRegion{}, false
+	}
+
+	return AtlasRegion{
+		Texture: a.pages[r.Page],
+		Offset:  mgl32.Vec2{r.UV[0], r.UV[1]},
+		Scale:   mgl32.Vec2{r.UV[2] - r.UV[0], r.UV[3] - r.UV[1]},
+	}, true
+}
+
+// Names returns the names of the images in the atlas, sorted.
+func (a *Atlas) Names() []string {
+	names := make([]string, 0, len(a.regions))
+	for name := range a.regions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Pages returns the textures of the pages.
+func (a *Atlas) Pages() []*Texture {
+	return a.pages
+}
+
+// Delete frees the pages. Materials using them can't be drawn after this.
+func (a *Atlas) Delete() {
+	for _, t := range a.pages {
+		t.Delete()
+	}
+	a.pages = nil
+}
@@ -0,0 +1,108 @@
+package gfx
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+)
+
+// Target is a framebuffer to render to instead of the screen, for example when there is no window.
+type Target struct {
+	Width, Height      int32
+	fbo                uint32
+	colorTex, depthTex uint32
+}
+
+// CreateTarget returns a framebuffer with a color texture and a depth buffer.
+func CreateTarget(width, height int32) (*Target, error) {
+	t := &Target{Width: width, Height: height}
+
+	desc := TextureDesc{Width: width, Height: height, Format: RGBA8, MinFilter: Linear, MagFilter: Linear}
+	t.colorTex = device.CreateTexture(desc, nil)
+	desc.Format, desc.MinFilter, desc.MagFilter = Depth24Stencil8, Nearest, Nearest
+	t.depthTex = device.CreateTexture(desc, nil)
+
+	var err error
+	t.fbo, err = device.CreateFramebuffer(
+		FramebufferAttachment{ColorAttachment, t.colorTex},
+		FramebufferAttachment{DepthStencilAttachment, t.depthTex})
+	if err != nil {
+		device.DeleteTexture(t.colorTex)
+		device.DeleteTexture(t.depthTex)
+		return nil, fmt.Errorf("target of %vx%v: %v", width, height, err)
+	}
+
+	return t, nil
+}
+
+// Bind makes everything after it render to the target, until BindScreen is called.
+func (t *Target) Bind() {
+	device.BindFramebuffer(t.fbo)
+	device.SetViewport(0, 0, t.Width, t.Height)
+}
+
+// BindScreen makes everything after it render to the window again.
+func BindScreen(width, height int32) {
+	device.BindFramebuffer(0)
+	device.SetViewport(0, 0, width, height)
+}
+
+// Delete frees the framebuffer and its textures.
+func (t *Target) Delete() {
+	device.DeleteFramebuffer(t.fbo)
+	device.DeleteTexture(t.colorTex)
+	device.DeleteTexture(t.depthTex)
+}
+
+// CaptureFrame reads back what was rendered to the target, or to the window when the target is nil.
+func CaptureFrame(t *Target) (*image.RGBA, error) {
+	var fbo uint32
+	var width, height int32
+	if t != nil {
+		fbo, width, height = t.fbo, t.Width, t.Height
+	} else {
+		viewport := device.Viewport()
+		width, height = viewport[2], viewport[3]
+	}
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("can't capture a frame of %vx%v", width, height)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, int(width), int(height)))
+	if err := device.ReadPixels(fbo, 0, 0, width, height, img.Pix); err != nil {
+		return nil, err
+	}
+
+	// OpenGL has 0, 0 in the bottom left, images in the top left. The opposite of what readImage does.
+	flipRows(img.Pix, img.Stride, int(height))
+
+	return img, nil
+}
+
+// flipRows turns the rows of pixels upside down.
+func flipRows(pix []byte, stride, height int) {
+	row := make([]byte, stride)
+	for y := 0; y < height/2; y++ {
+		top := pix[y*stride : (y+1)*stride]
+		bottom := pix[(height-1-y)*stride : (height-y)*stride]
+		copy(row, top)
+		copy(top, bottom)
+		copy(bottom, row)
+	}
+}
+
+// SavePNG writes the image to a PNG file.
+func SavePNG(file string, img image.Image) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,348 @@
+package gfx
+
+import (
+	"fmt"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// device is what every draw call and resource in the package goes through, see UseDevice.
+var device Device
+
+// Device is everything the renderer needs from a graphics API. Resources are referred to by ids, 0 is none.
+// The OpenGL version is GLDevice, RecordingDevice is a fake one for tests.
+type Device interface {
+	// Buffers hold vertices, indices or instance data.
+	CreateBuffer(data interface{}, usage Usage) uint32
+	UpdateBuffer(id uint32, data interface{}, usage Usage)
+	DeleteBuffer(id uint32)
+
+	// Vertex arrays tell the vertex shader where its inputs are.
+	CreateVertexArray() uint32
+	BindVertexArray(id uint32)
+	SetVertexAttrib(buffer uint32, a VertexAttrib)
+	SetIndexBuffer(buffer uint32)
+	DeleteVertexArray(id uint32)
+
+	// Textures, data is nil or a slice matching the format.
+	CreateTexture(desc TextureDesc, data interface{}) uint32
+	// CreateTextureLevels uploads the mipmaps that are already made, the full size first. Compressed formats
+	// can only be created like this, and only when SupportsFormat.
+	CreateTextureLevels(desc TextureDesc, levels [][]byte) uint32
+	SupportsFormat(f TextureFormat) bool
+	SetTextureParams(id uint32, s SamplerDesc)
+	BindTexture(unit int, id uint32)
+	DeleteTexture(id uint32)
+	// Samplers replace the sampling parameters of the texture bound to the same unit, 0 unbinds the sampler.
+	CreateSampler(s SamplerDesc) uint32
+	BindSampler(unit int, id uint32)
+	DeleteSampler(id uint32)
+	// MaxAnisotropy is the most anisotropic filtering the GPU does, 1 when it can't.
+	MaxAnisotropy() float32
+
+	// Shader programs and their uniforms. Sources that don't compile give a *CompileError, programs that don't
+	// link a *LinkError.
+	CreateProgram(vertex, geometry, fragment string) (uint32, error)
+	DeleteProgram(id uint32)
+	// ActiveUniforms and ActiveAttribs are what the linked program uses, the compiler drops what isn't used.
+	ActiveUniforms(program uint32) []ActiveVar
+	ActiveAttribs(program uint32) []ActiveVar
+	// The uniform setters change the uniform of the program, which doesn't have to be in use.
+	SetUniformInt(program uint32, loc int32, i int32)
+	SetUniformFloat(program uint32, loc int32, f float32)
+	SetUniformVec2(program uint32, loc int32, v []mgl32.Vec2)
+	SetUniformVec3(program uint32, loc int32, v []mgl32.Vec3)
+	SetUniformMat4(program uint32, loc int32, m []mgl32.Mat4)
+	// Uniform blocks read their members from the buffer bound to their binding point.
+	UniformBlocks(program uint32) []UniformBlock
+	SetUniformBlockBinding(program uint32, block string, binding uint32)
+	BindUniformBuffer(binding uint32, buffer uint32)
+
+	// Framebuffers, 0 is the screen.
+	CreateFramebuffer(attachments ...FramebufferAttachment) (uint32, error)
+	BindFramebuffer(id uint32)
+	Framebuffer() uint32
+	DeleteFramebuffer(id uint32)
+	ReadPixels(framebuffer uint32, x, y, width, height int32, pix []uint8) error
+
+	// Queries read back a GPU timestamp in nanoseconds, once the commands before it are done.
+	CreateQuery() uint32
+	QueryTimestamp(id uint32)
+	QueryResult(id uint32) (uint64, bool)
+	DeleteQuery(id uint32)
+
+	// State and drawing.
+	SetPipeline(p *Pipeline)
+	SetViewport(x, y, width, height int32)
+	Viewport() [4]int32
+	SetClearColor(c mgl32.Vec4)
+	Clear(flags ClearFlags)
+	Draw(mode Primitive, first, count int32)
+	DrawIndexed(mode Primitive, count int32)
+	DrawInstanced(mode Primitive, first, count, instances int32)
+}
+
+// UseDevice makes the package draw with the device. Call it before InitRenderer, which uses OpenGL otherwise.
+func UseDevice(d Device) {
+	if d == nil {
+		device = nil
+		return
+	}
+	device = wrapStats(d)
+}
+
+// CurrentDevice returns the device the package draws with.
+func CurrentDevice() Device {
+	if s, ok := device.(*statsDevice); ok {
+		return s.Device
+	}
+	return device
+}
+
+// Resize sets the viewport to the new size of the screen.
+func Resize(width, height int32) {
+	if device != nil {
+		device.SetViewport(0, 0, width, height)
+	}
+}
+
+// ActiveVar is a uniform or attribute of a linked program.
+type ActiveVar struct {
+	Name string
+	// Type is the GLSL type, like "vec3" or "sampler2D".
+	Type string
+	// Size is the length of an array, 1 for other variables.
+	Size     int32
+	Location int32
+	// Block is the uniform block the uniform is in, Offset where in the block in bytes. Offset is -1 when it
+	// isn't known, or the uniform isn't in a block.
+	Block  string
+	Offset int32
+}
+
+// UniformBlock is a uniform block of a linked program, Size is in bytes or -1 when it isn't known.
+type UniformBlock struct {
+	Name string
+	Size int32
+}
+
+// Usage is a hint of how often a buffer changes.
+type Usage int
+
+// The buffer usages.
+const (
+	StaticDraw Usage = iota
+	DynamicDraw
+	StreamDraw
+)
+
+// AttribType is the type of a vertex attribute in its buffer.
+type AttribType int
+
+// The attribute types.
+const (
+	AttribFloat AttribType = iota
+	AttribUnsignedShort
+)
+
+// VertexAttrib describes where one input of the vertex shader is in a buffer. Offset and Stride are in bytes.
+type VertexAttrib struct {
+	Index   uint32
+	Size    int32
+	Type    AttribType
+	Stride  int32
+	Offset  int
+	Divisor uint32
+	// Integer keeps the values integers in the shader, instead of converting them to floats.
+	Integer bool
+}
+
+// TextureFormat is how the pixels of a texture are stored.
+type TextureFormat int
+
+// The texture formats, and the type of data CreateTexture expects for them.
+const (
+	// RGBA8 takes []uint8.
+	RGBA8 TextureFormat = iota
+	// R8 takes []uint8.
+	R8
+	// RGB16F takes []float32.
+	RGB16F
+	// Depth24 takes []float32.
+	Depth24
+	// Depth24Stencil8 takes []uint32.
+	Depth24Stencil8
+	// RG8 takes []uint8.
+	RG8
+	// RGBA16F takes []float32.
+	RGBA16F
+	// SRGBA8 takes []uint8, the colors are converted from sRGB to linear when they're sampled.
+	SRGBA8
+
+	// The compressed formats store blocks of 4x4 pixels, see the gputex package. They can only be created with
+	// CreateTextureLevels.
+	BC1
+	BC1SRGB
+	BC1A
+	BC1ASRGB
+	BC2
+	BC2SRGB
+	BC3
+	BC3SRGB
+	BC4
+	BC5
+	BC6H
+	BC6HSigned
+	BC7
+	BC7SRGB
+)
+
+var formatNames = map[TextureFormat]string{
+	RGBA8: "RGBA8", R8: "R8", RGB16F: "RGB16F", Depth24: "Depth24", Depth24Stencil8: "Depth24Stencil8", RG8: "RG8",
+	RGBA16F: "RGBA16F", SRGBA8: "SRGBA8", BC1: "BC1", BC1SRGB: "BC1SRGB", BC1A: "BC1A", BC1ASRGB: "BC1ASRGB",
+	BC2: "BC2", BC2SRGB: "BC2SRGB", BC3: "BC3", BC3SRGB: "BC3SRGB", BC4: "BC4", BC5: "BC5", BC6H: "BC6H",
+	BC6HSigned: "BC6HSigned", BC7: "BC7", BC7SRGB: "BC7SRGB",
+}
+
+// Compressed returns whether the format stores blocks.
+func (f TextureFormat) Compressed() bool {
+	return f >= BC1
+}
+
+// String returns the name of the format.
+func (f TextureFormat) String() string {
+	if name, ok := formatNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("TextureFormat(%d)", int(f))
+}
+
+// Filter is how a texture is sampled between pixels.
+type Filter int
+
+// The filters. The mipmap filters are only for minifying, the first part is how a mipmap is sampled, the second
+// how the mipmaps are chosen.
+const (
+	Nearest Filter = iota
+	Linear
+	LinearMipmapLinear
+	NearestMipmapNearest
+	LinearMipmapNearest
+	NearestMipmapLinear
+)
+
+// Wrap is what happens outside the texture coordinates 0 to 1.
+type Wrap int
+
+// The wrap modes.
+const (
+	ClampToEdge Wrap = iota
+	Repeat
+	MirroredRepeat
+)
+
+// TextureDesc describes a 2D texture.
+type TextureDesc struct {
+	Width, Height        int32
+	Format               TextureFormat
+	MinFilter, MagFilter Filter
+	Wrap                 Wrap
+	// Anisotropy sharpens textures seen at an angle, 0 and 1 are off. It's limited to MaxAnisotropy.
+	Anisotropy float32
+	// Mipmaps generates the smaller versions of the texture from the data.
+	Mipmaps bool
+}
+
+// sampler returns how the texture is sampled.
+func (desc TextureDesc) sampler() SamplerDesc {
+	return SamplerDesc{desc.MinFilter, desc.MagFilter, desc.Wrap, desc.Anisotropy}
+}
+
+// SamplerDesc is how a texture is sampled.
+type SamplerDesc struct {
+	MinFilter, MagFilter Filter
+	Wrap                 Wrap
+	Anisotropy           float32
+}
+
+// Attachment is the point a texture is attached to in a framebuffer.
+type Attachment int
+
+// The attachment points.
+const (
+	ColorAttachment Attachment = iota
+	DepthAttachment
+	DepthStencilAttachment
+)
+
+// FramebufferAttachment is a texture attached to a framebuffer.
+type FramebufferAttachment struct {
+	Point   Attachment
+	Texture uint32
+}
+
+// BlendMode is how the output of the fragment shader is combined with what's already there.
+type BlendMode int
+
+// The blend modes.
+const (
+	BlendNone BlendMode = iota
+	// BlendAlpha mixes by the alpha of the output.
+	BlendAlpha
+	// BlendAdditive adds the output, scaled by its alpha.
+	BlendAdditive
+)
+
+// Compare is a depth test function.
+type Compare int
+
+// The depth test functions.
+const (
+	Less Compare = iota
+	LessEqual
+	Equal
+	Always
+)
+
+// Pipeline is a program together with the fixed function state it's drawn with.
+type Pipeline struct {
+	Program    uint32
+	Blend      BlendMode
+	DepthTest  bool
+	DepthWrite bool
+	DepthFunc  Compare
+	// Cull removes the back faces, which are the clockwise triangles.
+	Cull bool
+}
+
+// defaultPipeline returns the state most things are drawn with: alpha blending, depth testing and culling.
+func defaultPipeline(program uint32) Pipeline {
+	return Pipeline{
+		Program:    program,
+		Blend:      BlendAlpha,
+		DepthTest:  true,
+		DepthWrite: true,
+		DepthFunc:  Less,
+		Cull:       true,
+	}
+}
+
+// ClearFlags picks which buffers Clear clears.
+type ClearFlags int
+
+// The clear flags.
+const (
+	ClearColor ClearFlags = 1 << iota
+	ClearDepth
+)
+
+// Primitive is what the vertices are drawn as.
+type Primitive int
+
+// The primitives.
+const (
+	Triangles Primitive = iota
+	TriangleStrip
+	Lines
+	Points
+)
@@ -0,0 +1,854 @@
+package gfx
+
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// GLDevice is the OpenGL 3.3 Device. It needs a current context, created by the window package.
+type GLDevice struct {
+	// Cache skips binds and state changes that wouldn't change anything.
+	Cache *StateCache
+
+	// maxAnisotropy is 0 until it's asked for.
+	maxAnisotropy float32
+	// extensions are the names of the extensions of the driver, nil until one is asked for.
+	extensions map[string]bool
+}
+
+// CreateGLDevice returns a device for the current OpenGL context.
+func CreateGLDevice() *GLDevice {
+	return &GLDevice{Cache: CreateStateCache()}
+}
+
+// BeginFrame starts counting the calls of a new frame, it's called by gfx.BeginFrame.
+func (d *GLDevice) BeginFrame() {
+	d.Cache.NextFrame()
+}
+
+// bindArrayBuffer binds a buffer to the array buffer target.
+func (d *GLDevice) bindArrayBuffer(id uint32) {
+	if d.Cache.change(stateArrayBuffer, int64(id)) {
+		gl.BindBuffer(gl.ARRAY_BUFFER, id)
+	}
+}
+
+// activeTexture makes the texture unit the one BindTexture changes.
+func (d *GLDevice) activeTexture(unit int) {
+	if d.Cache.change(stateActiveTexture, int64(unit)) {
+		gl.ActiveTexture(gl.TEXTURE0 + uint32(unit))
+	}
+}
+
+// byteSize returns the size of a slice in bytes.
+func byteSize(data interface{}) int {
+	switch d := data.(type) {
+	case []float32:
+		return len(d) * 4
+	case []uint32:
+		return len(d) * 4
+	case []uint16:
+		return len(d) * 2
+	case []uint8:
+		return len(d)
+	case nil:
+		return 0
+	}
+
+	panic(fmt.Sprintf("unsupported buffer data %T", data))
+}
+
+// ptr returns a pointer to the data, or nil for empty data.
+func ptr(data interface{}) unsafe.Pointer {
+	if byteSize(data) == 0 {
+		return nil
+	}
+	return gl.Ptr(data)
+}
+
+var glUsages = map[Usage]uint32{
+	StaticDraw:  gl.STATIC_DRAW,
+	DynamicDraw: gl.DYNAMIC_DRAW,
+	StreamDraw:  gl.STREAM_DRAW,
+}
+
+// CreateBuffer creates a buffer with the data in it.
+func (d *GLDevice) CreateBuffer(data interface{}, usage Usage) uint32 {
+	var id uint32
+	gl.GenBuffers(1, &id)
+	d.UpdateBuffer(id, data, usage)
+
+	return id
+}
+
+// UpdateBuffer replaces the data of the buffer.
+func (d *GLDevice) UpdateBuffer(id uint32, data interface{}, usage Usage) {
+	// Buffers don't have a type in OpenGL, the array buffer binding doesn't change any vertex array.
+	d.bindArrayBuffer(id)
+	gl.BufferData(gl.ARRAY_BUFFER, byteSize(data), ptr(data), glUsages[usage])
+}
+
+// DeleteBuffer frees the buffer.
+func (d *GLDevice) DeleteBuffer(id uint32) {
+	d.Cache.forgetIf(stateArrayBuffer, int64(id))
+	gl.DeleteBuffers(1, &id)
+}
+
+// CreateVertexArray creates a vertex array and binds it.
+func (d *GLDevice) CreateVertexArray() uint32 {
+	var id uint32
+	gl.GenVertexArrays(1, &id)
+	d.BindVertexArray(id)
+
+	return id
+}
+
+// BindVertexArray makes the vertex array the one drawn and changed.
+func (d *GLDevice) BindVertexArray(id uint32) {
+	if d.Cache.change(stateVertexArray, int64(id)) {
+		gl.BindVertexArray(id)
+	}
+}
+
+// SetVertexAttrib adds an input from the buffer to the bound vertex array.
+func (d *GLDevice) SetVertexAttrib(buffer uint32, a VertexAttrib) {
+	d.bindArrayBuffer(buffer)
+
+	xtype := uint32(gl.FLOAT)
+	if a.Type == AttribUnsignedShort {
+		xtype = gl.UNSIGNED_SHORT
+	}
+
+	if a.Integer {
+		gl.VertexAttribIPointer(a.Index, a.Size, xtype, a.Stride, gl.PtrOffset(a.Offset))
+	} else {
+		gl.VertexAttribPointer(a.Index, a.Size, xtype, false, a.Stride, gl.PtrOffset(a.Offset))
+	}
+	gl.EnableVertexAttribArray(a.Index)
+	if a.Divisor != 0 {
+		gl.VertexAttribDivisor(a.Index, a.Divisor)
+	}
+}
+
+// SetIndexBuffer makes the bound vertex array use the buffer for its indices.
+func (d *GLDevice) SetIndexBuffer(buffer uint32) {
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, buffer)
+}
+
+// DeleteVertexArray frees the vertex array, but not its buffers.
+func (d *GLDevice) DeleteVertexArray(id uint32) {
+	// Deleting the bound vertex array binds 0.
+	if v, ok := d.Cache.get(stateVertexArray); ok && v == int64(id) {
+		d.Cache.set(stateVertexArray, 0)
+	}
+	gl.DeleteVertexArrays(1, &id)
+}
+
+// glFormats are the internal format, format and type of every texture format.
+var glFormats = map[TextureFormat][3]uint32{
+	RGBA8:           {gl.RGBA8, gl.RGBA, gl.UNSIGNED_BYTE},
+	R8:              {gl.R8, gl.RED, gl.UNSIGNED_BYTE},
+	RGB16F:          {gl.RGB16F, gl.RGB, gl.FLOAT},
+	Depth24:         {gl.DEPTH_COMPONENT24, gl.DEPTH_COMPONENT, gl.FLOAT},
+	Depth24Stencil8: {gl.DEPTH24_STENCIL8, gl.DEPTH_STENCIL, gl.UNSIGNED_INT_24_8},
+	RG8:             {gl.RG8, gl.RG, gl.UNSIGNED_BYTE},
+	RGBA16F:         {gl.RGBA16F, gl.RGBA, gl.FLOAT},
+	SRGBA8:          {gl.SRGB8_ALPHA8, gl.RGBA, gl.UNSIGNED_BYTE},
+	BC1:             {compressedRGBS3TCDXT1},
+	BC1SRGB:         {compressedSRGBS3TCDXT1},
+	BC1A:            {compressedRGBAS3TCDXT1},
+	BC1ASRGB:        {compressedSRGBAlphaS3TCDXT1},
+	BC2:             {compressedRGBAS3TCDXT3},
+	BC2SRGB:         {compressedSRGBAlphaS3TCDXT3},
+	BC3:             {compressedRGBAS3TCDXT5},
+	BC3SRGB:         {compressedSRGBAlphaS3TCDXT5},
+	BC4:             {gl.COMPRESSED_RED_RGTC1},
+	BC5:             {gl.COMPRESSED_RG_RGTC2},
+	BC6H:            {compressedRGBBPTCUnsignedFloat},
+	BC6HSigned:      {compressedRGBBPTCSignedFloat},
+	BC7:             {compressedRGBABPTCUnorm},
+	BC7SRGB:         {compressedSRGBAlphaBPTCUnorm},
+}
+
+// S3TC (BC1 to BC3) is the EXT_texture_compression_s3tc extension and BPTC (BC6H and BC7) is
+// ARB_texture_compression_bptc, which is in OpenGL 4.2. RGTC (BC4 and BC5) is in 3.3.
+const (
+	compressedRGBS3TCDXT1          = 0x83F0
+	compressedRGBAS3TCDXT1         = 0x83F1
+	compressedRGBAS3TCDXT3         = 0x83F2
+	compressedRGBAS3TCDXT5         = 0x83F3
+	compressedSRGBS3TCDXT1         = 0x8C4C
+	compressedSRGBAlphaS3TCDXT1    = 0x8C4D
+	compressedSRGBAlphaS3TCDXT3    = 0x8C4E
+	compressedSRGBAlphaS3TCDXT5    = 0x8C4F
+	compressedRGBABPTCUnorm        = 0x8E8C
+	compressedSRGBAlphaBPTCUnorm   = 0x8E8D
+	compressedRGBBPTCSignedFloat   = 0x8E8E
+	compressedRGBBPTCUnsignedFloat = 0x8E8F
+)
+
+var glFilters = map[Filter]int32{
+	Nearest:              gl.NEAREST,
+	Linear:               gl.LINEAR,
+	LinearMipmapLinear:   gl.LINEAR_MIPMAP_LINEAR,
+	NearestMipmapNearest: gl.NEAREST_MIPMAP_NEAREST,
+	LinearMipmapNearest:  gl.LINEAR_MIPMAP_NEAREST,
+	NearestMipmapLinear:  gl.NEAREST_MIPMAP_LINEAR,
+}
+
+var glWraps = map[Wrap]int32{
+	ClampToEdge:    gl.CLAMP_TO_EDGE,
+	Repeat:         gl.REPEAT,
+	MirroredRepeat: gl.MIRRORED_REPEAT,
+}
+
+// Anisotropic filtering isn't in OpenGL 3.3, it's the EXT_texture_filter_anisotropic extension. It's in almost every
+// driver, and in OpenGL 4.6 with the same values.
+const (
+	textureMaxAnisotropy    = 0x84FE
+	maxTextureMaxAnisotropy = 0x84FF
+)
+
+// CreateTexture creates a 2D texture and binds it to unit 0.
+func (d *GLDevice) CreateTexture(desc TextureDesc, data interface{}) uint32 {
+	var id uint32
+	gl.GenTextures(1, &id)
+	d.BindTexture(0, id)
+
+	// Rows of pixels don't have to start on 4 bytes, like with R8 textures.
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	f := glFormats[desc.Format]
+	gl.TexImage2D(gl.TEXTURE_2D, 0, int32(f[0]), desc.Width, desc.Height, 0, f[1], f[2], ptr(data))
+	if desc.Mipmaps {
+		gl.GenerateMipmap(gl.TEXTURE_2D)
+	}
+	d.SetTextureParams(id, desc.sampler())
+
+	return id
+}
+
+// CreateTextureLevels creates a 2D texture with the mipmaps and binds it to unit 0. With only the full size
+// desc.Mipmaps makes the others, if the format isn't compressed.
+func (d *GLDevice) CreateTextureLevels(desc TextureDesc, levels [][]byte) uint32 {
+	var id uint32
+	gl.GenTextures(1, &id)
+	d.BindTexture(0, id)
+
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	f := glFormats[desc.Format]
+	w, h := desc.Width, desc.Height
+	for i, l := range levels {
+		if desc.Format.Compressed() {
+			gl.CompressedTexImage2D(gl.TEXTURE_2D, int32(i), f[0], w, h, 0, int32(len(l)), ptr(l))
+		} else {
+			gl.TexImage2D(gl.TEXTURE_2D, int32(i), int32(f[0]), w, h, 0, f[1], f[2], ptr(l))
+		}
+		if w > 1 {
+			w /= 2
+		}
+		if h > 1 {
+			h /= 2
+		}
+	}
+
+	if len(levels) == 1 && desc.Mipmaps && !desc.Format.Compressed() {
+		gl.GenerateMipmap(gl.TEXTURE_2D)
+	} else {
+		// Files don't always have the mipmaps down to 1x1, the texture is incomplete without the limit.
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAX_LEVEL, int32(len(levels)-1))
+	}
+	d.SetTextureParams(id, desc.sampler())
+
+	return id
+}
+
+// SupportsFormat returns whether the GPU can sample textures of the format. The compressed formats other than BC4
+// and BC5 need extensions.
+func (d *GLDevice) SupportsFormat(f TextureFormat) bool {
+	switch f {
+	case BC1, BC1A, BC2, BC3:
+		return d.hasExtension("GL_EXT_texture_compression_s3tc")
+	case BC1SRGB, BC1ASRGB, BC2SRGB, BC3SRGB:
+		return d.hasExtension("GL_EXT_texture_compression_s3tc") &&
+			(d.hasExtension("GL_EXT_texture_sRGB") || d.hasExtension("GL_EXT_texture_compression_s3tc_srgb"))
+	case BC6H, BC6HSigned, BC7, BC7SRGB:
+		return d.hasExtension("GL_ARB_texture_compression_bptc")
+	}
+	_, ok := glFormats[f]
+	return ok
+}
+
+// hasExtension returns whether the driver has the extension, the list is asked for once.
+func (d *GLDevice) hasExtension(name string) bool {
+	if d.extensions == nil {
+		d.extensions = map[string]bool{}
+		var n int32
+		gl.GetIntegerv(gl.NUM_EXTENSIONS, &n)
+		for i := int32(0); i < n; i++ {
+			d.extensions[gl.GoStr(gl.GetStringi(gl.EXTENSIONS, uint32(i)))] = true
+		}
+	}
+	return d.extensions[name]
+}
+
+// SetTextureParams changes how the texture is sampled.
+func (d *GLDevice) SetTextureParams(id uint32, s SamplerDesc) {
+	// The texture has to be bound to change it, use the active unit.
+	unit, ok := d.Cache.get(stateActiveTexture)
+	if !ok {
+		unit = 0
+	}
+	d.BindTexture(int(unit), id)
+
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, glFilters[s.MinFilter])
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, glFilters[s.MagFilter])
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, glWraps[s.Wrap])
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, glWraps[s.Wrap])
+	if d.MaxAnisotropy() > 1 {
+		gl.TexParameterf(gl.TEXTURE_2D, textureMaxAnisotropy, d.anisotropy(s.Anisotropy))
+	}
+}
+
+// CreateSampler creates a sampler object, it can be bound to many units at the same time.
+func (d *GLDevice) CreateSampler(s SamplerDesc) uint32 {
+	var id uint32
+	gl.GenSamplers(1, &id)
+
+	gl.SamplerParameteri(id, gl.TEXTURE_MIN_FILTER, glFilters[s.MinFilter])
+	gl.SamplerParameteri(id, gl.TEXTURE_MAG_FILTER, glFilters[s.MagFilter])
+	gl.SamplerParameteri(id, gl.TEXTURE_WRAP_S, glWraps[s.Wrap])
+	gl.SamplerParameteri(id, gl.TEXTURE_WRAP_T, glWraps[s.Wrap])
+	if d.MaxAnisotropy() > 1 {
+		gl.SamplerParameterf(id, textureMaxAnisotropy, d.anisotropy(s.Anisotropy))
+	}
+
+	return id
+}
+
+// BindSampler binds the sampler to a texture unit, it doesn't change the active unit.
+func (d *GLDevice) BindSampler(unit int, id uint32) {
+	if unit < MaxTextureUnits && !d.Cache.change(stateSampler+unit, int64(id)) {
+		return
+	}
+	gl.BindSampler(uint32(unit), id)
+}
+
+// DeleteSampler frees the sampler.
+func (d *GLDevice) DeleteSampler(id uint32) {
+	// Deleting a sampler unbinds it from every unit, like a texture.
+	for unit := 0; unit < MaxTextureUnits; unit++ {
+		if v, ok := d.Cache.get(stateSampler + unit); ok && v == int64(id) {
+			d.Cache.set(stateSampler+unit, 0)
+		}
+	}
+	gl.DeleteSamplers(1, &id)
+}
+
+// MaxAnisotropy returns the most anisotropic filtering the GPU does, 1 when the driver doesn't have the extension.
+func (d *GLDevice) MaxAnisotropy() float32 {
+	if d.maxAnisotropy != 0 {
+		return d.maxAnisotropy
+	}
+
+	d.maxAnisotropy = 1
+	if d.hasExtension("GL_EXT_texture_filter_anisotropic") || d.hasExtension("GL_ARB_texture_filter_anisotropic") {
+		gl.GetFloatv(maxTextureMaxAnisotropy, &d.maxAnisotropy)
+	}
+
+	return d.maxAnisotropy
+}
+
+// anisotropy limits the anisotropy to what the GPU does, 0 is off like 1.
+func (d *GLDevice) anisotropy(a float32) float32 {
+	if a < 1 {
+		return 1
+	}
+	if max := d.MaxAnisotropy(); a > max {
+		return max
+	}
+	return a
+}
+
+// BindTexture binds the texture to a texture unit, for the sampler uniforms.
+func (d *GLDevice) BindTexture(unit int, id uint32) {
+	if unit < MaxTextureUnits && !d.Cache.change(stateTexture+unit, int64(id)) {
+		return
+	}
+	d.activeTexture(unit)
+	gl.BindTexture(gl.TEXTURE_2D, id)
+}
+
+// DeleteTexture frees the texture.
+func (d *GLDevice) DeleteTexture(id uint32) {
+	// Deleting a texture unbinds it from every unit.
+	for unit := 0; unit < MaxTextureUnits; unit++ {
+		if v, ok := d.Cache.get(stateTexture + unit); ok && v == int64(id) {
+			d.Cache.set(stateTexture+unit, 0)
+		}
+	}
+	gl.DeleteTextures(1, &id)
+}
+
+var glStageNames = map[uint32]string{
+	gl.VERTEX_SHADER:   "vertex",
+	gl.GEOMETRY_SHADER: "geometry",
+	gl.FRAGMENT_SHADER: "fragment",
+}
+
+// compileShader compiles the shader and returns the errors from the log.
+func compileShader(source string, shaderType uint32) (uint32, error) {
+	shader := gl.CreateShader(shaderType)
+
+	csource, free := gl.Strs(source + "\x00")
+	gl.ShaderSource(shader, 1, csource, nil)
+	free()
+	gl.CompileShader(shader)
+
+	var status int32
+	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
+
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
+		gl.DeleteShader(shader)
+
+		return 0, &CompileError{Stage: glStageNames[shaderType], Log: strings.TrimRight(log, "\x00")}
+	}
+
+	return shader, nil
+}
+
+// CreateProgram compiles and links the shaders, geometry can be empty.
+func (d *GLDevice) CreateProgram(vertex, geometry, fragment string) (uint32, error) {
+	sources := []struct {
+		source string
+		xtype  uint32
+	}{
+		{vertex, gl.VERTEX_SHADER},
+		{geometry, gl.GEOMETRY_SHADER},
+		{fragment, gl.FRAGMENT_SHADER},
+	}
+
+	var shaders []uint32
+	defer func() {
+		// The shaders are useless once linked, or when one of them didn't compile.
+		for _, shader := range shaders {
+			gl.DeleteShader(shader)
+		}
+	}()
+
+	for _, s := range sources {
+		if s.source == "" && s.xtype == gl.GEOMETRY_SHADER {
+			continue
+		}
+
+		shader, err := compileShader(s.source, s.xtype)
+		if err != nil {
+			return 0, err
+		}
+		shaders = append(shaders, shader)
+	}
+
+	// Link the seperate shaders into one program.
+	program := gl.CreateProgram()
+	for _, shader := range shaders {
+		gl.AttachShader(program, shader)
+	}
+	gl.LinkProgram(program)
+
+	var status int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
+
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
+		gl.DeleteProgram(program)
+
+		return 0, &LinkError{Log: strings.TrimRight(log, "\x00")}
+	}
+
+	return program, nil
+}
+
+// DeleteProgram frees the program.
+func (d *GLDevice) DeleteProgram(id uint32) {
+	// The program stays in use until another one is, but its id can be given to a new program.
+	d.Cache.forgetIf(stateProgram, int64(id))
+	gl.DeleteProgram(id)
+}
+
+// glTypeNames are the GLSL names of the types of uniforms and attributes.
+var glTypeNames = map[uint32]string{
+	gl.FLOAT:             "float",
+	gl.FLOAT_VEC2:        "vec2",
+	gl.FLOAT_VEC3:        "vec3",
+	gl.FLOAT_VEC4:        "vec4",
+	gl.INT:               "int",
+	gl.INT_VEC2:          "ivec2",
+	gl.INT_VEC3:          "ivec3",
+	gl.INT_VEC4:          "ivec4",
+	gl.UNSIGNED_INT:      "uint",
+	gl.UNSIGNED_INT_VEC4: "uvec4",
+	gl.BOOL:              "bool",
+	gl.FLOAT_MAT3:        "mat3",
+	gl.FLOAT_MAT4:        "mat4",
+	gl.SAMPLER_2D:        "sampler2D",
+	gl.SAMPLER_2D_ARRAY:  "sampler2DArray",
+	gl.SAMPLER_CUBE:      "samplerCube",
+}
+
+// activeVars lists the uniforms or attributes of a program, get is glGetActiveUniform or glGetActiveAttrib.
+func activeVars(program uint32, countParam, lengthParam uint32,
+	get func(uint32, uint32, int32, *int32, *int32, *uint32, *uint8),
+	location func(uint32, *uint8) int32) []ActiveVar {
+	var count, maxLength int32
+	gl.GetProgramiv(program, countParam, &count)
+	gl.GetProgramiv(program, lengthParam, &maxLength)
+
+	vars := make([]ActiveVar, 0, count)
+	name := make([]uint8, maxLength+1)
+	for i := uint32(0); i < uint32(count); i++ {
+		var length, size int32
+		var xtype uint32
+		get(program, i, int32(len(name)), &length, &size, &xtype, &name[0])
+
+		v := ActiveVar{Name: string(name[:length]), Type: glTypeNames[xtype], Size: size, Offset: -1}
+		if v.Type == "" {
+			v.Type = fmt.Sprintf("0x%x", xtype)
+		}
+		v.Location = location(program, gl.Str(v.Name+"\x00"))
+		vars = append(vars, v)
+	}
+
+	return vars
+}
+
+// ActiveUniforms returns the uniforms the program uses. Arrays are named like the first element, "name[0]".
+func (d *GLDevice) ActiveUniforms(program uint32) []ActiveVar {
+	vars := activeVars(program, gl.ACTIVE_UNIFORMS, gl.ACTIVE_UNIFORM_MAX_LENGTH, gl.GetActiveUniform,
+		gl.GetUniformLocation)
+
+	// The members of uniform blocks are uniforms too, without a location.
+	blocks := d.UniformBlocks(program)
+	for i := range vars {
+		index := uint32(i)
+		var block int32
+		gl.GetActiveUniformsiv(program, 1, &index, gl.UNIFORM_BLOCK_INDEX, &block)
+		if block < 0 || int(block) >= len(blocks) {
+			continue
+		}
+		vars[i].Block = blocks[block].Name
+		gl.GetActiveUniformsiv(program, 1, &index, gl.UNIFORM_OFFSET, &vars[i].Offset)
+	}
+
+	return vars
+}
+
+// UniformBlocks returns the uniform blocks the program uses, in the order of their index.
+func (d *GLDevice) UniformBlocks(program uint32) []UniformBlock {
+	var count, maxLength int32
+	gl.GetProgramiv(program, gl.ACTIVE_UNIFORM_BLOCKS, &count)
+	gl.GetProgramiv(program, gl.ACTIVE_UNIFORM_BLOCK_MAX_NAME_LENGTH, &maxLength)
+
+	blocks := make([]UniformBlock, count)
+	name := make([]uint8, maxLength+1)
+	for i := range blocks {
+		var length int32
+		gl.GetActiveUniformBlockName(program, uint32(i), int32(len(name)), &length, &name[0])
+		blocks[i].Name = string(name[:length])
+		gl.GetActiveUniformBlockiv(program, uint32(i), gl.UNIFORM_BLOCK_DATA_SIZE, &blocks[i].Size)
+	}
+
+	return blocks
+}
+
+// SetUniformBlockBinding makes the block of the program read from the buffer bound to the binding point. It does
+// nothing when the program doesn't use the block.
+func (d *GLDevice) SetUniformBlockBinding(program uint32, block string, binding uint32) {
+	index := gl.GetUniformBlockIndex(program, gl.Str(block+"\x00"))
+	if index != gl.INVALID_INDEX {
+		gl.UniformBlockBinding(program, index, binding)
+	}
+}
+
+// BindUniformBuffer binds the buffer to the binding point, for the uniform blocks bound to it.
+func (d *GLDevice) BindUniformBuffer(binding uint32, buffer uint32) {
+	gl.BindBufferBase(gl.UNIFORM_BUFFER, binding, buffer)
+}
+
+// ActiveAttribs returns the inputs of the vertex shader the program uses.
+func (d *GLDevice) ActiveAttribs(program uint32) []ActiveVar {
+	return activeVars(program, gl.ACTIVE_ATTRIBUTES, gl.ACTIVE_ATTRIBUTE_MAX_LENGTH, gl.GetActiveAttrib,
+		gl.GetAttribLocation)
+}
+
+// useProgram makes the program the one in use, if it isn't already.
+func (d *GLDevice) useProgram(id uint32) {
+	if d.Cache.change(stateProgram, int64(id)) {
+		gl.UseProgram(id)
+	}
+}
+
+// SetUniformInt sets an int, bool or sampler uniform.
+func (d *GLDevice) SetUniformInt(program uint32, loc int32, i int32) {
+	d.useProgram(program)
+	gl.Uniform1i(loc, i)
+}
+
+// SetUniformFloat sets a float uniform.
+func (d *GLDevice) SetUniformFloat(program uint32, loc int32, f float32) {
+	d.useProgram(program)
+	gl.Uniform1f(loc, f)
+}
+
+// SetUniformVec2 sets a vec2 or vec2 array uniform.
+func (d *GLDevice) SetUniformVec2(program uint32, loc int32, v []mgl32.Vec2) {
+	if len(v) > 0 {
+		d.useProgram(program)
+		gl.Uniform2fv(loc, int32(len(v)), &v[0][0])
+	}
+}
+
+// SetUniformVec3 sets a vec3 or vec3 array uniform.
+func (d *GLDevice) SetUniformVec3(program uint32, loc int32, v []mgl32.Vec3) {
+	if len(v) > 0 {
+		d.useProgram(program)
+		gl.Uniform3fv(loc, int32(len(v)), &v[0][0])
+	}
+}
+
+// SetUniformMat4 sets a mat4 or mat4 array uniform.
+func (d *GLDevice) SetUniformMat4(program uint32, loc int32, m []mgl32.Mat4) {
+	if len(m) > 0 {
+		d.useProgram(program)
+		gl.UniformMatrix4fv(loc, int32(len(m)), false, &m[0][0])
+	}
+}
+
+var glAttachments = map[Attachment]uint32{
+	ColorAttachment:        gl.COLOR_ATTACHMENT0,
+	DepthAttachment:        gl.DEPTH_ATTACHMENT,
+	DepthStencilAttachment: gl.DEPTH_STENCIL_ATTACHMENT,
+}
+
+// CreateFramebuffer creates a framebuffer that draws to the textures. The bound framebuffer doesn't change.
+func (d *GLDevice) CreateFramebuffer(attachments ...FramebufferAttachment) (uint32, error) {
+	prev := d.Framebuffer()
+
+	var id uint32
+	gl.GenFramebuffers(1, &id)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, id)
+
+	color := false
+	for _, a := range attachments {
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, glAttachments[a.Point], gl.TEXTURE_2D, a.Texture, 0)
+		color = color || a.Point == ColorAttachment
+	}
+	if !color {
+		// A framebuffer with only depth, like for a depth pre-pass.
+		gl.DrawBuffer(gl.NONE)
+		gl.ReadBuffer(gl.NONE)
+	}
+
+	status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, prev)
+	d.Cache.set(stateFramebuffer, int64(prev))
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		gl.DeleteFramebuffers(1, &id)
+		return 0, fmt.Errorf("framebuffer is incomplete: 0x%x", status)
+	}
+
+	return id, nil
+}
+
+// BindFramebuffer makes everything after it draw to the framebuffer.
+func (d *GLDevice) BindFramebuffer(id uint32) {
+	if d.Cache.change(stateFramebuffer, int64(id)) {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, id)
+	}
+}
+
+// Framebuffer returns the framebuffer being drawn to.
+func (d *GLDevice) Framebuffer() uint32 {
+	if id, ok := d.Cache.get(stateFramebuffer); ok {
+		return uint32(id)
+	}
+
+	var id int32
+	gl.GetIntegerv(gl.DRAW_FRAMEBUFFER_BINDING, &id)
+	d.Cache.set(stateFramebuffer, int64(id))
+	return uint32(id)
+}
+
+// DeleteFramebuffer frees the framebuffer, but not its textures.
+func (d *GLDevice) DeleteFramebuffer(id uint32) {
+	// Deleting the bound framebuffer binds the screen.
+	if v, ok := d.Cache.get(stateFramebuffer); ok && v == int64(id) {
+		d.Cache.set(stateFramebuffer, 0)
+	}
+	gl.DeleteFramebuffers(1, &id)
+}
+
+// ReadPixels reads RGBA pixels from the framebuffer, without changing what's being drawn to.
+func (d *GLDevice) ReadPixels(framebuffer uint32, x, y, width, height int32, pix []uint8) error {
+	if len(pix) < int(width*height*4) {
+		return fmt.Errorf("%v bytes don't fit %vx%v pixels", len(pix), width, height)
+	}
+
+	var prev int32
+	gl.GetIntegerv(gl.READ_FRAMEBUFFER_BINDING, &prev)
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, framebuffer)
+	if framebuffer == 0 {
+		gl.ReadBuffer(gl.BACK)
+	}
+
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	gl.ReadPixels(x, y, width, height, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(pix))
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, uint32(prev))
+
+	if e := gl.GetError(); e != gl.NO_ERROR {
+		return fmt.Errorf("reading the pixels failed: 0x%x", e)
+	}
+
+	return nil
+}
+
+// CreateQuery creates a query object.
+func (d *GLDevice) CreateQuery() uint32 {
+	var id uint32
+	gl.GenQueries(1, &id)
+	return id
+}
+
+// QueryTimestamp records the GPU time in the query. It uses timestamps instead of GL_TIME_ELAPSED queries,
+// because those can't be nested.
+func (d *GLDevice) QueryTimestamp(id uint32) {
+	gl.QueryCounter(id, gl.TIMESTAMP)
+}
+
+// QueryResult returns the timestamp, and false when the GPU isn't there yet. It never waits.
+func (d *GLDevice) QueryResult(id uint32) (uint64, bool) {
+	var available int32
+	gl.GetQueryObjectiv(id, gl.QUERY_RESULT_AVAILABLE, &available)
+	if available == gl.FALSE {
+		return 0, false
+	}
+
+	var t uint64
+	gl.GetQueryObjectui64v(id, gl.QUERY_RESULT, &t)
+	return t, true
+}
+
+// DeleteQuery frees the query.
+func (d *GLDevice) DeleteQuery(id uint32) {
+	gl.DeleteQueries(1, &id)
+}
+
+var glCompares = map[Compare]uint32{
+	Less:      gl.LESS,
+	LessEqual: gl.LEQUAL,
+	Equal:     gl.EQUAL,
+	Always:    gl.ALWAYS,
+}
+
+// enable turns an OpenGL capability on or off, if it isn't already.
+func (d *GLDevice) enable(state int, cap uint32, on bool) {
+	if !d.Cache.changeBool(state, on) {
+		return
+	}
+	if on {
+		gl.Enable(cap)
+	} else {
+		gl.Disable(cap)
+	}
+}
+
+// SetPipeline uses the program and sets the state of the pipeline, only the parts that are different.
+func (d *GLDevice) SetPipeline(p *Pipeline) {
+	d.useProgram(p.Program)
+
+	d.enable(stateBlend, gl.BLEND, p.Blend != BlendNone)
+	if p.Blend != BlendNone && d.Cache.change(stateBlendFunc, int64(p.Blend)) {
+		switch p.Blend {
+		case BlendAlpha:
+			gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+		case BlendAdditive:
+			gl.BlendFunc(gl.SRC_ALPHA, gl.ONE)
+		}
+	}
+
+	d.enable(stateDepthTest, gl.DEPTH_TEST, p.DepthTest)
+	if d.Cache.changeBool(stateDepthMask, p.DepthWrite) {
+		gl.DepthMask(p.DepthWrite)
+	}
+	if d.Cache.change(stateDepthFunc, int64(p.DepthFunc)) {
+		gl.DepthFunc(glCompares[p.DepthFunc])
+	}
+	d.enable(stateCull, gl.CULL_FACE, p.Cull)
+}
+
+// SetViewport sets the part of the framebuffer that's drawn to.
+func (d *GLDevice) SetViewport(x, y, width, height int32) {
+	if d.Cache.changeViewport([4]int32{x, y, width, height}) {
+		gl.Viewport(x, y, width, height)
+	}
+}
+
+// Viewport returns the x, y, width and height of the viewport.
+func (d *GLDevice) Viewport() [4]int32 {
+	if d.Cache.known[stateViewport] && d.Cache.Enabled {
+		return d.Cache.viewport
+	}
+
+	var v [4]int32
+	gl.GetIntegerv(gl.VIEWPORT, &v[0])
+	d.Cache.viewport, d.Cache.known[stateViewport] = v, true
+	return v
+}
+
+// SetClearColor sets the color Clear fills the screen with.
+func (d *GLDevice) SetClearColor(c mgl32.Vec4) {
+	gl.ClearColor(c[0], c[1], c[2], c[3])
+}
+
+// Clear clears the buffers of the bound framebuffer.
+func (d *GLDevice) Clear(flags ClearFlags) {
+	var mask uint32
+	if flags&ClearColor != 0 {
+		mask |= gl.COLOR_BUFFER_BIT
+	}
+	if flags&ClearDepth != 0 {
+		mask |= gl.DEPTH_BUFFER_BIT
+	}
+	gl.Clear(mask)
+}
+
+var glPrimitives = map[Primitive]uint32{
+	Triangles:     gl.TRIANGLES,
+	TriangleStrip: gl.TRIANGLE_STRIP,
+	Lines:         gl.LINES,
+	Points:        gl.POINTS,
+}
+
+// Draw draws count vertices of the bound vertex array.
+func (d *GLDevice) Draw(mode Primitive, first, count int32) {
+	gl.DrawArrays(glPrimitives[mode], first, count)
+}
+
+// DrawIndexed draws count indices of the bound vertex array, indices are always 32 bit.
+func (d *GLDevice) DrawIndexed(mode Primitive, count int32) {
+	gl.DrawElements(glPrimitives[mode], count, gl.UNSIGNED_INT, nil)
+}
+
+// DrawInstanced draws the vertices for every instance.
+func (d *GLDevice) DrawInstanced(mode Primitive, first, count, instances int32) {
+	gl.DrawArraysInstanced(glPrimitives[mode], first, count, instances)
+}
@@ -0,0 +1,423 @@
+package gfx
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"GopherGL/src/glsl"
+)
+
+// Command is a call made to a RecordingDevice.
+type Command struct {
+	Name string
+	Args []interface{}
+}
+
+// String formats the command like a function call.
+func (c Command) String() string {
+	args := make([]string, len(c.Args))
+	for i, a := range c.Args {
+		args[i] = fmt.Sprint(a)
+	}
+
+	return c.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// RecordingDevice is a Device that doesn't draw anything, it only records the commands. Use it to test code
+// that draws, by checking the commands it gives. Ids start at 1 and go up for every resource created.
+type RecordingDevice struct {
+	Commands []Command
+	// NoCompression makes SupportsFormat say no to the compressed formats, like a GPU without the extensions.
+	NoCompression bool
+
+	nextID      uint32
+	locations   map[string]int32
+	names       map[int32]string
+	uniforms    map[uint32][]ActiveVar
+	attribs     map[uint32][]ActiveVar
+	blocks      map[uint32][]UniformBlock
+	framebuffer uint32
+	viewport    [4]int32
+}
+
+// CreateRecordingDevice returns a device with a viewport of the size.
+func CreateRecordingDevice(width, height int32) *RecordingDevice {
+	return &RecordingDevice{
+		locations: make(map[string]int32),
+		names:     make(map[int32]string),
+		uniforms:  make(map[uint32][]ActiveVar),
+		attribs:   make(map[uint32][]ActiveVar),
+		blocks:    make(map[uint32][]UniformBlock),
+		viewport:  [4]int32{0, 0, width, height},
+	}
+}
+
+// record adds a command.
+func (d *RecordingDevice) record(name string, args ...interface{}) {
+	d.Commands = append(d.Commands, Command{name, args})
+}
+
+// id returns a new resource id.
+func (d *RecordingDevice) id() uint32 {
+	d.nextID++
+	return d.nextID
+}
+
+// Reset forgets the recorded commands, the resources stay.
+func (d *RecordingDevice) Reset() {
+	d.Commands = d.Commands[:0]
+}
+
+// String returns every command on its own line.
+func (d *RecordingDevice) String() string {
+	var b strings.Builder
+	for _, c := range d.Commands {
+		b.WriteString(c.String())
+		b.WriteByte('\n')
+	}
+
+	return b.String()
+}
+
+// Count returns how often a command was recorded.
+func (d *RecordingDevice) Count(name string) int {
+	n := 0
+	for _, c := range d.Commands {
+		if c.Name == name {
+			n++
+		}
+	}
+
+	return n
+}
+
+// describe returns the length of slices instead of their contents, so the commands stay readable.
+func describe(data interface{}) string {
+	switch d := data.(type) {
+	case []float32:
+		return fmt.Sprintf("[%v]float32", len(d))
+	case []uint32:
+		return fmt.Sprintf("[%v]uint32", len(d))
+	case []uint16:
+		return fmt.Sprintf("[%v]uint16", len(d))
+	case []uint8:
+		return fmt.Sprintf("[%v]uint8", len(d))
+	}
+
+	return fmt.Sprint(data)
+}
+
+// CreateBuffer records the command and returns a new id.
+func (d *RecordingDevice) CreateBuffer(data interface{}, usage Usage) uint32 {
+	id := d.id()
+	d.record("CreateBuffer", id, describe(data), usage)
+	return id
+}
+
+// UpdateBuffer records the command.
+func (d *RecordingDevice) UpdateBuffer(id uint32, data interface{}, usage Usage) {
+	d.record("UpdateBuffer", id, describe(data), usage)
+}
+
+// DeleteBuffer records the command.
+func (d *RecordingDevice) DeleteBuffer(id uint32) {
+	d.record("DeleteBuffer", id)
+}
+
+// CreateVertexArray records the command and returns a new id.
+func (d *RecordingDevice) CreateVertexArray() uint32 {
+	id := d.id()
+	d.record("CreateVertexArray", id)
+	return id
+}
+
+// BindVertexArray records the command.
+func (d *RecordingDevice) BindVertexArray(id uint32) {
+	d.record("BindVertexArray", id)
+}
+
+// SetVertexAttrib records the command.
+func (d *RecordingDevice) SetVertexAttrib(buffer uint32, a VertexAttrib) {
+	d.record("SetVertexAttrib", buffer, a)
+}
+
+// SetIndexBuffer records the command.
+func (d *RecordingDevice) SetIndexBuffer(buffer uint32) {
+	d.record("SetIndexBuffer", buffer)
+}
+
+// DeleteVertexArray records the command.
+func (d *RecordingDevice) DeleteVertexArray(id uint32) {
+	d.record("DeleteVertexArray", id)
+}
+
+// CreateTexture records the command and returns a new id.
+func (d *RecordingDevice) CreateTexture(desc TextureDesc, data interface{}) uint32 {
+	id := d.id()
+	d.record("CreateTexture", id, desc, describe(data))
+	return id
+}
+
+// CreateTextureLevels records the command and returns a new id.
+func (d *RecordingDevice) CreateTextureLevels(desc TextureDesc, levels [][]byte) uint32 {
+	id := d.id()
+	sizes := make([]string, len(levels))
+	for i, l := range levels {
+		sizes[i] = describe(l)
+	}
+	d.record("CreateTextureLevels", id, desc, strings.Join(sizes, " "))
+	return id
+}
+
+// SupportsFormat returns true, unless the format is compressed and NoCompression is set. It isn't recorded.
+func (d *RecordingDevice) SupportsFormat(f TextureFormat) bool {
+	return !d.NoCompression || !f.Compressed()
+}
+
+// SetTextureParams records the command.
+func (d *RecordingDevice) SetTextureParams(id uint32, s SamplerDesc) {
+	d.record("SetTextureParams", id, s)
+}
+
+// BindTexture records the command.
+func (d *RecordingDevice) BindTexture(unit int, id uint32) {
+	d.record("BindTexture", unit, id)
+}
+
+// DeleteTexture records the command.
+func (d *RecordingDevice) DeleteTexture(id uint32) {
+	d.record("DeleteTexture", id)
+}
+
+// CreateSampler records the command and returns a new id.
+func (d *RecordingDevice) CreateSampler(s SamplerDesc) uint32 {
+	id := d.id()
+	d.record("CreateSampler", id, s)
+	return id
+}
+
+// BindSampler records the command.
+func (d *RecordingDevice) BindSampler(unit int, id uint32) {
+	d.record("BindSampler", unit, id)
+}
+
+// DeleteSampler records the command.
+func (d *RecordingDevice) DeleteSampler(id uint32) {
+	d.record("DeleteSampler", id)
+}
+
+// MaxAnisotropy returns 16, what most GPUs do. It isn't recorded.
+func (d *RecordingDevice) MaxAnisotropy() float32 {
+	return 16
+}
+
+// CreateProgram records the command and returns a new id. The sources aren't compiled, but the uniforms, inputs
+// and uniform blocks they declare are what ActiveUniforms, ActiveAttribs and UniformBlocks return. The offsets
+// and sizes of the blocks aren't known.
+func (d *RecordingDevice) CreateProgram(vertex, geometry, fragment string) (uint32, error) {
+	id := d.id()
+	d.record("CreateProgram", id)
+
+	var uniforms []ActiveVar
+	seen, blocks := make(map[string]bool), make(map[string]bool)
+	for i, source := range []string{vertex, geometry, fragment} {
+		us, inputs, err := glsl.Declarations(source)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, u := range us {
+			if seen[u.Name] {
+				continue
+			}
+			seen[u.Name] = true
+
+			if u.Block == "" {
+				uniforms = append(uniforms, ActiveVar{u.Name, u.Type, int32(u.Size), d.location(id, u.Name), "", -1})
+				continue
+			}
+			uniforms = append(uniforms, ActiveVar{u.Name, u.Type, int32(u.Size), -1, u.Block, -1})
+			if !blocks[u.Block] {
+				blocks[u.Block] = true
+				d.blocks[id] = append(d.blocks[id], UniformBlock{u.Block, -1})
+			}
+		}
+		if i == 0 {
+			for _, in := range inputs {
+				d.attribs[id] = append(d.attribs[id], ActiveVar{in.Name, in.Type, int32(in.Size), int32(in.Location), "", -1})
+			}
+		}
+	}
+	d.uniforms[id] = uniforms
+
+	return id, nil
+}
+
+// DeleteProgram records the command.
+func (d *RecordingDevice) DeleteProgram(id uint32) {
+	d.record("DeleteProgram", id)
+	delete(d.uniforms, id)
+	delete(d.attribs, id)
+	delete(d.blocks, id)
+}
+
+// ActiveUniforms returns the uniforms the sources declared, it isn't recorded.
+func (d *RecordingDevice) ActiveUniforms(program uint32) []ActiveVar {
+	return d.uniforms[program]
+}
+
+// ActiveAttribs returns the inputs the vertex source declared, it isn't recorded.
+func (d *RecordingDevice) ActiveAttribs(program uint32) []ActiveVar {
+	return d.attribs[program]
+}
+
+// UniformBlocks returns the blocks the sources declared, it isn't recorded.
+func (d *RecordingDevice) UniformBlocks(program uint32) []UniformBlock {
+	return d.blocks[program]
+}
+
+// SetUniformBlockBinding records the command.
+func (d *RecordingDevice) SetUniformBlockBinding(program uint32, block string, binding uint32) {
+	d.record("SetUniformBlockBinding", program, block, binding)
+}
+
+// BindUniformBuffer records the command.
+func (d *RecordingDevice) BindUniformBuffer(binding uint32, buffer uint32) {
+	d.record("BindUniformBuffer", binding, buffer)
+}
+
+// location returns the same location for the same program and name.
+func (d *RecordingDevice) location(program uint32, name string) int32 {
+	key := fmt.Sprint(program, ":", name)
+	loc, ok := d.locations[key]
+	if !ok {
+		loc = int32(len(d.locations))
+		d.locations[key] = loc
+		d.names[loc] = name
+	}
+
+	return loc
+}
+
+// SetUniformInt records the command, with the name of the uniform instead of the location.
+func (d *RecordingDevice) SetUniformInt(program uint32, loc int32, i int32) {
+	d.record("SetUniformInt", d.names[loc], i)
+}
+
+// SetUniformFloat records the command, with the name of the uniform instead of the location.
+func (d *RecordingDevice) SetUniformFloat(program uint32, loc int32, f float32) {
+	d.record("SetUniformFloat", d.names[loc], f)
+}
+
+// SetUniformVec2 records the command, with the name of the uniform instead of the location.
+func (d *RecordingDevice) SetUniformVec2(program uint32, loc int32, v []mgl32.Vec2) {
+	d.record("SetUniformVec2", d.names[loc], len(v))
+}
+
+// SetUniformVec3 records the command, with the name of the uniform instead of the location.
+func (d *RecordingDevice) SetUniformVec3(program uint32, loc int32, v []mgl32.Vec3) {
+	d.record("SetUniformVec3", d.names[loc], len(v))
+}
+
+// SetUniformMat4 records the command, with the name of the uniform instead of the location.
+func (d *RecordingDevice) SetUniformMat4(program uint32, loc int32, m []mgl32.Mat4) {
+	d.record("SetUniformMat4", d.names[loc], len(m))
+}
+
+// CreateFramebuffer records the command and returns a new id.
+func (d *RecordingDevice) CreateFramebuffer(attachments ...FramebufferAttachment) (uint32, error) {
+	id := d.id()
+	d.record("CreateFramebuffer", id, attachments)
+	return id, nil
+}
+
+// BindFramebuffer records the command.
+func (d *RecordingDevice) BindFramebuffer(id uint32) {
+	d.framebuffer = id
+	d.record("BindFramebuffer", id)
+}
+
+// Framebuffer returns the last bound framebuffer, it isn't recorded.
+func (d *RecordingDevice) Framebuffer() uint32 {
+	return d.framebuffer
+}
+
+// DeleteFramebuffer records the command.
+func (d *RecordingDevice) DeleteFramebuffer(id uint32) {
+	d.record("DeleteFramebuffer", id)
+}
+
+// ReadPixels records the command and leaves the pixels as they are.
+func (d *RecordingDevice) ReadPixels(framebuffer uint32, x, y, width, height int32, pix []uint8) error {
+	d.record("ReadPixels", framebuffer, x, y, width, height)
+	if len(pix) < int(width*height*4) {
+		return fmt.Errorf("%v bytes don't fit %vx%v pixels", len(pix), width, height)
+	}
+
+	return nil
+}
+
+// CreateQuery records the command and returns a new id.
+func (d *RecordingDevice) CreateQuery() uint32 {
+	id := d.id()
+	d.record("CreateQuery", id)
+	return id
+}
+
+// QueryTimestamp records the command.
+func (d *RecordingDevice) QueryTimestamp(id uint32) {
+	d.record("QueryTimestamp", id)
+}
+
+// QueryResult returns 0 as the time, it isn't recorded.
+func (d *RecordingDevice) QueryResult(id uint32) (uint64, bool) {
+	return 0, true
+}
+
+// DeleteQuery records the command.
+func (d *RecordingDevice) DeleteQuery(id uint32) {
+	d.record("DeleteQuery", id)
+}
+
+// SetPipeline records the command.
+func (d *RecordingDevice) SetPipeline(p *Pipeline) {
+	d.record("SetPipeline", *p)
+}
+
+// SetViewport records the command.
+func (d *RecordingDevice) SetViewport(x, y, width, height int32) {
+	d.viewport = [4]int32{x, y, width, height}
+	d.record("SetViewport", x, y, width, height)
+}
+
+// Viewport returns the last viewport, it isn't recorded.
+func (d *RecordingDevice) Viewport() [4]int32 {
+	return d.viewport
+}
+
+// SetClearColor records the command.
+func (d *RecordingDevice) SetClearColor(c mgl32.Vec4) {
+	d.record("SetClearColor", c)
+}
+
+// Clear records the command.
+func (d *RecordingDevice) Clear(flags ClearFlags) {
+	d.record("Clear", flags)
+}
+
+// Draw records the command.
+func (d *RecordingDevice) Draw(mode Primitive, first, count int32) {
+	d.record("Draw", mode, first, count)
+}
+
+// DrawIndexed records the command.
+func (d *RecordingDevice) DrawIndexed(mode Primitive, count int32) {
+	d.record("DrawIndexed", mode, count)
+}
+
+// DrawInstanced records the command.
+func (d *RecordingDevice) DrawInstanced(mode Primitive, first, count, instances int32) {
+	d.record("DrawInstanced", mode, first, count, instances)
+}
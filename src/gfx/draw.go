@@ -0,0 +1,48 @@
+package gfx
+
+import (
+	"GopherGL/src/camera"
+	"GopherGL/src/mesh"
+	"GopherGL/src/render"
+)
+
+// Renderer is the package as a render.Renderer, so code drawing through that interface can also draw with the
+// software renderer of the raster package. It draws with the current device, call InitRenderer first.
+type Renderer struct{}
+
+var _ render.Renderer = Renderer{}
+
+// CreateEntity loads the textures with CreateMaterial and uploads the mesh.
+func (Renderer) CreateEntity(m *mesh.Mesh, fileTex, fileSpec string, shininess float32) (render.Entity, error) {
+	mat, err := CreateMaterial(fileTex, fileSpec, shininess)
+	if err != nil {
+		return nil, err
+	}
+	e, err := CreateEntity(m, mat)
+	if err != nil {
+		mat.Delete()
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// SetPointLights is the package's SetPointLights.
+func (Renderer) SetPointLights(lights ...render.PointLight) error {
+	pls := make([]*PointLight, len(lights))
+	for i, l := range lights {
+		pls[i] = &PointLight{l.Color, l.Position, l.Constant, l.Linear, l.Quadratic}
+	}
+
+	return SetPointLights(pls...)
+}
+
+// BeginFrame is the package's BeginFrame.
+func (Renderer) BeginFrame() {
+	BeginFrame()
+}
+
+// Render draws an Entity made by CreateEntity, or by the package's CreateEntity.
+func (Renderer) Render(c *camera.Camera, e render.Entity, sun render.DirectionalLight) {
+	Render(c, e.(*Entity), &DirectionalLight{sun.Color, sun.Dir, sun.Intensity})
+}
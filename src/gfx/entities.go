@@ -1,116 +1,198 @@
 package gfx
 
 import (
-	"github.com/go-gl/gl/v3.3-core/gl"
+	"errors"
+
 	"github.com/go-gl/mathgl/mgl32"
+
+	"GopherGL/src/lod"
+	"GopherGL/src/mesh"
+	"GopherGL/src/spatial"
 )
 
 // Entity represents a mesh with material. It also contains the position, rotation and later maybe the scale.
 type Entity struct {
 	vao              uint32
 	size             int32
+	buffers          []uint32
 	PosX, PosY, PosZ float32
 	rotX, rotY, rotZ float32
 	Trans            mgl32.Mat4
 	mat              *Material
+
+	// LOD picks which of the meshes to draw, it's nil when the Entity has only one mesh.
+	LOD    *lod.Selector
+	lods   []lodMesh
+	radius float32
+	bounds spatial.AABB
+	// shared is true when the mesh is a Mesh other entities draw too, the entity doesn't delete it.
+	shared bool
+	// indices are the scene indices the Entity is in, they're told when it moves.
+	indices []*SceneIndex
+}
+
+// Mesh is a mesh on the GPU that many entities can draw, see AcquireMesh and CreateEntityFromMesh.
+type Mesh struct {
+	vao     uint32
+	size    int32
+	buffers []uint32
+	bytes   int64
+	radius  float32
+	bounds  spatial.AABB
+}
+
+// lodMesh is an uploaded level of detail.
+type lodMesh struct {
+	vao     uint32
+	size    int32
+	buffers []uint32
 }
 
 // CreateCube returns a pointer to an Entity which is a cube.
-func CreateCube(posX, posY, posZ, rotX, rotY, rotZ float32, mat *Material) *Entity {
-	c := &Entity{}
+func CreateCube(posX, posY, posZ, rotX, rotY, rotZ float32, mat *Material) (*Entity, error) {
+	c, err := CreateEntity(mesh.Cube(), mat)
+	if err != nil {
+		return nil, err
+	}
 
 	c.PosX, c.PosY, c.PosZ = posX, posY, posZ
-	c.rotX, c.rotY, c.rotZ = rotX, rotY, rotZ
-	c.Trans = mgl32.HomogRotate3DX(c.rotX).Mul4(mgl32.HomogRotate3DY(c.rotY)).Mul4(mgl32.HomogRotate3DZ(c.rotZ))
-	c.Trans = c.Trans.Mul4(mgl32.Translate3D(posX, posY, posZ))
-	c.mat = mat
-
-	vertices := []float32 {
-		// positions      tex coords normals
-		// Back quad.
-		0.5, -0.5, -0.5, 0.0, 0.0, 0.0, 0.0, -1.0,
-		-0.5, -0.5, -0.5, 1.0, 0.0, 0.0, 0.0, -1.0,
-		-0.5, 0.5, -0.5, 1.0, 1.0, 0.0, 0.0, -1.0,
-		0.5, 0.5, -0.5, 0.0, 1.0, 0.0, 0.0, -1.0,
-
-		// Front quad.
-		-0.5, -0.5, 0.5, 0.0, 0.0, 0.0, 0.0, 1.0,
-		0.5, -0.5, 0.5, 1.0, 0.0, 0.0, 0.0, 1.0,
-		0.5, 0.5, 0.5, 1.0, 1.0, 0.0, 0.0, 1.0,
-		-0.5, 0.5, 0.5, 0.0, 1.0, 0.0, 0.0, 1.0,
-
-		// Left quad.
-		-0.5, -0.5, -0.5, 0.0, 0.0, -1.0, 0.0, 0.0,
-		-0.5, -0.5, 0.5, 1.0, 0.0, -1.0, 0.0, 0.0,
-		-0.5, 0.5, 0.5, 1.0, 1.0, -1.0, 0.0, 0.0,
-		-0.5, 0.5, -0.5, 0.0, 1.0, -1.0, 0.0, 0.0,
-
-		// Right quad.
-		0.5, -0.5, 0.5, 0.0, 0.0, 1.0, 0.0, 0.0,
-		0.5, -0.5, -0.5, 1.0, 0.0, 1.0, 0.0, 0.0,
-		0.5, 0.5, -0.5, 1.0, 1.0, 1.0, 0.0, 0.0,
-		0.5, 0.5, 0.5, 0.0, 1.0, 1.0, 0.0, 0.0,
-
-		// Bottom quad.
-		0.5, -0.5, 0.5, 1.0, 0.0, 0.0, -1.0, 0.0,
-		-0.5, -0.5, 0.5, 0.0, 0.0, 0.0, -1.0, 0.0,
-		-0.5, -0.5, -0.5, 0.0, 1.0, 0.0, -1.0, 0.0,
-		0.5, -0.5, -0.5, 1.0, 1.0, 0.0, -1.0, 0.0,
-
-		// Top quad.
-		-0.5, 0.5, 0.5, 0.0, 0.0, 0.0, 1.0, 0.0,
-		0.5, 0.5, 0.5, 1.0, 0.0, 0.0, 1.0, 0.0,
-		0.5, 0.5, -0.5, 1.0, 1.0, 0.0, 1.0, 0.0,
-		-0.5, 0.5, -0.5, 0.0, 1.0, 0.0, 1.0, 0.0,
+	c.SetRot(rotX, rotY, rotZ)
+
+	return c, nil
+}
+
+// CreateEntity uploads the mesh and returns an Entity at the origin. Use PlaceholderMaterial when the material
+// couldn't be loaded.
+func CreateEntity(m *mesh.Mesh, mat *Material) (*Entity, error) {
+	if mat == nil {
+		return nil, errors.New("an entity needs a material")
+	}
+	if len(m.Indices) == 0 {
+		return nil, errors.New("the mesh has no triangles")
+	}
+
+	e := &Entity{}
+	e.Trans = mgl32.Ident4()
+	e.mat = mat
+	e.vao, e.size, e.buffers = uploadMesh(m)
+	e.radius = m.Radius()
+	e.bounds.Min, e.bounds.Max = m.Bounds()
+
+	return e, nil
+}
+
+// CreateEntityFromMesh returns an Entity at the origin drawing a mesh that's already uploaded. The mesh isn't
+// deleted with the entity, so it has to stay around while the entity is drawn.
+func CreateEntityFromMesh(m *Mesh, mat *Material) (*Entity, error) {
+	if mat == nil {
+		return nil, errors.New("an entity needs a material")
 	}
 
-	indices := []uint32{
-		0, 2, 3,
-		0, 1, 2,
+	e := &Entity{}
+	e.Trans = mgl32.Ident4()
+	e.mat = mat
+	e.vao, e.size, e.buffers = m.vao, m.size, m.buffers
+	e.radius = m.radius
+	e.bounds = m.bounds
+	e.shared = true
 
-		4, 6, 7,
-		4, 5, 6,
+	return e, nil
+}
 
-		8, 10, 11,
-		8, 9, 10,
+// SetMaterial replaces the material, like when the real one is loaded.
+func (e *Entity) SetMaterial(mat *Material) {
+	e.mat = mat
+}
 
-		12, 14, 15,
-		12, 13, 14,
+// SetMesh replaces the mesh, for example when its file changed. The levels of detail stay.
+func (e *Entity) SetMesh(m *mesh.Mesh) {
+	vao, size, buffers := uploadMesh(m)
+	if !e.shared {
+		deleteMesh(e.vao, e.buffers)
+	}
+	e.vao, e.size, e.buffers, e.shared = vao, size, buffers, false
+	e.radius = m.Radius()
+	e.bounds.Min, e.bounds.Max = m.Bounds()
+	e.moved()
 
-		16, 18, 19,
-		16, 17, 18,
+	if e.LOD != nil {
+		e.lods[0] = lodMesh{e.vao, e.size, e.buffers}
+	}
+}
 
-		20, 22, 23,
-		20, 21, 22,
+// AddLOD uploads a less detailed version of the mesh, which is used from the switch point in the level.
+// Add them from most to least detailed. The switch points are by distance, unless the LOD mode is changed.
+func (e *Entity) AddLOD(m *mesh.Mesh, l lod.Level) {
+	if e.LOD == nil {
+		e.LOD = lod.CreateSelector(lod.ByDistance, lod.Level{})
+		e.lods = []lodMesh{{e.vao, e.size, e.buffers}}
 	}
-	c.size = int32(len(indices) * 4)
 
-	gl.GenVertexArrays(1, &c.vao)
-	gl.BindVertexArray(c.vao)
+	vao, size, buffers := uploadMesh(m)
+	e.lods = append(e.lods, lodMesh{vao, size, buffers})
+	e.LOD.Levels = append(e.LOD.Levels, l)
+}
 
-	var vbo uint32
-	gl.GenBuffers(1, &vbo)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STATIC_DRAW)
+// uploadMesh stores the vertices and indices of the mesh in buffers, and returns the vertex array, index count
+// and the buffers.
+func uploadMesh(m *mesh.Mesh) (uint32, int32, []uint32) {
+	vao := device.CreateVertexArray()
+	vbo := device.CreateBuffer(m.Vertices, StaticDraw)
+	buffers := []uint32{vbo}
 
 	// Pass data to the shader.
 	// Positions.
-	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, 8*4, gl.PtrOffset(0))
-	gl.EnableVertexAttribArray(0)
+	device.SetVertexAttrib(vbo, VertexAttrib{Index: 0, Size: 3, Stride: mesh.VertexSize * 4, Offset: 0})
 	// Texture coordinates.
-	gl.VertexAttribPointer(1, 2, gl.FLOAT, false, 8*4, gl.PtrOffset(3*4))
-	gl.EnableVertexAttribArray(1)
+	device.SetVertexAttrib(vbo, VertexAttrib{Index: 1, Size: 2, Stride: mesh.VertexSize * 4, Offset: 3 * 4})
 	// Normals.
-	gl.VertexAttribPointer(2, 3, gl.FLOAT, false, 8*4, gl.PtrOffset(5*4))
-	gl.EnableVertexAttribArray(2)
+	device.SetVertexAttrib(vbo, VertexAttrib{Index: 2, Size: 3, Stride: mesh.VertexSize * 4, Offset: 5 * 4})
+
+	if m.Skinned() {
+		// Joint indices, these have to stay integers.
+		jbo := device.CreateBuffer(m.Joints, StaticDraw)
+		device.SetVertexAttrib(jbo, VertexAttrib{Index: 3, Size: 4, Type: AttribUnsignedShort, Stride: 4 * 2, Integer: true})
+
+		// Joint weights.
+		wbo := device.CreateBuffer(m.Weights, StaticDraw)
+		device.SetVertexAttrib(wbo, VertexAttrib{Index: 4, Size: 4, Stride: 4 * 4})
+		buffers = append(buffers, jbo, wbo)
+	}
 
-	var ibo uint32
 	// Store the indices in a buffer.
-	gl.GenBuffers(1, &ibo)
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ibo)
-	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, int(c.size), gl.Ptr(indices), gl.STATIC_DRAW)
+	ibo := device.CreateBuffer(m.Indices, StaticDraw)
+	device.SetIndexBuffer(ibo)
+	buffers = append(buffers, ibo)
+
+	return vao, int32(len(m.Indices)), buffers
+}
+
+// createMesh uploads a mesh to share, see Mesh.
+func createMesh(m *mesh.Mesh) (*Mesh, error) {
+	if len(m.Indices) == 0 {
+		return nil, errors.New("the mesh has no triangles")
+	}
+
+	gm := &Mesh{radius: m.Radius()}
+	gm.vao, gm.size, gm.buffers = uploadMesh(m)
+	gm.bounds.Min, gm.bounds.Max = m.Bounds()
+	gm.bytes = int64(len(m.Vertices)*4 + len(m.Indices)*4 + len(m.Joints)*2 + len(m.Weights)*4)
+
+	return gm, nil
+}
+
+// delete frees the buffers of the mesh, when the last handle to it is released.
+func (m *Mesh) delete() {
+	deleteMesh(m.vao, m.buffers)
+	m.vao, m.buffers = 0, nil
+}
 
-	return c
+// deleteMesh frees what uploadMesh created.
+func deleteMesh(vao uint32, buffers []uint32) {
+	device.DeleteVertexArray(vao)
+	for _, b := range buffers {
+		device.DeleteBuffer(b)
+	}
 }
 
 /*
@@ -193,6 +275,38 @@ func CreateModel(objFile string, mat Material) *Entity {
 // SetRot takes in x, y, and z values in degrees and rotates the Entity.
 func (e *Entity) SetRot(x, y, z float32) {
 	e.rotX, e.rotY, e.rotZ = x, y, z
+	e.updateTrans()
+}
+
+// SetPos moves the Entity to x, y and z.
+func (e *Entity) SetPos(x, y, z float32) {
+	e.PosX, e.PosY, e.PosZ = x, y, z
+	e.updateTrans()
+}
+
+// SetTransform replaces the transformation matrix, until SetPos or SetRot is called. Set Trans with this instead of
+// directly, or a SceneIndex won't see it moved.
+func (e *Entity) SetTransform(trans mgl32.Mat4) {
+	e.Trans = trans
+	e.moved()
+}
+
+// moved marks the Entity dirty in its scene indices.
+func (e *Entity) moved() {
+	for _, s := range e.indices {
+		s.dirty[e] = true
+	}
+}
+
+// Bounds returns the box around the Entity in world space.
+func (e *Entity) Bounds() spatial.AABB {
+	return e.bounds.Transform(e.Trans)
+}
+
+// updateTrans recalculates the transformation matrix from the position and rotation, in the same order SetRot always
+// used: the rotation is applied after the translation.
+func (e *Entity) updateTrans() {
 	e.Trans = mgl32.HomogRotate3DX(e.rotX).Mul4(mgl32.HomogRotate3DY(e.rotY)).Mul4(mgl32.HomogRotate3DZ(e.rotZ))
 	e.Trans = e.Trans.Mul4(mgl32.Translate3D(e.PosX, e.PosY, e.PosZ))
+	e.moved()
 }
@@ -0,0 +1,75 @@
+package gfx
+
+import (
+	"fmt"
+	"os"
+
+	"GopherGL/src/glsl"
+)
+
+// NotFoundError is a file that doesn't exist, like a texture or a shader, or a file a shader includes.
+type NotFoundError struct {
+	File string
+	Err  error
+}
+
+// Error returns the file that wasn't found.
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("file not found: %v", e.Err)
+}
+
+// DecodeError is a file that was there, but couldn't be read as what it should be, like a broken image.
+type DecodeError struct {
+	File string
+	Err  error
+}
+
+// Error returns the file and why it couldn't be decoded.
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("can't decode %v: %v", e.File, e.Err)
+}
+
+// CompileError is a shader stage that didn't compile, Log is what the driver said about it. When the shader came
+// from a file, the lines in the log point at the lines of the files.
+type CompileError struct {
+	File  string
+	Stage string
+	Log   string
+}
+
+// Error returns the stage and the log.
+func (e *CompileError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("failed to compile the %v shader: %v", e.Stage, e.Log)
+	}
+	return fmt.Sprintf("%v: failed to compile the %v shader: %v", e.File, e.Stage, e.Log)
+}
+
+// LinkError is a program whose stages compiled but don't fit together, like an output of the vertex stage the
+// fragment stage reads with another type.
+type LinkError struct {
+	File string
+	Log  string
+}
+
+// Error returns the log.
+func (e *LinkError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("failed to link: %v", e.Log)
+	}
+	return fmt.Sprintf("%v: failed to link: %v", e.File, e.Log)
+}
+
+// fileError returns a NotFoundError when the file, or a file it includes, doesn't exist. Other errors are
+// DecodeErrors.
+func fileError(file string, err error) error {
+	cause := err
+	if e, ok := err.(*glsl.Error); ok {
+		cause = e.Err
+	}
+	if os.IsNotExist(cause) {
+		return &NotFoundError{File: file, Err: err}
+	}
+
+	return &DecodeError{File: file, Err: err}
+}
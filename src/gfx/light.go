@@ -17,11 +17,8 @@ type DirectionalLight struct {
 	intensity float32
 }
 
-// CreateDirectionalLight returns a pointer to the light and sets the uniforms in the shader.
+// CreateDirectionalLight returns a pointer to the light, it's uploaded to the shaders when it's rendered with.
 func CreateDirectionalLight(dir mgl32.Vec3, i float32) *DirectionalLight {
-	//directionalShader.SetUniformFloat("sun.intensity", i)
-	//directionalShader.SetUniformVec3("sun.direction", dir)
-
 	return &DirectionalLight {
 		mgl32.Vec3{1.0, 1.0, 1.0},
 		dir,
@@ -29,13 +26,43 @@ func CreateDirectionalLight(dir mgl32.Vec3, i float32) *DirectionalLight {
 	}
 }
 
+// SetDirection changes the direction the light shines in.
+func (dl *DirectionalLight) SetDirection(dir mgl32.Vec3) {
+	dl.dir = dir
+}
+
+// SetIntensity changes how bright the light is.
+func (dl *DirectionalLight) SetIntensity(i float32) {
+	dl.intensity = i
+}
+
+// SetColor changes the color of the light.
+func (dl *DirectionalLight) SetColor(color mgl32.Vec3) {
+	dl.color = color
+}
+
 // PointLight is a type of light were position matters, it will shine in all directions.
 type PointLight struct {
 	color, position mgl32.Vec3
 	constant, linear, quadratic float32
 }
-/*
-func CreatePointLight(s *Shader, pos mgl32.Vec3) *PointLight {
-	
+
+// CreatePointLight returns a light at the position, that reaches about 50 units. Use SetPointLights to turn it on.
+func CreatePointLight(pos, color mgl32.Vec3) *PointLight {
+	return &PointLight{color, pos, 1.0, 0.09, 0.032}
+}
+
+// SetPosition moves the light.
+func (pl *PointLight) SetPosition(pos mgl32.Vec3) {
+	pl.position = pos
 }
-*/
\ No newline at end of file
+
+// SetColor changes the color of the light, brighter than 1 makes it reach further.
+func (pl *PointLight) SetColor(color mgl32.Vec3) {
+	pl.color = color
+}
+
+// SetAttenuation changes how fast the light fades with the distance d: 1 / (constant + linear*d + quadratic*d*d).
+func (pl *PointLight) SetAttenuation(constant, linear, quadratic float32) {
+	pl.constant, pl.linear, pl.quadratic = constant, linear, quadratic
+}
\ No newline at end of file
@@ -1,63 +1,611 @@
 package gfx
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
-	"image"
-	"image/draw"
-	"os"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
 
-	"github.com/disintegration/imaging"
-	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"GopherGL/src/glsl"
 )
 
-// Material can be attached to an Entity.
+// Material can be attached to an Entity. It's the shader an Entity is drawn with, the textures and parameters the
+// shader gets, and the render state. Load one from a file with LoadMaterial.
 type Material struct {
-	texID, specID     uint32
-	Shininess float32
+	// State is how the material is drawn, it starts as alpha blending, depth testing and culling.
+	State RenderState
+
+	file string
+	// shader is nil when the renderer picks the shader, like basic.glsl for Render. shaderHandle is its handle.
+	shader       *Shader
+	shaderHandle *ShaderHandle
+	features     []string
+	textures     []materialTexture
+	params       map[string][]float32
+	// unwatch stops reloading the material when its file changes, it's nil when the file isn't watched.
+	unwatch func()
+}
+
+// materialTexture is a texture and the sampler uniform it's bound to. File is "" for textures that weren't loaded.
+// Textures in an atlas have a region. The handle is what the texture or atlas was acquired with, it's nil for
+// ones set with SetAtlasTexture, and the placeholder's.
+type materialTexture struct {
+	uniform, file string
+	tex           *Texture
+	region        *AtlasRegion
+	handle        *Handle
+}
+
+// release frees the texture, when the material owns it.
+func (t materialTexture) release() {
+	if t.handle != nil {
+		t.handle.Release()
+	} else if t.region == nil {
+		t.tex.Delete()
+	}
+}
+
+// RenderState is the fixed function state a material is drawn with, see Pipeline.
+type RenderState struct {
+	Blend      BlendMode
+	DepthTest  bool
+	DepthWrite bool
+	DepthFunc  Compare
+	Cull       bool
+}
+
+// defaultState is the state of defaultPipeline.
+func defaultState() RenderState {
+	p := defaultPipeline(0)
+	return RenderState{p.Blend, p.DepthTest, p.DepthWrite, p.DepthFunc, p.Cull}
+}
+
+// pipeline returns the state of the material, drawing with the program.
+func (m *Material) pipeline(program uint32) Pipeline {
+	s := m.State
+	return Pipeline{program, s.Blend, s.DepthTest, s.DepthWrite, s.DepthFunc, s.Cull}
+}
+
+// CreateMaterial takes in an albedo and specular texture. And you can also set the shininess of the specular part.
+// It's drawn with the shader the renderer picks. The textures are acquired, see AcquireTexture, so with hot
+// reloading on they're uploaded again when their files change. When a texture can't be loaded, the error says why
+// and PlaceholderMaterial can be used instead.
+func CreateMaterial(fileTex, fileSpec string, shininess float32) (*Material, error) {
+	m := &Material{State: defaultState(), params: make(map[string][]float32)}
+	if err := m.SetTexture("mat.diffTex", fileTex); err != nil {
+		return nil, err
+	}
+	if err := m.SetTexture("mat.specTex", fileSpec); err != nil {
+		m.Delete()
+		return nil, err
+	}
+	m.params["mat.shininess"] = []float32{shininess}
+
+	return m, nil
 }
 
-// createTex reads and sets the texture to whatever is passed.
-func createTex(texFile string) (uint32, error) {
-	// Open the texture file.
-	tex, err := os.Open(texFile)
-	check(err)
-	defer tex.Close()
+// materialFile is what's in a material file, for example:
+//
+//	{
+//		"shader": "../shaders/basic.glsl",
+//		"features": ["NORMAL_MAP"],
+//		"textures": {"mat.diffTex": "containerTex.png", "mat.specTex": "props.json#containerSpec"},
+//		"params": {"mat.shininess": 1.0},
+//		"state": {"blend": "none", "cull": false}
+//	}
+//
+// Files are relative to the material file, a texture with a # is a region of an atlas, see SetTexture. Without a
+// shader the renderer picks one. The params are numbers, bools, or arrays of 2 or 3 numbers for vec2 and vec3
+// uniforms. The state can have blend ("none", "alpha" or "additive"), depthTest, depthWrite, depthFunc ("less",
+// "lequal", "equal" or "always") and cull, what isn't there is like CreateMaterial.
+type materialFile struct {
+	Shader   string                 `json:"shader"`
+	Features []string               `json:"features"`
+	Textures map[string]string      `json:"textures"`
+	Params   map[string]interface{} `json:"params"`
+	State    struct {
+		Blend      *string `json:"blend"`
+		DepthTest  *bool   `json:"depthTest"`
+		DepthWrite *bool   `json:"depthWrite"`
+		DepthFunc  *string `json:"depthFunc"`
+		Cull       *bool   `json:"cull"`
+	} `json:"state"`
+}
 
-	texImage, _, err := image.Decode(tex)
-	check(err)
-	texImage = imaging.FlipV(texImage) // We need to flip it because OpenGL has 0, 0 in the bottom left.
+var (
+	blendNames   = map[string]BlendMode{"none": BlendNone, "alpha": BlendAlpha, "additive": BlendAdditive}
+	compareNames = map[string]Compare{"less": Less, "lequal": LessEqual, "equal": Equal, "always": Always}
+)
 
-	rgba := image.NewRGBA(texImage.Bounds())
-	if rgba.Stride != rgba.Rect.Size().X*4 {
-		return 0, fmt.Errorf("stride from %v is unsupported", texFile)
+// LoadMaterial reads a material file, see materialFile for what it looks like. The textures and parameters are
+// checked against the uniforms the shader uses. The shader and textures are acquired, so ones other materials
+// use aren't loaded again. With hot reloading on the material is loaded again when the file changes. The error is
+// a *NotFoundError or *DecodeError for the material file and its textures, it's one of the errors of the shader
+// when that doesn't compile, and it says which uniform is wrong otherwise.
+func LoadMaterial(file string) (*Material, error) {
+	m, err := readMaterial(file)
+	if err != nil {
+		return nil, err
 	}
-	draw.Draw(rgba, rgba.Bounds(), texImage, image.Point{0, 0}, draw.Src)
 
-	// Generate and bind the buffer.
-	var texture uint32
-	gl.GenTextures(1, &texture)
-	gl.BindTexture(gl.TEXTURE_2D, texture)
+	m.watch()
+	return m, nil
+}
 
-	// Parameters for the texture.
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+// readMaterial reads a material file, without watching its files.
+func readMaterial(file string) (*Material, error) {
+	d, err := readMaterialData(file, device.SupportsFormat, nil)
+	if err != nil {
+		return nil, err
+	}
+	return d.create()
+}
 
-	// Pass the data to OpenGL and generate mipmaps.
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, int32(rgba.Rect.Size().X), int32(rgba.Rect.Size().Y),
-		0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(rgba.Pix))
-	gl.GenerateMipmap(gl.TEXTURE_2D)
+// materialData is a material file that was read, with the shader preprocessed and the textures decoded. It's made
+// without the device, see LoadMaterialAsync.
+type materialData struct {
+	m          *Material
+	shaderFile string
+	// shader is nil when the material doesn't have one.
+	shader   *glsl.Program
+	textures []*textureRef
+}
 
-	return texture, nil
+// textureRef is a texture of a material that was read, a texture file or a region of an atlas.
+type textureRef struct {
+	uniform, file string
+	tex           *textureData
+	// tex is nil when the texture was already loaded. atlasFile and region are set for textures in an atlas, atlas
+	// is nil when the atlas was already loaded.
+	atlasFile, region string
+	atlas             *atlasData
 }
 
-// CreateMaterial takes in an albedo and specular texture. And you can also set the shininess of the specular part.
-func CreateMaterial(fileTex, fileSpec string, shininess float32) *Material {
-	texID, err := createTex(fileTex)
-	check(err)
-	specID, err := createTex(fileSpec)
-	check(err)
+// readMaterialData reads a material file, supports says which texture formats the device can sample. progress is
+// called with the textures that were read, it can be nil.
+func readMaterialData(file string, supports func(TextureFormat) bool,
+	progress func(done, total int)) (*materialData, error) {
+	src, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fileError(file, err)
+	}
+
+	var f materialFile
+	dec := json.NewDecoder(bytes.NewReader(src))
+	// A typo in a key would be ignored otherwise.
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&f); err != nil {
+		return nil, &DecodeError{File: file, Err: err}
+	}
+
+	m := &Material{State: defaultState(), file: file, features: f.Features, params: make(map[string][]float32)}
+	if err := m.setState(f); err != nil {
+		return nil, &DecodeError{File: file, Err: err}
+	}
+	for name, v := range f.Params {
+		values, err := paramValues(v)
+		if err != nil {
+			return nil, &DecodeError{File: file, Err: fmt.Errorf("%v: %v", name, err)}
+		}
+		m.params[name] = values
+	}
+
+	d := &materialData{m: m}
+	dir := filepath.Dir(file)
+	if f.Shader != "" {
+		d.shaderFile = filepath.Join(dir, f.Shader)
+		if d.shader, err = preprocess(d.shaderFile, f.Features); err != nil {
+			return nil, err
+		}
+	}
+
+	// Sorted, so the texture units don't change between runs.
+	names := make([]string, 0, len(f.Textures))
+	for name := range f.Textures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		if progress != nil {
+			progress(i, len(names))
+		}
+		t, err := readTextureRef(name, filepath.Join(dir, f.Textures[name]), supports)
+		if err != nil {
+			return nil, err
+		}
+		d.textures = append(d.textures, t)
+	}
+
+	return d, nil
+}
+
+// create compiles the shader, if it wasn't already, and uploads the textures that aren't loaded.
+func (d *materialData) create() (*Material, error) {
+	m, file := d.m, d.m.file
+	if d.shader != nil {
+		h, err := acquireShader(d.shaderFile, m.features, d.shader)
+		if err != nil {
+			return nil, err
+		}
+		m.shader, m.shaderHandle = h.Shader(), h
+	}
+
+	for _, t := range d.textures {
+		if err := m.setTextureRef(t); err != nil {
+			m.Delete()
+			switch err.(type) {
+			case *NotFoundError, *DecodeError:
+				return nil, err
+			}
+			return nil, fmt.Errorf("%v: %v", file, err)
+		}
+	}
+
+	if err := m.validate(); err != nil {
+		m.Delete()
+		return nil, fmt.Errorf("%v: %v", file, err)
+	}
+
+	return m, nil
+}
+
+// setState sets the render state the file has.
+func (m *Material) setState(f materialFile) error {
+	s := f.State
+	if s.Blend != nil {
+		b, ok := blendNames[*s.Blend]
+		if !ok {
+			return fmt.Errorf("unknown blend mode %q", *s.Blend)
+		}
+		m.State.Blend = b
+	}
+	if s.DepthFunc != nil {
+		c, ok := compareNames[*s.DepthFunc]
+		if !ok {
+			return fmt.Errorf("unknown depth function %q", *s.DepthFunc)
+		}
+		m.State.DepthFunc = c
+	}
+	for _, b := range []struct {
+		from *bool
+		to   *bool
+	}{{s.DepthTest, &m.State.DepthTest}, {s.DepthWrite, &m.State.DepthWrite}, {s.Cull, &m.State.Cull}} {
+		if b.from != nil {
+			*b.to = *b.from
+		}
+	}
+
+	return nil
+}
+
+// paramValues converts a parameter from JSON.
+func paramValues(v interface{}) ([]float32, error) {
+	switch v := v.(type) {
+	case float64:
+		return []float32{float32(v)}, nil
+	case bool:
+		if v {
+			return []float32{1.0}, nil
+		}
+		return []float32{0.0}, nil
+	case []interface{}:
+		values := make([]float32, len(v))
+		for i, e := range v {
+			f, ok := e.(float64)
+			if !ok {
+				return nil, fmt.Errorf("%v isn't a number", e)
+			}
+			values[i] = float32(f)
+		}
+		if len(values) < 2 || len(values) > 3 {
+			return nil, fmt.Errorf("%v numbers, a vector has 2 or 3", len(values))
+		}
+		return values, nil
+	}
+
+	return nil, fmt.Errorf("%v isn't a number, bool or vector", v)
+}
+
+// loadShader returns the variant of the shader file, compiling it when it's the first time. p is the file
+// preprocessed with the features.
+func loadShader(file string, features []string, p *glsl.Program) (*Shader, error) {
+	if s := findShader(file); s != nil {
+		return s.variant(features, p)
+	}
+	return createShaderFrom(file, features, p)
+}
+
+// findShader returns a variant of the shader file that was compiled, or nil.
+func findShader(file string) *Shader {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return nil
+	}
+	for _, s := range shaders {
+		if a, err := filepath.Abs(s.file); err == nil && a == abs {
+			return s
+		}
+	}
+
+	return nil
+}
+
+// watch reloads the material when its file changes. The textures reload themselves, see AcquireTexture.
+func (m *Material) watch() {
+	if m.file == "" || m.unwatch != nil {
+		return
+	}
+	m.unwatch = OnChange(m.file, m.reload)
+}
 
-	return &Material{texID, specID, shininess}
+// reload reads the material file again. When that fails the material stays like it was. The textures that didn't
+// change are acquired again, so they aren't loaded again.
+func (m *Material) reload() error {
+	if m.unwatch == nil {
+		// It was deleted, by something else reloading in the same frame.
+		return nil
+	}
+	n, err := readMaterial(m.file)
+	if err != nil {
+		return err
+	}
+
+	// The new material keeps watching the file.
+	unwatch := m.unwatch
+	m.unwatch = nil
+	m.Delete()
+	n.unwatch = unwatch
+	*m = *n
+
+	return nil
+}
+
+// validate checks the textures and parameters against the uniforms the shader uses. Without a shader it's checked
+// against basic.glsl, once the renderer is set up.
+func (m *Material) validate() error {
+	s := m.shader
+	if s == nil {
+		s = basicShader
+	}
+	if s == nil {
+		return nil
+	}
+
+	for _, t := range m.textures {
+		if _, err := s.uniform(t.uniform, 1, "sampler*"); err != nil {
+			return err
+		}
+	}
+	for name, v := range m.params {
+		if _, err := s.uniform(name, 1, paramTypes[len(v)]...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// paramTypes are the uniform types a parameter with that many values can set.
+var paramTypes = map[int][]string{
+	1: {"float", "int", "bool"},
+	2: {"vec2"},
+	3: {"vec3"},
+}
+
+// SetTexture acquires the texture file for the sampler uniform, replacing the texture it had, see AcquireTexture.
+// A file like "props.json#crate" is the region crate of the atlas props.json, see AcquireAtlas.
+func (m *Material) SetTexture(uniform, file string) error {
+	t, err := readTextureRef(uniform, file, device.SupportsFormat)
+	if err != nil {
+		return err
+	}
+	return m.setTextureRef(t)
+}
+
+// readTextureRef reads a texture of a material, with the default options. Textures and atlases are only read
+// when they aren't loaded yet.
+func readTextureRef(uniform, file string, supports func(TextureFormat) bool) (*textureRef, error) {
+	t := &textureRef{uniform: uniform, file: file}
+	var err error
+	if atlasFile, region, ok := splitRef(file); ok {
+		t.atlasFile, t.region = atlasFile, region
+		if !assetLoaded("atlas", atlasFile, "") {
+			t.atlas, err = readAtlas(atlasFile, AtlasTextureOptions(), supports)
+		}
+	} else if o := DefaultTextureOptions(); !assetLoaded("texture", file, textureKey(o)) {
+		t.tex, err = readTexture(file, o, supports)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// setTextureRef acquires a texture that was read and sets it.
+func (m *Material) setTextureRef(t *textureRef) error {
+	var mt materialTexture
+	if t.atlasFile != "" {
+		h, err := acquireAtlas(t.atlasFile, t.atlas)
+		if err != nil {
+			return err
+		}
+		r, ok := h.Atlas().Region(t.region)
+		if !ok {
+			h.Release()
+			return fmt.Errorf("%v: there's no region %q", t.atlasFile, t.region)
+		}
+		mt = materialTexture{t.uniform, t.file, r.Texture, &r, &h.Handle}
+	} else {
+		h, err := acquireTexture(t.file, DefaultTextureOptions(), t.tex)
+		if err != nil {
+			return err
+		}
+		mt = materialTexture{t.uniform, t.file, h.Texture(), nil, &h.Handle}
+	}
+
+	if err := m.setTexture(mt); err != nil {
+		mt.release()
+		return err
+	}
+	return nil
+}
+
+// SetAtlasTexture uses a region of the atlas for the sampler uniform, replacing the texture it had. The atlas isn't
+// deleted with the material. The texture coordinates of the meshes are moved into the region, so every texture of
+// the material has to be in a region with the same coordinates, like in atlases packed the same way.
+func (m *Material) SetAtlasTexture(uniform string, a *Atlas, name string) error {
+	r, ok := a.Region(name)
+	if !ok {
+		return fmt.Errorf("the atlas has no region %q", name)
+	}
+	return m.setTexture(materialTexture{uniform: uniform, tex: r.Texture, region: &r})
+}
+
+// setTexture adds the texture, or replaces the one of its uniform.
+func (m *Material) setTexture(t materialTexture) error {
+	for _, other := range m.textures {
+		if other.uniform == t.uniform {
+			continue
+		}
+		if (other.region == nil) != (t.region == nil) || t.region != nil &&
+			(other.region.Offset != t.region.Offset || other.region.Scale != t.region.Scale) {
+			return fmt.Errorf("%v and %v aren't in atlas regions with the same texture coordinates, the "+
+				"textures of a material are all in the same place or none is in an atlas", t.uniform, other.uniform)
+		}
+	}
+
+	for i := range m.textures {
+		if m.textures[i].uniform == t.uniform {
+			m.textures[i].release()
+			m.textures[i] = t
+			return nil
+		}
+	}
+	m.textures = append(m.textures, t)
+
+	return nil
+}
+
+// SetParam sets a float, int or bool uniform to one value, or a vec2 or vec3 uniform to two or three. When the
+// material has a shader it's an error if the shader doesn't use the uniform, or it has another type.
+func (m *Material) SetParam(uniform string, values ...float32) error {
+	if len(paramTypes[len(values)]) == 0 {
+		return fmt.Errorf("%v values, a parameter has 1, 2 or 3", len(values))
+	}
+	if m.shader != nil {
+		if _, err := m.shader.uniform(uniform, 1, paramTypes[len(values)]...); err != nil {
+			return err
+		}
+	}
+
+	m.params[uniform] = append([]float32(nil), values...)
+	return nil
+}
+
+// Param returns the values of a parameter, or nil when the material doesn't set it.
+func (m *Material) Param(uniform string) []float32 {
+	return m.params[uniform]
+}
+
+// shaderOr returns the shader of the material, or the fallback when it doesn't have one.
+func (m *Material) shaderOr(fallback *Shader) *Shader {
+	if m.shader != nil {
+		return m.shader
+	}
+	return fallback
+}
+
+// apply binds the textures, from texture unit 0 on, and sets the parameters. Uniforms the shader doesn't have are
+// skipped, a material without a shader is used with more than one. Textures on the same atlas page as the ones of
+// the material drawn before aren't bound again.
+func (m *Material) apply(s *Shader) {
+	offset, scale := mgl32.Vec2{0.0, 0.0}, mgl32.Vec2{1.0, 1.0}
+	for i, t := range m.textures {
+		t.tex.bind(i)
+		s.SetUniformInt32(t.uniform, int32(i))
+		if t.region != nil {
+			offset, scale = t.region.Offset, t.region.Scale
+		}
+	}
+	// The texture coordinates of the mesh are moved into the atlas region, see mesh.glsl.
+	if _, ok := s.uniforms["uvOffset"]; ok {
+		s.SetUniformVec2("uvOffset", offset)
+		s.SetUniformVec2("uvScale", scale)
+	}
+
+	for name, v := range m.params {
+		u, ok := s.uniforms[name]
+		if !ok {
+			continue
+		}
+		switch {
+		case u.Type == "vec2" && len(v) == 2:
+			s.SetUniformVec2(name, mgl32.Vec2{v[0], v[1]})
+		case u.Type == "vec3" && len(v) == 3:
+			s.SetUniformVec3(name, mgl32.Vec3{v[0], v[1], v[2]})
+		case u.Type == "float":
+			s.SetUniformFloat(name, v[0])
+		case u.Type == "int" || u.Type == "bool":
+			s.SetUniformInt32(name, int32(v[0]))
+		}
+	}
+}
+
+// Delete releases the shader and textures, atlases set with SetAtlasTexture aren't deleted. The material can't be
+// drawn after this, and isn't reloaded when its file changes.
+func (m *Material) Delete() {
+	for _, t := range m.textures {
+		t.release()
+	}
+	m.textures = nil
+	if m.shaderHandle != nil {
+		m.shaderHandle.Release()
+		m.shader, m.shaderHandle = nil, nil
+	}
+	if m.unwatch != nil {
+		m.unwatch()
+		m.unwatch = nil
+	}
+}
+
+var placeholder *Material
+
+// PlaceholderMaterial returns a bright magenta material, to use when a material couldn't be loaded. It's hard to
+// miss, but the game keeps running.
+func PlaceholderMaterial() *Material {
+	if placeholder == nil {
+		// One pixel is always a valid texture.
+		o := TextureOptions{SamplerOptions: SamplerOptions{MinFilter: Nearest, MagFilter: Nearest}, Format: RGBA8}
+		diff, _ := CreateTexture(1, 1, []uint8{255, 0, 255, 255}, o)
+		spec, _ := CreateTexture(1, 1, []uint8{0, 0, 0, 255}, o)
+		placeholder = &Material{
+			State: defaultState(),
+			textures: []materialTexture{
+				{uniform: "mat.diffTex", tex: diff},
+				{uniform: "mat.specTex", tex: spec},
+			},
+			params: make(map[string][]float32),
+		}
+	}
+
+	return placeholder
+}
+
+// String returns the file of the material, or what its textures are.
+func (m *Material) String() string {
+	if m.file != "" {
+		return m.file
+	}
+
+	files := make([]string, len(m.textures))
+	for i, t := range m.textures {
+		files[i] = t.file
+	}
+	return "material(" + strings.Join(files, ", ") + ")"
 }
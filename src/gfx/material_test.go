@@ -0,0 +1,76 @@
+package gfx
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testdata is where the material files are, TestMain changes to src.
+const testdata = "gfx/testdata/"
+
+func TestReadMaterialData(t *testing.T) {
+	d, err := readMaterialData(testdata+"container.mat.json", recorder.SupportsFormat, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := RenderState{Blend: BlendNone, DepthTest: true, DepthWrite: true, DepthFunc: LessEqual, Cull: false}
+	if d.m.State != want {
+		t.Errorf("the state is %+v, want %+v", d.m.State, want)
+	}
+	params := map[string][]float32{"mat.shininess": {0.5}, "uvOffset": {0.25, 0.75}, "useAO": {0.0}}
+	if !reflect.DeepEqual(d.m.params, params) {
+		t.Errorf("the params are %v, want %v", d.m.params, params)
+	}
+
+	// Files are relative to the material, and the textures are sorted by uniform.
+	if d.shaderFile != filepath.FromSlash("../shaders/basic.glsl") || d.shader == nil {
+		t.Errorf("the shader is %q, want it read from ../shaders/basic.glsl", d.shaderFile)
+	}
+	if len(d.textures) != 2 {
+		t.Fatalf("got %v textures, want 2", len(d.textures))
+	}
+	for i, want := range []struct{ uniform, file string }{
+		{"mat.diffTex", "../res/containerTex.png"},
+		{"mat.specTex", "../res/containerSpec.png"},
+	} {
+		if tex := d.textures[i]; tex.uniform != want.uniform || tex.file != filepath.FromSlash(want.file) {
+			t.Errorf("texture %v is %v from %v, want %v from %v", i, tex.uniform, tex.file, want.uniform, want.file)
+		}
+	}
+}
+
+func TestReadMaterialDataErrors(t *testing.T) {
+	tests := []struct {
+		file string
+		// notFound is whether the error is a *NotFoundError, it's a *DecodeError otherwise.
+		notFound bool
+	}{
+		{"blend.mat.json", false},
+		{"depthfunc.mat.json", false},
+		{"unknown.mat.json", false},
+		{"param.mat.json", false},
+		{"truncated.mat.json", false},
+		{"missing.mat.json", true},
+		{"nothere.mat.json", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			_, err := readMaterialData(testdata+test.file, recorder.SupportsFormat, nil)
+			switch err.(type) {
+			case *NotFoundError:
+				if !test.notFound {
+					t.Errorf("got %v, want a *DecodeError", err)
+				}
+			case *DecodeError:
+				if test.notFound {
+					t.Errorf("got %v, want a *NotFoundError", err)
+				}
+			default:
+				t.Errorf("got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package gfx
+
+import (
+	"GopherGL/src/camera"
+	"GopherGL/src/particle"
+)
+
+var (
+	particleShader             *Shader
+	particleVao, particleInsts uint32
+	particleData               []float32
+)
+
+// initParticles creates the quad every particle is drawn with, and the buffer for the instance data.
+func initParticles() error {
+	var err error
+	if particleShader, err = createShader("../shaders/particle.glsl"); err != nil {
+		return err
+	}
+
+	// A quad as a triangle strip, the vertex shader turns it towards the camera.
+	corners := []float32{
+		-0.5, -0.5,
+		0.5, -0.5,
+		-0.5, 0.5,
+		0.5, 0.5,
+	}
+
+	particleVao = device.CreateVertexArray()
+	vbo := device.CreateBuffer(corners, StaticDraw)
+
+	// Corners.
+	device.SetVertexAttrib(vbo, VertexAttrib{Index: 0, Size: 2, Stride: 2 * 4})
+
+	// The instance buffer is filled every frame.
+	particleInsts = device.CreateBuffer(nil, StreamDraw)
+
+	// Center and size.
+	device.SetVertexAttrib(particleInsts, VertexAttrib{Index: 1, Size: 4, Stride: 8 * 4, Divisor: 1})
+	// Color.
+	device.SetVertexAttrib(particleInsts, VertexAttrib{Index: 2, Size: 4, Stride: 8 * 4, Offset: 4 * 4, Divisor: 1})
+
+	return nil
+}
+
+// RenderParticles draws all particles of the emitter as billboards. Draw them after all the other entities.
+func RenderParticles(c *camera.Camera, e *particle.Emitter) {
+	if len(e.Particles) == 0 {
+		return
+	}
+
+	// Alpha blending only looks correct when the furthest particles are drawn first.
+	if !e.Additive {
+		e.SortBackToFront(c.Pos)
+	}
+
+	particleData = particleData[:0]
+	for _, p := range e.Particles {
+		particleData = append(particleData,
+			p.Pos.X(), p.Pos.Y(), p.Pos.Z(), p.Size,
+			p.Color.X(), p.Color.Y(), p.Color.Z(), p.Color.W())
+	}
+
+	// The particles shouldn't hide each other, but the scene should still hide them.
+	p := defaultPipeline(particleShader.program)
+	p.DepthWrite = false
+	if e.Additive {
+		p.Blend = BlendAdditive
+	}
+
+	device.SetPipeline(&p)
+	setCamera(c)
+
+	device.BindVertexArray(particleVao)
+	device.UpdateBuffer(particleInsts, particleData, StreamDraw)
+	device.DrawInstanced(TriangleStrip, 0, 4, int32(len(e.Particles)))
+}
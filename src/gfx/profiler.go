@@ -0,0 +1,165 @@
+package gfx
+
+import (
+	"hash/fnv"
+	"time"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"GopherGL/src/prof"
+)
+
+var (
+	overlayShader            *Shader
+	overlayVao, overlayInsts uint32
+	overlayRects             []float32
+)
+
+// gpuTimer gives the profiler timestamps through the device, it reuses queries once their result is read.
+type gpuTimer struct {
+	free []uint32
+}
+
+// Timestamp records the GPU time.
+func (t *gpuTimer) Timestamp() uint32 {
+	var id uint32
+	if n := len(t.free); n > 0 {
+		id, t.free = t.free[n-1], t.free[:n-1]
+	} else {
+		id = device.CreateQuery()
+	}
+	device.QueryTimestamp(id)
+
+	return id
+}
+
+// Result returns the timestamp if it's there.
+func (t *gpuTimer) Result(id uint32) (uint64, bool) {
+	return device.QueryResult(id)
+}
+
+// Release puts the query back to be used again.
+func (t *gpuTimer) Release(id uint32) {
+	t.free = append(t.free, id)
+}
+
+// initOverlay creates the quad the overlay rectangles are drawn with, and sets up GPU timing for the profiler.
+func initOverlay() error {
+	var err error
+	if overlayShader, err = createShader("../shaders/overlay.glsl"); err != nil {
+		return err
+	}
+
+	corners := []float32{
+		0.0, 0.0,
+		1.0, 0.0,
+		0.0, 1.0,
+		1.0, 1.0,
+	}
+
+	overlayVao = device.CreateVertexArray()
+	vbo := device.CreateBuffer(corners, StaticDraw)
+	device.SetVertexAttrib(vbo, VertexAttrib{Index: 0, Size: 2, Stride: 2 * 4})
+
+	// Rectangle and color.
+	overlayInsts = device.CreateBuffer(nil, StreamDraw)
+	device.SetVertexAttrib(overlayInsts, VertexAttrib{Index: 1, Size: 4, Stride: 8 * 4, Divisor: 1})
+	device.SetVertexAttrib(overlayInsts, VertexAttrib{Index: 2, Size: 4, Stride: 8 * 4, Offset: 4 * 4, Divisor: 1})
+
+	prof.Default.GPU = &gpuTimer{}
+
+	return nil
+}
+
+// addRect adds a rectangle in pixels, from the top left of the screen, to be drawn by flushRects.
+func addRect(x, y, w, h float32, c mgl32.Vec4) {
+	overlayRects = append(overlayRects, x, y, w, h, c[0], c[1], c[2], c[3])
+}
+
+// flushRects draws the added rectangles on top of everything.
+func flushRects() {
+	if len(overlayRects) == 0 {
+		return
+	}
+
+	p := Pipeline{Program: overlayShader.program, Blend: BlendAlpha}
+	device.SetPipeline(&p)
+
+	viewport := device.Viewport()
+	overlayShader.SetUniformVec2("screenSize", mgl32.Vec2{float32(viewport[2]), float32(viewport[3])})
+
+	device.BindVertexArray(overlayVao)
+	device.UpdateBuffer(overlayInsts, overlayRects, StreamDraw)
+	device.DrawInstanced(TriangleStrip, 0, 4, int32(len(overlayRects)/8))
+
+	overlayRects = overlayRects[:0]
+}
+
+// nameColor returns a color that's always the same for a name.
+func nameColor(name string) mgl32.Vec4 {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	v := h.Sum32()
+
+	return mgl32.Vec4{
+		0.4 + 0.6*float32(v&0xff)/255.0,
+		0.4 + 0.6*float32(v>>8&0xff)/255.0,
+		0.4 + 0.6*float32(v>>16&0xff)/255.0,
+		0.9,
+	}
+}
+
+// ProfilerBudget is the frame time the full width of the profiler overlay stands for.
+var ProfilerBudget = time.Second / 60
+
+// RenderProfiler draws the profiler as an overlay, in pixels from the top left of the screen. At the top is a
+// flame graph of the latest frame with the CPU parts and below it the GPU parts, at the bottom a bar for every
+// frame in the history. Draw it last.
+func RenderProfiler(p *prof.Profiler, x, y, width, height float32) {
+	const rowHeight = 8.0
+
+	addRect(x, y, width, height, mgl32.Vec4{0.0, 0.0, 0.0, 0.6})
+	scale := width / float32(ProfilerBudget)
+
+	// The flame graph, CPU rows first and the GPU rows after them.
+	depth := 0
+	if f := p.Latest(); f != nil {
+		for _, s := range f.Samples {
+			if s.Depth+1 > depth {
+				depth = s.Depth + 1
+			}
+		}
+
+		for _, s := range f.Samples {
+			c := nameColor(s.Name)
+			addRect(x+float32(s.Start)*scale, y+float32(s.Depth)*rowHeight, float32(s.CPU)*scale, rowHeight-1.0, c)
+			if s.GPUDone {
+				gy := y + float32(depth+s.Depth)*rowHeight + 2.0
+				addRect(x+float32(s.GPUStart)*scale, gy, float32(s.GPU)*scale, rowHeight-1.0, c)
+			}
+		}
+	}
+
+	// The frame times, red when over budget. The budget is the line at half the height.
+	frames := p.Frames()
+	top := y + float32(2*depth)*rowHeight + 4.0
+	if top < y+height {
+		chart := y + height - top
+		barWidth := width / float32(p.History)
+		for i, f := range frames {
+			h := chart * 0.5 * float32(f.Duration) / float32(ProfilerBudget)
+			if h > chart {
+				h = chart
+			}
+
+			c := mgl32.Vec4{0.3, 0.8, 0.3, 0.9}
+			if f.Duration > ProfilerBudget {
+				c = mgl32.Vec4{0.9, 0.3, 0.3, 0.9}
+			}
+			addRect(x+float32(i)*barWidth, y+height-h, barWidth, h, c)
+		}
+		addRect(x, y+height-chart*0.5, width, 1.0, mgl32.Vec4{1.0, 1.0, 1.0, 0.5})
+	}
+
+	flushRects()
+}
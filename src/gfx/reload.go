@@ -0,0 +1,157 @@
+package gfx
+
+import (
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"GopherGL/src/watch"
+)
+
+var (
+	// shaders is every shader and variant that was compiled, to find the ones using a changed file.
+	shaders []*Shader
+
+	watcher *watch.Watcher
+	// reloaders are the functions given to OnChange by the absolute path of the file.
+	reloaders = make(map[string][]*reloader)
+	reloadErr error
+)
+
+// reloader is a function given to OnChange, it's a pointer so OnChange can find it again to remove it.
+type reloader struct {
+	reload func() error
+}
+
+// EnableHotReload watches the directories for changed files, ../shaders and ../res when none are given. The
+// shaders and textures using a changed file are loaded again in BeginFrame, without restarting.
+func EnableHotReload(dirs ...string) error {
+	if len(dirs) == 0 {
+		dirs = []string{"../shaders", "../res"}
+	}
+
+	w, err := watch.CreateWatcher(dirs...)
+	if err != nil {
+		return err
+	}
+
+	DisableHotReload()
+	watcher = w
+	watcher.Start(250 * time.Millisecond)
+
+	return nil
+}
+
+// DisableHotReload stops watching the files.
+func DisableHotReload() {
+	if watcher != nil {
+		watcher.Stop()
+		watcher = nil
+	}
+}
+
+// OnChange calls reload in BeginFrame when the file changed, while hot reloading is on. Use it for things the
+// package doesn't know the file of, like a mesh:
+//
+//	stop := gfx.OnChange("../res/robot.glb", func() error {
+//		f, err := gltf.Load("../res/robot.glb")
+//		...
+//		robot.SetMesh(m)
+//		return nil
+//	})
+//
+// Call the returned function when whatever reload changes is gone, reload isn't called after that.
+func OnChange(file string, reload func() error) func() {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		abs = file
+	}
+	r := &reloader{reload}
+	reloaders[abs] = append(reloaders[abs], r)
+
+	return func() {
+		list := reloaders[abs]
+		for i := range list {
+			if list[i] != r {
+				continue
+			}
+			// A new slice, applyReloads could be looping over the old one.
+			list = append(list[:i:i], list[i+1:]...)
+			if len(list) == 0 {
+				delete(reloaders, abs)
+			} else {
+				reloaders[abs] = list
+			}
+			return
+		}
+	}
+}
+
+// ReloadError returns why the last hot reload failed, or nil when it worked. Whatever failed keeps using what was
+// loaded before.
+func ReloadError() error {
+	return reloadErr
+}
+
+// applyReloads loads everything using a changed file again, it's called on the GL thread by BeginFrame.
+func applyReloads() {
+	if watcher == nil {
+		return
+	}
+
+	changed := watcher.Changed()
+	if len(changed) == 0 {
+		return
+	}
+
+	reloadErr = nil
+	fail := func(err error) {
+		log.Printf("hot reload: %v", err)
+		reloadErr = err
+	}
+
+	// A shader is only compiled once, even when more of its files changed.
+	for _, s := range shaders {
+		for _, file := range changed {
+			if s.uses(file) {
+				if err := s.reload(); err != nil {
+					fail(err)
+				}
+				break
+			}
+		}
+	}
+
+	for _, file := range changed {
+		for _, r := range reloaders[file] {
+			if err := r.reload(); err != nil {
+				fail(err)
+			}
+		}
+	}
+}
+
+// uses returns whether the shader was made from the file.
+func (s *Shader) uses(file string) bool {
+	for _, f := range s.files {
+		if f == file {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderReloadError draws why the last hot reload failed in red, in pixels from the top left of the screen. It
+// draws nothing when the reload worked. Draw it last.
+func RenderReloadError(x, y float32) {
+	if reloadErr == nil {
+		return
+	}
+
+	lines := strings.Split(strings.TrimSpace(reloadErr.Error()), "\n")
+	addTextBox(x, y, lines, mgl32.Vec4{1.0, 0.3, 0.3, 1.0})
+	flushRects()
+}
@@ -0,0 +1,80 @@
+package gfx
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// watching returns how many reloaders the file has.
+func watching(t *testing.T, file string) int {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(reloaders[abs])
+}
+
+func TestOnChangeStop(t *testing.T) {
+	const file = "../res/test.txt"
+	calls := 0
+	stop := OnChange(file, func() error { calls++; return nil })
+	stop2 := OnChange(file, func() error { calls += 10; return nil })
+	if n := watching(t, file); n != 2 {
+		t.Fatalf("%v reloaders, want 2", n)
+	}
+
+	stop()
+	stop()
+	if n := watching(t, file); n != 1 {
+		t.Fatalf("%v reloaders after stopping one twice, want 1", n)
+	}
+	abs, _ := filepath.Abs(file)
+	for _, r := range reloaders[abs] {
+		r.reload()
+	}
+	if calls != 10 {
+		t.Errorf("the stopped reloader was called")
+	}
+
+	stop2()
+	if _, ok := reloaders[abs]; ok {
+		t.Errorf("the file is still watched")
+	}
+}
+
+func TestHotReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "robot.glb")
+	if err := ioutil.WriteFile(file, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := EnableHotReload(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer DisableHotReload()
+
+	stopped, kept := 0, 0
+	stop := OnChange(file, func() error { stopped++; return nil })
+	defer OnChange(file, func() error { kept++; return nil })()
+	stop()
+
+	if err := ioutil.WriteFile(file, []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The watcher polls every 250 ms, BeginFrame would call applyReloads every frame.
+	for deadline := time.Now().Add(3 * time.Second); kept == 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		applyReloads()
+	}
+	if kept != 1 || stopped != 0 {
+		t.Errorf("the kept reloader was called %v times and the stopped one %v, want 1 and 0", kept, stopped)
+	}
+}
@@ -2,48 +2,132 @@
 package gfx
 
 import (
-	"github.com/go-gl/gl/v3.3-core/gl"
+	"time"
+
+	"github.com/go-gl/mathgl/mgl32"
+
 	"GopherGL/src/camera"
+	"GopherGL/src/lod"
+	"GopherGL/src/prof"
+)
+
+var (
+	startTime  time.Time
+	lastFrame  time.Time
+	frameDelta float32
 )
 
-// InitRenderer currently only sets up the shaders.
-func InitRenderer() {
-	pointShader = createShader("../shaders/point.glsl")
-	directionalShader = createShader("../shaders/directional.glsl")
-	ambientShader = createShader("../shaders/ambient.glsl")
-	basicShader = createShader("../shaders/basic.glsl")
+// InitRenderer sets up the device and the shaders. It uses OpenGL, unless another device was set with UseDevice.
+// The error is about the first shader that couldn't be loaded.
+func InitRenderer() error {
+	if device == nil {
+		UseDevice(CreateGLDevice())
+	}
+
+	// Alpha blending allows us to use transparency with PNG files.
+	p := defaultPipeline(0)
+	device.SetPipeline(&p)
+
+	// The camera and lights are shared by the shaders, in uniform buffers.
+	if err := initUniformBuffers(); err != nil {
+		return err
+	}
+	startTime = time.Now()
+
+	for _, s := range []struct {
+		shader **Shader
+		file   string
+	}{
+		{&pointShader, "../shaders/point.glsl"},
+		{&directionalShader, "../shaders/directional.glsl"},
+		{&ambientShader, "../shaders/ambient.glsl"},
+		{&basicShader, "../shaders/basic.glsl"},
+		{&skinnedShader, "../shaders/skinned.glsl"},
+		{&terrainShader, "../shaders/terrain.glsl"},
+	} {
+		var err error
+		if *s.shader, err = createShader(s.file); err != nil {
+			return err
+		}
+	}
+
+	for _, initPart := range []func() error{initParticles, initSSAO, initOverlay} {
+		if err := initPart(); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // BeginFrame clears the screen, do this before rendering.
 func BeginFrame() {
-	// The background color.
-	gl.Clear(gl.COLOR_BUFFER_BIT)
-	gl.Clear(gl.DEPTH_BUFFER_BIT)
-	gl.ClearColor(0.2, 0.3, 0.3, 1.0)
+	// Keep track of the frame time, for things like fading between levels of detail.
+	now := time.Now()
+	if !lastFrame.IsZero() {
+		frameDelta = float32(now.Sub(lastFrame).Seconds())
+	}
+	lastFrame = now
+	frameTime = float32(now.Sub(startTime).Seconds())
+
+	prof.NextFrame()
+
+	// Swap in the shaders and textures whose files changed.
+	applyReloads()
+	// Upload what finished loading in the background.
+	runUploads()
+
+	// Ambient occlusion has to be calculated again every frame.
+	currentAO = nil
+
+	// Start counting the statistics of the frame, and the state cache of the GLDevice.
+	if d, ok := device.(interface{ BeginFrame() }); ok {
+		d.BeginFrame()
+	}
+
+	// The background color, the depth buffer is only cleared when depth writing is on.
+	p := defaultPipeline(0)
+	device.SetPipeline(&p)
+	device.SetClearColor(mgl32.Vec4{0.2, 0.3, 0.3, 1.0})
+	device.Clear(ClearColor | ClearDepth)
 }
 
 // Render takes in an Entity and draws it to the framebuffer.
 func Render(c *camera.Camera, e *Entity, dl *DirectionalLight) {
-	// Set the texture and specular lighting map.
-	gl.ActiveTexture(gl.TEXTURE0)
-	gl.BindTexture(gl.TEXTURE_2D, e.mat.texID)
-	gl.ActiveTexture(gl.TEXTURE1)
-	gl.BindTexture(gl.TEXTURE_2D, e.mat.specID)
-	basicShader.SetUniformFloat("mat.shininess", e.mat.Shininess)
-	basicShader.SetUniformInt32("mat.diffTex", 0)
-	basicShader.SetUniformInt32("mat.specTex", 1)
-	
-	basicShader.SetUniformMat4("model", e.Trans)
-	basicShader.SetUniformMat4("view", c.View)
-	basicShader.SetUniformVec3("viewPos", c.Pos)
-	basicShader.SetUniformMat4("projection", c.Proj)
-	
-	basicShader.SetUniformFloat("sun.intensity", dl.intensity)
-	basicShader.SetUniformVec3("sun.direction", dl.dir)
+	// The material picks the shader and the state, basic.glsl when it doesn't have a shader.
+	s := e.mat.shaderOr(basicShader)
+	p := e.mat.pipeline(s.program)
+	device.SetPipeline(&p)
+	setCamera(c)
+	setLights(dl)
+
+	// Bind the textures and set the parameters.
+	e.mat.apply(s)
+
+	// The ambient occlusion of this frame, if RenderSSAO was called. It's on the last unit, the material has the
+	// ones before.
+	if _, ok := s.uniforms["aoTex"]; ok {
+		s.SetUniformInt32("aoTex", MaxTextureUnits-1)
+		if currentAO != nil {
+			bindTexture(MaxTextureUnits-1, currentAO.blurTex, 0)
+			s.SetUniformInt32("useAO", 1)
+		} else {
+			s.SetUniformInt32("useAO", 0)
+		}
+	}
 	
-	gl.UseProgram(basicShader.program)
-	gl.BindVertexArray(e.vao)
-	gl.DrawElements(gl.TRIANGLES, e.size, gl.UNSIGNED_INT, gl.Ptr(nil))
+	s.SetUniformMat4("model", e.Trans)
+
+	if e.LOD == nil {
+		drawMesh(s, e.vao, e.size, 1.0)
+	} else {
+		// Both levels are drawn with a dither pattern while fading, together they cover every pixel once.
+		level, prev, fade := e.LOD.Update(lodMetric(c, e), frameDelta)
+		if fade < 1.0 {
+			drawMesh(s, e.lods[prev].vao, e.lods[prev].size, fade-1.0)
+		}
+		drawMesh(s, e.lods[level].vao, e.lods[level].size, fade)
+	}
 	
 	/*
 	// TODO: There should be deferred shading instead of this mess.
@@ -82,3 +166,23 @@ func Render(c *camera.Camera, e *Entity, dl *DirectionalLight) {
 	gl.Disable(gl.BLEND)
 	*/
 }
+
+// drawMesh draws the vertex array with the bound shader. A positive fade draws that part of the dither pattern,
+// a negative fade the rest of it. A fade of 1 draws everything. Shaders without lodFade always draw everything.
+func drawMesh(s *Shader, vao uint32, size int32, fade float32) {
+	if _, ok := s.uniforms["lodFade"]; ok {
+		s.SetUniformFloat("lodFade", fade)
+	}
+	device.BindVertexArray(vao)
+	device.DrawIndexed(Triangles, size)
+}
+
+// lodMetric returns the distance or screen size of the Entity, depending on the mode of its LOD selector.
+func lodMetric(c *camera.Camera, e *Entity) float32 {
+	dist := e.Trans.Col(3).Vec3().Sub(c.Pos).Len()
+	if e.LOD.Mode == lod.ByScreenSize {
+		return lod.ScreenSize(e.radius, dist, c.Fov)
+	}
+
+	return dist
+}
@@ -0,0 +1,95 @@
+package gfx
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"GopherGL/src/camera"
+)
+
+var recorder *RecordingDevice
+
+// TestMain sets the renderer up with a RecordingDevice. The shaders are found from src, like when the game runs.
+func TestMain(m *testing.M) {
+	if err := os.Chdir(".."); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	recorder = CreateRecordingDevice(800, 600)
+	UseDevice(recorder)
+	if err := InitRenderer(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	os.Exit(m.Run())
+}
+
+// commands returns the recorded commands, one per line.
+func commands(cs ...Command) string {
+	var b strings.Builder
+	for _, c := range cs {
+		b.WriteString(c.String())
+		b.WriteByte('\n')
+	}
+
+	return b.String()
+}
+
+func TestRenderEntity(t *testing.T) {
+	mat, err := CreateMaterial("../res/containerTex.png", "../res/containerSpec.png", 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mat.Delete()
+	e, err := CreateCube(0.0, 0.0, 0.0, 0.0, 0.0, 0.0, mat)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cam := camera.CreateCamera(mgl32.Vec3{0.0, 0.0, 3.0}, 800.0/600.0, 90.0)
+	sun := CreateDirectionalLight(mgl32.Vec3{0.5, -0.5, 0.0}, 1.0)
+	diff, spec := mat.textures[0].tex.id, mat.textures[1].tex.id
+
+	// The material's textures and parameters, the model matrix and the mesh.
+	draw := []Command{
+		{"SetPipeline", []interface{}{mat.pipeline(basicShader.program)}},
+		{"BindTexture", []interface{}{0, diff}},
+		{"BindSampler", []interface{}{0, 0}},
+		{"SetUniformInt", []interface{}{"mat.diffTex", 0}},
+		{"BindTexture", []interface{}{1, spec}},
+		{"BindSampler", []interface{}{1, 0}},
+		{"SetUniformInt", []interface{}{"mat.specTex", 1}},
+		{"SetUniformVec2", []interface{}{"uvOffset", 1}},
+		{"SetUniformVec2", []interface{}{"uvScale", 1}},
+		{"SetUniformFloat", []interface{}{"mat.shininess", 0.5}},
+		{"SetUniformInt", []interface{}{"aoTex", MaxTextureUnits - 1}},
+		{"SetUniformInt", []interface{}{"useAO", 0}},
+		{"SetUniformMat4", []interface{}{"model", 1}},
+		{"SetUniformFloat", []interface{}{"lodFade", 1}},
+		{"BindVertexArray", []interface{}{e.vao}},
+		{"DrawIndexed", []interface{}{Triangles, 36}},
+	}
+
+	// The first time the camera and lights are uploaded too, right after the pipeline.
+	recorder.Reset()
+	Render(cam, e, sun)
+	first := append([]Command{draw[0],
+		{"UpdateBuffer", []interface{}{frameUniforms.id, "[144]uint8", DynamicDraw}},
+		{"UpdateBuffer", []interface{}{lightsUniforms.id, "[432]uint8", DynamicDraw}},
+	}, draw[1:]...)
+	if got, want := recorder.String(), commands(first...); got != want {
+		t.Errorf("the first Render gave\n%v\nwant\n%v", got, want)
+	}
+
+	// They didn't change, so they aren't uploaded again.
+	recorder.Reset()
+	Render(cam, e, sun)
+	if got, want := recorder.String(), commands(draw...); got != want {
+		t.Errorf("the second Render gave\n%v\nwant\n%v", got, want)
+	}
+}
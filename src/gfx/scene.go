@@ -0,0 +1,112 @@
+package gfx
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"GopherGL/src/camera"
+	"GopherGL/src/spatial"
+)
+
+// SceneIndex keeps entities in a bounding volume hierarchy, so culling, picking and proximity checks
+// don't have to loop over every Entity.
+type SceneIndex struct {
+	tree *spatial.Tree
+	ids  map[*Entity]int
+	// dirty are the entities that moved since the last Update.
+	dirty map[*Entity]bool
+}
+
+// CreateSceneIndex returns an empty index. Entities can move margin units before the tree has to change.
+func CreateSceneIndex(margin float32) *SceneIndex {
+	return &SceneIndex{
+		tree:  spatial.CreateTree(margin),
+		ids:   make(map[*Entity]int),
+		dirty: make(map[*Entity]bool),
+	}
+}
+
+// Add puts an Entity in the index.
+func (s *SceneIndex) Add(e *Entity) {
+	if _, ok := s.ids[e]; ok {
+		return
+	}
+	s.ids[e] = s.tree.Insert(e.Bounds(), e)
+	e.indices = append(e.indices, s)
+}
+
+// Remove takes an Entity out of the index.
+func (s *SceneIndex) Remove(e *Entity) {
+	id, ok := s.ids[e]
+	if !ok {
+		return
+	}
+	s.tree.Remove(id)
+	delete(s.ids, e)
+	delete(s.dirty, e)
+	for i, index := range e.indices {
+		if index == s {
+			e.indices = append(e.indices[:i], e.indices[i+1:]...)
+			break
+		}
+	}
+}
+
+// Update moves the entities that moved since the last update, call it once per frame.
+func (s *SceneIndex) Update() {
+	for e := range s.dirty {
+		s.tree.Move(s.ids[e], e.Bounds())
+		delete(s.dirty, e)
+	}
+}
+
+// entities turns a list of ids into entities.
+func (s *SceneIndex) entities(ids []int) []*Entity {
+	out := make([]*Entity, len(ids))
+	for i, id := range ids {
+		out[i] = s.tree.Data(id).(*Entity)
+	}
+
+	return out
+}
+
+// collect runs a query and returns all entities it finds.
+func (s *SceneIndex) collect(query func(fn func(id int) bool)) []*Entity {
+	var ids []int
+	query(func(id int) bool {
+		ids = append(ids, id)
+		return true
+	})
+
+	return s.entities(ids)
+}
+
+// Visible returns the entities that are in view of the camera.
+func (s *SceneIndex) Visible(c *camera.Camera) []*Entity {
+	f := spatial.FrustumFromMatrix(c.Proj.Mul4(c.View))
+	return s.collect(func(fn func(id int) bool) { s.tree.QueryFrustum(f, fn) })
+}
+
+// InBox returns the entities that overlap the box.
+func (s *SceneIndex) InBox(box spatial.AABB) []*Entity {
+	return s.collect(func(fn func(id int) bool) { s.tree.QueryAABB(box, fn) })
+}
+
+// InSphere returns the entities that overlap the sphere.
+func (s *SceneIndex) InSphere(center mgl32.Vec3, radius float32) []*Entity {
+	return s.collect(func(fn func(id int) bool) { s.tree.QuerySphere(center, radius, fn) })
+}
+
+// Nearest returns up to k entities closest to p, closest first.
+func (s *SceneIndex) Nearest(p mgl32.Vec3, k int) []*Entity {
+	return s.entities(s.tree.Nearest(p, k))
+}
+
+// Pick returns the first Entity hit by the ray, and the distance along dir. It's nil if nothing was hit.
+func (s *SceneIndex) Pick(origin, dir mgl32.Vec3, maxDist float32) (*Entity, float32) {
+	id, dist, ok := s.tree.Raycast(spatial.Ray{Origin: origin, Dir: dir}, maxDist, nil)
+	if !ok {
+		return nil, maxDist
+	}
+
+	return s.tree.Data(id).(*Entity), dist
+}
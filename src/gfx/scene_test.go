@@ -0,0 +1,62 @@
+package gfx
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"GopherGL/src/spatial"
+)
+
+func TestSceneIndexUpdate(t *testing.T) {
+	mat, err := CreateMaterial("../res/containerTex.png", "../res/containerSpec.png", 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mat.Delete()
+	a, err := CreateCube(0.0, 0.0, 0.0, 0.0, 0.0, 0.0, mat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := CreateCube(0.0, 0.0, 0.0, 0.0, 0.0, 0.0, mat)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := CreateSceneIndex(0.1)
+	s.Add(a)
+	s.Add(b)
+	if len(s.dirty) != 0 {
+		t.Fatalf("%v entities are dirty after adding them", len(s.dirty))
+	}
+
+	// Only the entities that moved are updated.
+	far := spatial.AABB{Min: mgl32.Vec3{9.0, -1.0, -1.0}, Max: mgl32.Vec3{11.0, 1.0, 1.0}}
+	a.SetPos(10.0, 0.0, 0.0)
+	if len(s.dirty) != 1 || !s.dirty[a] {
+		t.Errorf("the dirty entities are %v, want only the one that moved", s.dirty)
+	}
+	if found := s.InBox(far); len(found) != 0 {
+		t.Errorf("the index moved the entity before Update")
+	}
+	s.Update()
+	if found := s.InBox(far); len(found) != 1 || found[0] != a {
+		t.Errorf("found %v entities where it moved to, want the one that moved", len(found))
+	}
+	if len(s.dirty) != 0 {
+		t.Errorf("%v entities are dirty after Update", len(s.dirty))
+	}
+
+	b.SetTransform(mgl32.Translate3D(10.0, 0.0, 0.0))
+	s.Update()
+	if found := s.InBox(far); len(found) != 2 {
+		t.Errorf("found %v entities after SetTransform, want 2", len(found))
+	}
+
+	// A removed Entity doesn't mark the index anymore.
+	s.Remove(a)
+	a.SetPos(0.0, 0.0, 0.0)
+	if len(s.dirty) != 0 || len(a.indices) != 0 {
+		t.Errorf("the removed entity is still in the index")
+	}
+}
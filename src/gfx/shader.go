@@ -1,148 +1,300 @@
 package gfx
 
 import (
-	"bufio"
 	"fmt"
-	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
-	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/mathgl/mgl32"
+
+	"GopherGL/src/glsl"
 )
 
-// check is used to check errors.
-func check(err error) {
+// Shader is an OpenGL shader.
+type Shader struct {
+	program  uint32
+	file     string
+	features []string
+	// files is every file the program was made from, for hot reloading.
+	files []string
+	// variants is shared by all variants of the file, keyed by glsl.VariantKey.
+	variants map[string]*Shader
+
+	uniforms map[string]ActiveVar
+	attribs  []ActiveVar
+}
+
+// createShader loads a shader file, see the glsl package for what it can contain. The features are defined in
+// every stage. The error is a *NotFoundError, *DecodeError, *CompileError or *LinkError.
+func createShader(shaderFile string, features ...string) (*Shader, error) {
+	p, err := preprocess(shaderFile, features)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+	return createShaderFrom(shaderFile, features, p)
 }
 
-// Used for specifying the shader type
-const (
-	shaderVertex   uint16 = 0
-	shaderFragment uint16 = 1
-)
+// createShaderFrom is createShader with the file already preprocessed, see preprocess.
+func createShaderFrom(shaderFile string, features []string, p *glsl.Program) (*Shader, error) {
+	s := &Shader{file: shaderFile, features: features, variants: make(map[string]*Shader)}
 
-// Shader is an OpenGL shader.
-type Shader struct {
-	program uint32
-}
-
-// CompileShader compiles the shader and prints any errors to the console.
-func compileShader(source string, shaderType uint32, length int32) (uint32, error) {
-	shader := gl.CreateShader(shaderType)
-
-	csource, free := gl.Strs(source)
-	gl.ShaderSource(shader, 1, csource, &length)
-	free()
-	gl.CompileShader(shader)
-
-	var status int32
-	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
-
-		return 0, fmt.Errorf("Failed to compile: %v", log)
-	}
-
-	return shader, nil
-}
-
-// CreateShader sets the shader and takes in a file path.
-func createShader(shaderFile string) *Shader {
-	// Read the file.
-	source, err := os.Open(shaderFile)
-	defer source.Close()
-	check(err)
-	scanner := bufio.NewScanner(source)
-	scanner.Split(bufio.ScanLines)
-
-	var shaderType uint16
-	var vertexSource string
-	var fragmentSource string
-
-	// Loops through each line.
-	for scanner.Scan() {
-		// Check which part of the shader we're currently in.
-		if scanner.Text() == "#vertex" {
-			shaderType = shaderVertex
-		} else if scanner.Text() == "#fragment" {
-			shaderType = shaderFragment
-		} else {
-			// Add the source to the string according to its type.
-			if shaderType == shaderVertex {
-				vertexSource += scanner.Text() + "\n"
-			} else if shaderType == shaderFragment {
-				fragmentSource += scanner.Text() + "\n"
-			}
+	var err error
+	s.program, s.files, err = compileProgram(shaderFile, p)
+	if err != nil {
+		return nil, err
+	}
+	s.reflect()
+	s.variants[glsl.VariantKey(features)] = s
+	shaders = append(shaders, s)
+
+	return s, nil
+}
+
+// preprocess reads a shader file and the files it includes. It doesn't need the device, so it can be done on
+// another goroutine.
+func preprocess(file string, features []string) (*glsl.Program, error) {
+	p, err := glsl.Load(file, features...)
+	if err != nil {
+		return nil, fileError(file, err)
+	}
+	return p, nil
+}
+
+// loadProgram preprocesses and compiles a shader file, see compileProgram.
+func loadProgram(file string, features []string) (uint32, []string, error) {
+	p, err := preprocess(file, features)
+	if err != nil {
+		return 0, nil, err
+	}
+	return compileProgram(file, p)
+}
+
+// compileProgram compiles a preprocessed shader file, compile errors point at the lines of the files. It returns
+// the absolute paths of the files that were read.
+func compileProgram(file string, p *glsl.Program) (uint32, []string, error) {
+	geometry := ""
+	if p.Geometry != nil {
+		geometry = p.Geometry.Source
+	}
+
+	program, err := device.CreateProgram(p.Vertex.Source, geometry, p.Fragment.Source)
+	if err == nil {
+		if err = bindBlocks(program); err != nil {
+			device.DeleteProgram(program)
+			err = &LinkError{Log: err.Error()}
+		}
+	}
+	switch e := err.(type) {
+	case *CompileError:
+		stages := map[string]*glsl.Stage{"vertex": p.Vertex, "geometry": p.Geometry, "fragment": p.Fragment}
+		if stage := stages[e.Stage]; stage != nil {
+			e.Log = stage.MapLog(e.Log)
+		}
+		e.File = file
+	case *LinkError:
+		e.File = file
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	files := make([]string, len(p.Files))
+	for i, f := range p.Files {
+		if files[i], err = filepath.Abs(f); err != nil {
+			return 0, nil, err
 		}
 	}
 
-	program := gl.CreateProgram()
-	vertexShader, err := compileShader(vertexSource, gl.VERTEX_SHADER, int32(len(vertexSource)))
-	check(err)
-	fragmentShader, err := compileShader(fragmentSource, gl.FRAGMENT_SHADER, int32(len(fragmentSource)))
-	check(err)
+	return program, files, nil
+}
 
-	// Link the seperate shaders into one program.
-	gl.AttachShader(program, vertexShader)
-	gl.AttachShader(program, fragmentShader)
-	gl.LinkProgram(program)
-	gl.ValidateProgram(program)
+// reload compiles the shader again. When that fails the old program stays, so the last one that worked is used.
+func (s *Shader) reload() error {
+	program, files, err := loadProgram(s.file, s.features)
+	if err != nil {
+		return err
+	}
 
-	// The shader are useless and can be deleted.
-	gl.DeleteShader(vertexShader)
-	gl.DeleteShader(fragmentShader)
+	device.DeleteProgram(s.program)
+	s.program, s.files = program, files
+	s.reflect()
 
-	gl.UseProgram(program)
-	return &Shader{program}
+	return nil
 }
 
-// SetUniformVec3 sets a uniform variable of type vec3.
-func (s *Shader) SetUniformVec3(name string, v mgl32.Vec3) {
-	// Add null terminator.
-	name += "\x00"
+// Variant returns the shader with other features defined, like "SKINNED" or "NORMAL_MAP". A variant is compiled
+// the first time it's asked for, after that it's the same *Shader.
+func (s *Shader) Variant(features ...string) (*Shader, error) {
+	return s.variant(features, nil)
+}
+
+// variant is Variant with the file already preprocessed with the features, or p is nil to do it.
+func (s *Shader) variant(features []string, p *glsl.Program) (*Shader, error) {
+	key := glsl.VariantKey(features)
+	if v, ok := s.variants[key]; ok {
+		return v, nil
+	}
+
+	var err error
+	if p == nil {
+		if p, err = preprocess(s.file, features); err != nil {
+			return nil, err
+		}
+	}
+	v := &Shader{file: s.file, features: features, variants: s.variants}
+	v.program, v.files, err = compileProgram(s.file, p)
+	if err != nil {
+		return nil, err
+	}
+	v.reflect()
+	s.variants[key] = v
+	shaders = append(shaders, v)
+
+	return v, nil
+}
+
+// delete frees the program, when the last handle to it is released. The other variants of the file stay.
+func (s *Shader) delete() {
+	device.DeleteProgram(s.program)
+	s.program = 0
+	delete(s.variants, glsl.VariantKey(s.features))
+	for i, other := range shaders {
+		if other == s {
+			shaders = append(shaders[:i], shaders[i+1:]...)
+			break
+		}
+	}
+}
+
+// CompileVariants compiles every combination of the flags now, so there's no hitch when one is first used. It
+// stops at the first one that doesn't compile.
+func (s *Shader) CompileVariants(flags ...string) error {
+	for _, features := range glsl.Permutations(flags) {
+		if _, err := s.Variant(features...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reflect caches the uniforms and attributes the program uses, it's done again whenever the program changes.
+func (s *Shader) reflect() {
+	s.uniforms = make(map[string]ActiveVar)
+	for _, u := range device.ActiveUniforms(s.program) {
+		// OpenGL names arrays after their first element.
+		u.Name = strings.TrimSuffix(u.Name, "[0]")
+		s.uniforms[u.Name] = u
+	}
+	s.attribs = device.ActiveAttribs(s.program)
+}
+
+// Uniforms returns the uniforms the shader uses, sorted by name.
+func (s *Shader) Uniforms() []ActiveVar {
+	uniforms := make([]ActiveVar, 0, len(s.uniforms))
+	for _, u := range s.uniforms {
+		uniforms = append(uniforms, u)
+	}
+	sort.Slice(uniforms, func(i, j int) bool { return uniforms[i].Name < uniforms[j].Name })
+
+	return uniforms
+}
+
+// Attribs returns the inputs of the vertex stage the shader uses.
+func (s *Shader) Attribs() []ActiveVar {
+	return s.attribs
+}
+
+// uniform returns the location of a uniform. It's an error when the shader doesn't use it, when it has another
+// type, or when the array is shorter than count. A type ending in * matches every type starting with the rest.
+func (s *Shader) uniform(name string, count int, types ...string) (int32, error) {
+	u, ok := s.uniforms[name]
+	if !ok {
+		return -1, fmt.Errorf("%v: there's no uniform %v, or it isn't used", s.file, name)
+	}
+	if u.Block != "" {
+		return -1, fmt.Errorf("%v: uniform %v is in the block %v, it can't be set on its own", s.file, name, u.Block)
+	}
+
+	for _, t := range types {
+		if u.Type == t || strings.HasSuffix(t, "*") && strings.HasPrefix(u.Type, t[:len(t)-1]) {
+			if count > int(u.Size) {
+				return -1, fmt.Errorf("%v: uniform %v has %v elements, not %v", s.file, name, u.Size, count)
+			}
+			return u.Location, nil
+		}
+	}
 
-	// Get the location, and give the pointer to the first value.
-	loc := gl.GetUniformLocation(s.program, gl.Str(name))
-	gl.Uniform3fv(loc, 1, &v[0])
+	return -1, fmt.Errorf("%v: uniform %v is a %v, not a %v", s.file, name, u.Type, strings.Join(types, " or "))
+}
+
+// SetUniformVec2 sets a uniform variable of type vec2.
+func (s *Shader) SetUniformVec2(name string, v mgl32.Vec2) error {
+	loc, err := s.uniform(name, 1, "vec2")
+	if err != nil {
+		return err
+	}
+
+	device.SetUniformVec2(s.program, loc, []mgl32.Vec2{v})
+	return nil
+}
+
+// SetUniformVec3 sets a uniform variable of type vec3.
+func (s *Shader) SetUniformVec3(name string, v mgl32.Vec3) error {
+	return s.SetUniformVec3Array(name, []mgl32.Vec3{v})
 }
 
 // SetUniformFloat sets a uniform variable of type float32.
-func (s *Shader) SetUniformFloat(name string, f float32) {
-	// Add null terminator.
-	name += "\x00"
+func (s *Shader) SetUniformFloat(name string, f float32) error {
+	loc, err := s.uniform(name, 1, "float")
+	if err != nil {
+		return err
+	}
 
-	// Get the location, and give the pointer to the first value.
-	loc := gl.GetUniformLocation(s.program, gl.Str(name))
-	gl.Uniform1f(loc, f)
+	device.SetUniformFloat(s.program, loc, f)
+	return nil
 }
 
 // SetUniformMat4 sets a uniform variable of type mat4.
-func (s *Shader) SetUniformMat4(name string, m mgl32.Mat4) {
-	// Add null terminator.
-	name += "\x00"
-
-	// Get the location, and give the pointer to the first value.
-	loc := gl.GetUniformLocation(s.program, gl.Str(name))
-	gl.UniformMatrix4fv(loc, 1, false, &m[0])
+func (s *Shader) SetUniformMat4(name string, m mgl32.Mat4) error {
+	return s.SetUniformMat4Array(name, []mgl32.Mat4{m})
 }
 
-// SetUniformInt32 sets a uniform variable of type int32.
-func (s *Shader) SetUniformInt32(name string, i int32) {
-	// Add null terminator.
-	name += "\x00"
+// SetUniformInt32 sets a uniform variable of type int32, bool or a sampler.
+func (s *Shader) SetUniformInt32(name string, i int32) error {
+	loc, err := s.uniform(name, 1, "int", "bool", "sampler*")
+	if err != nil {
+		return err
+	}
 
-	// Get the location, and give the pointer to the first value.
-	loc := gl.GetUniformLocation(s.program, gl.Str(name))
-	gl.Uniform1i(loc, i)
+	device.SetUniformInt(s.program, loc, i)
+	return nil
 }
 /*
 func (s* shader) setUniformDirectionalLight(name string, dl directionalLight) {
 	
 }
-*/
\ No newline at end of file
+*/
+
+// SetUniformMat4Array sets a uniform variable of type mat4[].
+func (s *Shader) SetUniformMat4Array(name string, m []mgl32.Mat4) error {
+	loc, err := s.uniform(name, len(m), "mat4")
+	if err != nil {
+		return err
+	}
+
+	device.SetUniformMat4(s.program, loc, m)
+	return nil
+}
+
+// SetUniformVec3Array sets a uniform variable of type vec3[].
+func (s *Shader) SetUniformVec3Array(name string, v []mgl32.Vec3) error {
+	loc, err := s.uniform(name, len(v), "vec3")
+	if err != nil {
+		return err
+	}
+
+	device.SetUniformVec3(s.program, loc, v)
+	return nil
+}
@@ -0,0 +1,56 @@
+package gfx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestShaderUniform(t *testing.T) {
+	tests := []struct {
+		name    string
+		uniform string
+		count   int
+		types   []string
+		// err is part of the error, it's empty when the uniform can be set.
+		err string
+	}{
+		{"mat4", "model", 1, []string{"mat4"}, ""},
+		{"unknown", "nothere", 1, []string{"float"}, "there's no uniform nothere"},
+		{"type mismatch", "model", 1, []string{"vec3"}, "uniform model is a mat4, not a vec3"},
+		{"one of the types", "mat.shininess", 1, []string{"int", "float"}, ""},
+		{"sampler", "mat.diffTex", 1, []string{"int", "bool", "sampler*"}, ""},
+		{"not a sampler", "mat.shininess", 1, []string{"sampler*"}, "is a float, not a sampler*"},
+		{"full array", "jointMats", MaxJoints, []string{"mat4"}, ""},
+		{"short array", "jointMats", MaxJoints + 1, []string{"mat4"}, "uniform jointMats has 64 elements, not 65"},
+		{"block member", "pointLightCount", 1, []string{"int"}, "is in the block Lights"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			loc, err := skinnedShader.uniform(test.uniform, test.count, test.types...)
+			if test.err == "" {
+				if err != nil || loc != skinnedShader.uniforms[test.uniform].Location {
+					t.Errorf("got location %v and %v", loc, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) || loc != -1 {
+				t.Errorf("got location %v and %v, want an error with %q", loc, err, test.err)
+			}
+		})
+	}
+
+	// Nothing is sent to the device when the uniform can't be set.
+	recorder.Reset()
+	if err := skinnedShader.SetUniformMat4Array("jointMats", make([]mgl32.Mat4, MaxJoints+1)); err == nil {
+		t.Errorf("too many joint matrices gave no error")
+	}
+	if err := skinnedShader.SetUniformInt32("model", 0); err == nil {
+		t.Errorf("setting a mat4 to an int gave no error")
+	}
+	if len(recorder.Commands) != 0 {
+		t.Errorf("the failed uniforms sent\n%v", recorder)
+	}
+}
@@ -0,0 +1,69 @@
+package gfx
+
+import (
+	"fmt"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"GopherGL/src/camera"
+	"GopherGL/src/mesh"
+	"GopherGL/src/skeleton"
+)
+
+// MaxJoints is the maximum amount of joints a skeleton can have, mesh.glsl has the same limit.
+const MaxJoints = 64
+
+var skinnedShader *Shader
+
+// SkinnedEntity is an Entity which is deformed by a skeleton.
+type SkinnedEntity struct {
+	*Entity
+	Skeleton *skeleton.Skeleton
+	palette  []mgl32.Mat4
+}
+
+// CreateSkinnedEntity uploads a mesh with joints and weights, and puts it in the rest pose of the skeleton.
+func CreateSkinnedEntity(m *mesh.Mesh, s *skeleton.Skeleton, mat *Material) (*SkinnedEntity, error) {
+	if !m.Skinned() {
+		return nil, fmt.Errorf("the mesh has no joints and weights")
+	}
+	if len(s.Joints) > MaxJoints {
+		return nil, fmt.Errorf("the skeleton has %v joints, the maximum is %v", len(s.Joints), MaxJoints)
+	}
+	for _, j := range m.Joints {
+		// The shader would read joint matrices that aren't set.
+		if int(j) >= len(s.Joints) {
+			return nil, fmt.Errorf("the mesh uses joint %v, the skeleton has %v joints", j, len(s.Joints))
+		}
+	}
+
+	entity, err := CreateEntity(m, mat)
+	if err != nil {
+		return nil, err
+	}
+	e := &SkinnedEntity{Entity: entity, Skeleton: s}
+	e.SetPose(s.RestPose())
+
+	return e, nil
+}
+
+// SetPose calculates the joint matrices of the pose, they're sent to the shader when rendering.
+func (e *SkinnedEntity) SetPose(p skeleton.Pose) {
+	e.palette = e.Skeleton.Palette(p, e.palette)
+}
+
+// RenderSkinned draws a SkinnedEntity in its current pose.
+func RenderSkinned(c *camera.Camera, e *SkinnedEntity, dl *DirectionalLight) {
+	// It's always skinned.glsl, but the textures, parameters and state come from the material.
+	p := e.mat.pipeline(skinnedShader.program)
+	device.SetPipeline(&p)
+	setCamera(c)
+	setLights(dl)
+	e.mat.apply(skinnedShader)
+
+	skinnedShader.SetUniformMat4("model", e.Trans)
+	skinnedShader.SetUniformMat4Array("jointMats", e.palette)
+
+	device.BindVertexArray(e.vao)
+	device.DrawIndexed(Triangles, e.size)
+}
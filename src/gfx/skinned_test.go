@@ -0,0 +1,32 @@
+package gfx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"GopherGL/src/mesh"
+	"GopherGL/src/skeleton"
+)
+
+func TestSkinnedJointRange(t *testing.T) {
+	s, err := skeleton.CreateSkeleton([]skeleton.Joint{
+		{Parent: -1, Rest: skeleton.IdentTransform(), InverseBind: mgl32.Ident4()},
+		{Parent: 0, Rest: skeleton.IdentTransform(), InverseBind: mgl32.Ident4()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One vertex with joint 2, the skeleton only has 0 and 1.
+	m := &mesh.Mesh{
+		Vertices: make([]float32, 8),
+		Indices:  []uint32{0, 0, 0},
+		Joints:   []uint16{0, 2, 0, 0},
+		Weights:  []float32{0.5, 0.5, 0.0, 0.0},
+	}
+	if _, err := CreateSkinnedEntity(m, s, nil); err == nil || !strings.Contains(err.Error(), "joint 2") {
+		t.Errorf("the error is %v", err)
+	}
+}
@@ -0,0 +1,249 @@
+package gfx
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"GopherGL/src/camera"
+	"GopherGL/src/prof"
+)
+
+// MaxSSAOSamples is the size of the sample kernel in ssao.glsl.
+const MaxSSAOSamples = 64
+
+var (
+	depthShader, ssaoShader, ssaoBlurShader *Shader
+	screenVao                               uint32
+
+	// currentAO is the ambient occlusion of this frame, set by RenderSSAO and cleared by BeginFrame.
+	currentAO *SSAO
+)
+
+// SSAO darkens the ambient light in corners and creases, using only the depth buffer.
+type SSAO struct {
+	// Radius is how far around a point, in world units, is checked for geometry.
+	Radius float32
+	// Bias keeps flat surfaces from occluding themselves.
+	Bias float32
+	// Samples is how many points are checked per pixel, up to MaxSSAOSamples.
+	Samples int
+	// Intensity scales the darkening, 1 is the physically plausible amount.
+	Intensity float32
+
+	width, height      int32
+	depthFbo, depthTex uint32
+	aoFbo, aoTex       uint32
+	blurFbo, blurTex   uint32
+	noiseTex           uint32
+	// kernel is made for the amount of samples, see createKernel.
+	kernel []mgl32.Vec3
+}
+
+// initSSAO creates the shaders and the empty vertex array used to draw a fullscreen triangle.
+func initSSAO() error {
+	var err error
+	if depthShader, err = createShader("../shaders/depth.glsl"); err != nil {
+		return err
+	}
+	if ssaoShader, err = createShader("../shaders/ssao.glsl"); err != nil {
+		return err
+	}
+	if ssaoBlurShader, err = createShader("../shaders/ssaoblur.glsl"); err != nil {
+		return err
+	}
+
+	// The core profile needs a vertex array bound, even when the vertices come from gl_VertexID.
+	screenVao = device.CreateVertexArray()
+
+	return nil
+}
+
+// CreateSSAO returns an SSAO pass with reasonable settings. The buffers are created on the first render.
+func CreateSSAO() *SSAO {
+	s := &SSAO{
+		Radius:    0.5,
+		Bias:      0.025,
+		Samples:   32,
+		Intensity: 1.0,
+	}
+
+	// A 4x4 tile of random rotations around the z axis.
+	r := rand.New(rand.NewSource(2))
+	noise := make([]float32, 4*4*3)
+	for i := 0; i < len(noise); i += 3 {
+		noise[i] = r.Float32()*2.0 - 1.0
+		noise[i+1] = r.Float32()*2.0 - 1.0
+	}
+
+	s.noiseTex = device.CreateTexture(TextureDesc{
+		Width:     4,
+		Height:    4,
+		Format:    RGB16F,
+		MinFilter: Nearest,
+		MagFilter: Nearest,
+		Wrap:      Repeat,
+	}, noise)
+
+	return s
+}
+
+// createKernel returns n points in the unit hemisphere around +z, more of them close to the center. They go out
+// from the center in order, so the kernel is only spread over the whole radius when all n are used.
+func createKernel(n int, seed int64) []mgl32.Vec3 {
+	r := rand.New(rand.NewSource(seed))
+	kernel := make([]mgl32.Vec3, n)
+	for i := range kernel {
+		v := mgl32.Vec3{r.Float32()*2.0 - 1.0, r.Float32()*2.0 - 1.0, r.Float32()}
+		v = v.Normalize().Mul(r.Float32())
+
+		// Close geometry matters more than far geometry.
+		scale := float32(i) / float32(n)
+		scale = 0.1 + 0.9*scale*scale
+		kernel[i] = v.Mul(scale)
+	}
+
+	return kernel
+}
+
+// createTarget makes a framebuffer with one texture attached.
+func createTarget(width, height int32, format TextureFormat, point Attachment) (uint32, uint32, error) {
+	tex := device.CreateTexture(TextureDesc{
+		Width:     width,
+		Height:    height,
+		Format:    format,
+		MinFilter: Nearest,
+		MagFilter: Nearest,
+		Wrap:      ClampToEdge,
+	}, nil)
+
+	fbo, err := device.CreateFramebuffer(FramebufferAttachment{point, tex})
+	if err != nil {
+		device.DeleteTexture(tex)
+		return 0, 0, err
+	}
+
+	return fbo, tex, nil
+}
+
+// resize recreates the buffers when the size of the screen changed.
+func (s *SSAO) resize(width, height int32) error {
+	if width == s.width && height == s.height {
+		return nil
+	}
+	s.Delete()
+	s.width, s.height = width, height
+
+	var err error
+	s.depthFbo, s.depthTex, err = createTarget(width, height, Depth24, DepthAttachment)
+	if err == nil {
+		s.aoFbo, s.aoTex, err = createTarget(width, height, R8, ColorAttachment)
+	}
+	if err == nil {
+		s.blurFbo, s.blurTex, err = createTarget(width, height, R8, ColorAttachment)
+	}
+	if err != nil {
+		s.Delete()
+		return fmt.Errorf("ssao buffers of %vx%v: %v", width, height, err)
+	}
+
+	return nil
+}
+
+// Delete frees the buffers, the noise texture stays so the pass can still be used.
+func (s *SSAO) Delete() {
+	if s.width == 0 {
+		return
+	}
+
+	for _, fbo := range []uint32{s.depthFbo, s.aoFbo, s.blurFbo} {
+		device.DeleteFramebuffer(fbo)
+	}
+	for _, tex := range []uint32{s.depthTex, s.aoTex, s.blurTex} {
+		device.DeleteTexture(tex)
+	}
+	s.depthFbo, s.aoFbo, s.blurFbo = 0, 0, 0
+	s.depthTex, s.aoTex, s.blurTex = 0, 0, 0
+	s.width, s.height = 0, 0
+}
+
+// RenderSSAO draws the depth of the entities and calculates the ambient occlusion from it. Call it after BeginFrame
+// and before Render, which then uses the result for the rest of the frame. When the buffers can't be created the
+// frame is drawn without ambient occlusion.
+func RenderSSAO(c *camera.Camera, s *SSAO, entities []*Entity) error {
+	prof.Begin("ssao")
+	defer prof.End()
+
+	// The buffers follow the size of the screen.
+	viewport := device.Viewport()
+	if err := s.resize(viewport[2], viewport[3]); err != nil {
+		return err
+	}
+
+	// Whatever was being drawn to before, the screen or a Target, is drawn to again at the end.
+	prevFbo := device.Framebuffer()
+
+	// Depth pre-pass.
+	device.BindFramebuffer(s.depthFbo)
+	device.SetViewport(0, 0, s.width, s.height)
+
+	p := defaultPipeline(depthShader.program)
+	device.SetPipeline(&p)
+	device.Clear(ClearDepth)
+	setCamera(c)
+	for _, e := range entities {
+		depthShader.SetUniformMat4("model", e.Trans)
+		vao, size := e.vao, e.size
+		if e.LOD != nil {
+			vao, size = e.lods[e.LOD.Current()].vao, e.lods[e.LOD.Current()].size
+		}
+		device.BindVertexArray(vao)
+		device.DrawIndexed(Triangles, size)
+	}
+
+	// The fullscreen passes don't need depth testing or blending.
+	device.BindVertexArray(screenVao)
+	p = Pipeline{Program: ssaoShader.program}
+
+	// Occlusion.
+	samples := s.Samples
+	if samples > MaxSSAOSamples {
+		samples = MaxSSAOSamples
+	} else if samples < 1 {
+		samples = 1
+	}
+	if len(s.kernel) != samples {
+		s.kernel = createKernel(samples, 1)
+	}
+
+	device.BindFramebuffer(s.aoFbo)
+	device.SetPipeline(&p)
+	bindTexture(0, s.depthTex, 0)
+	bindTexture(1, s.noiseTex, 0)
+	ssaoShader.SetUniformInt32("depthTex", 0)
+	ssaoShader.SetUniformInt32("noiseTex", 1)
+	ssaoShader.SetUniformMat4("projection", c.Proj)
+	ssaoShader.SetUniformMat4("invProjection", c.Proj.Inv())
+	ssaoShader.SetUniformVec3Array("samples", s.kernel)
+	ssaoShader.SetUniformInt32("sampleCount", int32(samples))
+	ssaoShader.SetUniformFloat("radius", s.Radius)
+	ssaoShader.SetUniformFloat("bias", s.Bias)
+	ssaoShader.SetUniformFloat("intensity", s.Intensity)
+	device.Draw(Triangles, 0, 3)
+
+	// Blur.
+	p.Program = ssaoBlurShader.program
+	device.BindFramebuffer(s.blurFbo)
+	device.SetPipeline(&p)
+	bindTexture(0, s.aoTex, 0)
+	ssaoBlurShader.SetUniformInt32("aoTex", 0)
+	device.Draw(Triangles, 0, 3)
+
+	// Go back to drawing to the screen.
+	device.BindFramebuffer(prevFbo)
+	device.SetViewport(viewport[0], viewport[1], viewport[2], viewport[3])
+
+	currentAO = s
+	return nil
+}
@@ -0,0 +1,107 @@
+package gfx
+
+// MaxTextureUnits is how many texture units the StateCache keeps track of, OpenGL 3.3 has at least 16.
+const MaxTextureUnits = 16
+
+// The pieces of state the StateCache knows about.
+const (
+	stateProgram = iota
+	stateVertexArray
+	stateArrayBuffer
+	stateFramebuffer
+	stateActiveTexture
+	stateBlend
+	stateBlendFunc
+	stateDepthTest
+	stateDepthMask
+	stateDepthFunc
+	stateCull
+	stateViewport
+	stateTexture
+	stateSampler = stateTexture + MaxTextureUnits
+	stateCount   = stateSampler + MaxTextureUnits
+)
+
+// StateCounters counts how many state changing calls were made and how many were skipped.
+type StateCounters struct {
+	Issued, Skipped int
+}
+
+// StateCache remembers the state of the graphics API, so calls that wouldn't change anything can be skipped.
+// It doesn't make any calls itself, the device asks it whether a call is needed.
+type StateCache struct {
+	// Enabled can be turned off to issue every call, for example to check whether the cache causes a bug.
+	Enabled bool
+
+	// Frame counts the calls of the current frame, LastFrame those of the frame before.
+	Frame, LastFrame StateCounters
+
+	values   [stateCount]int64
+	known    [stateCount]bool
+	viewport [4]int32
+}
+
+// CreateStateCache returns an enabled cache that doesn't know any state yet.
+func CreateStateCache() *StateCache {
+	return &StateCache{Enabled: true}
+}
+
+// change returns whether the state has to be set to v, and remembers it.
+func (c *StateCache) change(state int, v int64) bool {
+	if c.Enabled && c.known[state] && c.values[state] == v {
+		c.Frame.Skipped++
+		return false
+	}
+
+	c.values[state], c.known[state] = v, true
+	c.Frame.Issued++
+	return true
+}
+
+// changeBool is change for things that are on or off.
+func (c *StateCache) changeBool(state int, on bool) bool {
+	if on {
+		return c.change(state, 1)
+	}
+	return c.change(state, 0)
+}
+
+// changeViewport is change for the viewport.
+func (c *StateCache) changeViewport(v [4]int32) bool {
+	if c.Enabled && c.known[stateViewport] && c.viewport == v {
+		c.Frame.Skipped++
+		return false
+	}
+
+	c.viewport, c.known[stateViewport] = v, true
+	c.Frame.Issued++
+	return true
+}
+
+// get returns the remembered value of the state, and whether it's known.
+func (c *StateCache) get(state int) (int64, bool) {
+	return c.values[state], c.known[state] && c.Enabled
+}
+
+// set remembers a value without counting a call, for when the state was changed as a side effect.
+func (c *StateCache) set(state int, v int64) {
+	c.values[state], c.known[state] = v, true
+}
+
+// forgetIf forgets the state when it has the value, for when a bound resource is deleted.
+func (c *StateCache) forgetIf(state int, v int64) {
+	if c.known[state] && c.values[state] == v {
+		c.known[state] = false
+	}
+}
+
+// Invalidate forgets all state. Call it after making calls to the graphics API that don't go through the device.
+func (c *StateCache) Invalidate() {
+	c.known = [stateCount]bool{}
+}
+
+// NextFrame starts counting a new frame.
+func (c *StateCache) NextFrame() {
+	c.LastFrame = c.Frame
+	c.Frame = StateCounters{}
+}
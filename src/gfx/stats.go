@@ -0,0 +1,375 @@
+package gfx
+
+import (
+	"fmt"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// FrameStats is what was drawn in a frame, and how much GPU memory is used.
+type FrameStats struct {
+	DrawCalls, Triangles, Vertices int
+	// StateChanges counts the changes to the fixed function state, the viewport, framebuffer and vertex array.
+	StateChanges int
+	// TextureBinds and ShaderSwitches only count binds that change what was bound.
+	TextureBinds, ShaderSwitches int
+	Memory                       MemoryStats
+}
+
+// MemoryStats is an estimate of the GPU memory in bytes, by resource type.
+type MemoryStats struct {
+	Buffers, Textures int64
+	// RenderTargets are the textures attached to a framebuffer.
+	RenderTargets int64
+}
+
+// Total returns the memory of all resources together.
+func (m MemoryStats) Total() int64 {
+	return m.Buffers + m.Textures + m.RenderTargets
+}
+
+// Stats returns the statistics of the last frame, between the last two calls to BeginFrame. The memory is
+// what's used right now.
+func Stats() FrameStats {
+	s, ok := device.(*statsDevice)
+	if !ok {
+		return FrameStats{}
+	}
+
+	last := s.last
+	last.Memory = s.memory
+	return last
+}
+
+// statsDevice counts what goes through a device. Every device set with UseDevice is wrapped in one.
+type statsDevice struct {
+	Device
+
+	frame, last FrameStats
+	memory      MemoryStats
+
+	buffers  map[uint32]int64
+	textures map[uint32]int64
+	targets  map[uint32]bool
+
+	// What was bound last, to only count the changes.
+	pipeline     Pipeline
+	havePipeline bool
+	vao, fbo     uint32
+	viewport     [4]int32
+	units        [MaxTextureUnits]uint32
+}
+
+// wrapStats returns the device wrapped in a statsDevice.
+func wrapStats(d Device) *statsDevice {
+	return &statsDevice{
+		Device:   d,
+		buffers:  make(map[uint32]int64),
+		textures: make(map[uint32]int64),
+		targets:  make(map[uint32]bool),
+	}
+}
+
+// BeginFrame starts counting a new frame, and lets the device know if it counts things per frame too.
+func (d *statsDevice) BeginFrame() {
+	d.last = d.frame
+	d.frame = FrameStats{}
+
+	if inner, ok := d.Device.(interface{ BeginFrame() }); ok {
+		inner.BeginFrame()
+	}
+}
+
+// textureBytes estimates the memory of a texture, mipmaps add a third.
+func textureBytes(desc TextureDesc) int64 {
+	perPixel := map[TextureFormat]int64{
+		RGBA8: 4, R8: 1, RG8: 2, SRGBA8: 4, RGB16F: 6, RGBA16F: 8, Depth24: 4, Depth24Stencil8: 4,
+	}[desc.Format]
+
+	size := int64(desc.Width) * int64(desc.Height) * perPixel
+	if desc.Mipmaps {
+		size += size / 3
+	}
+
+	return size
+}
+
+// levelsBytes estimates the memory of a texture made with CreateTextureLevels.
+func levelsBytes(desc TextureDesc, levels [][]byte) int64 {
+	var size int64
+	for _, l := range levels {
+		size += int64(len(l))
+	}
+	if len(levels) == 1 && desc.Mipmaps && !desc.Format.Compressed() {
+		size += size / 3
+	}
+
+	return size
+}
+
+// The methods below count, and then call the same method of the device.
+
+func (d *statsDevice) CreateBuffer(data interface{}, usage Usage) uint32 {
+	id := d.Device.CreateBuffer(data, usage)
+	d.setBufferSize(id, data)
+
+	return id
+}
+
+func (d *statsDevice) UpdateBuffer(id uint32, data interface{}, usage Usage) {
+	d.Device.UpdateBuffer(id, data, usage)
+	d.setBufferSize(id, data)
+}
+
+// setBufferSize replaces the size of the buffer in the memory stats.
+func (d *statsDevice) setBufferSize(id uint32, data interface{}) {
+	size := int64(byteSize(data))
+	d.memory.Buffers += size - d.buffers[id]
+	d.buffers[id] = size
+}
+
+func (d *statsDevice) DeleteBuffer(id uint32) {
+	d.Device.DeleteBuffer(id)
+	d.memory.Buffers -= d.buffers[id]
+	delete(d.buffers, id)
+}
+
+func (d *statsDevice) BindVertexArray(id uint32) {
+	if id != d.vao {
+		d.frame.StateChanges++
+		d.vao = id
+	}
+	d.Device.BindVertexArray(id)
+}
+
+func (d *statsDevice) CreateVertexArray() uint32 {
+	// Creating a vertex array binds it.
+	id := d.Device.CreateVertexArray()
+	d.vao = id
+
+	return id
+}
+
+func (d *statsDevice) DeleteVertexArray(id uint32) {
+	d.Device.DeleteVertexArray(id)
+	if d.vao == id {
+		d.vao = 0
+	}
+}
+
+func (d *statsDevice) CreateTexture(desc TextureDesc, data interface{}) uint32 {
+	id := d.Device.CreateTexture(desc, data)
+	d.textures[id] = textureBytes(desc)
+	d.memory.Textures += d.textures[id]
+	d.units[0] = id
+
+	return id
+}
+
+func (d *statsDevice) CreateTextureLevels(desc TextureDesc, levels [][]byte) uint32 {
+	id := d.Device.CreateTextureLevels(desc, levels)
+	d.textures[id] = levelsBytes(desc, levels)
+	d.memory.Textures += d.textures[id]
+	d.units[0] = id
+
+	return id
+}
+
+func (d *statsDevice) BindTexture(unit int, id uint32) {
+	if unit >= MaxTextureUnits || d.units[unit] != id {
+		d.frame.TextureBinds++
+	}
+	if unit < MaxTextureUnits {
+		d.units[unit] = id
+	}
+	d.Device.BindTexture(unit, id)
+}
+
+func (d *statsDevice) SetTextureParams(id uint32, s SamplerDesc) {
+	// The device binds the texture to a unit to change it, so which texture is bound where isn't known anymore.
+	for unit := range d.units {
+		d.units[unit] = ^uint32(0)
+	}
+	d.Device.SetTextureParams(id, s)
+}
+
+func (d *statsDevice) DeleteTexture(id uint32) {
+	d.Device.DeleteTexture(id)
+
+	if d.targets[id] {
+		d.memory.RenderTargets -= d.textures[id]
+	} else {
+		d.memory.Textures -= d.textures[id]
+	}
+	delete(d.textures, id)
+	delete(d.targets, id)
+
+	for unit := range d.units {
+		if d.units[unit] == id {
+			d.units[unit] = 0
+		}
+	}
+}
+
+func (d *statsDevice) CreateFramebuffer(attachments ...FramebufferAttachment) (uint32, error) {
+	id, err := d.Device.CreateFramebuffer(attachments...)
+	if err != nil {
+		return id, err
+	}
+
+	// Once a texture is drawn to it counts as a render target, until it's deleted.
+	for _, a := range attachments {
+		if !d.targets[a.Texture] {
+			d.targets[a.Texture] = true
+			d.memory.Textures -= d.textures[a.Texture]
+			d.memory.RenderTargets += d.textures[a.Texture]
+		}
+	}
+
+	return id, nil
+}
+
+func (d *statsDevice) BindFramebuffer(id uint32) {
+	if id != d.fbo {
+		d.frame.StateChanges++
+		d.fbo = id
+	}
+	d.Device.BindFramebuffer(id)
+}
+
+func (d *statsDevice) DeleteFramebuffer(id uint32) {
+	d.Device.DeleteFramebuffer(id)
+	if d.fbo == id {
+		d.fbo = 0
+	}
+}
+
+func (d *statsDevice) DeleteProgram(id uint32) {
+	d.Device.DeleteProgram(id)
+	if d.havePipeline && d.pipeline.Program == id {
+		d.havePipeline = false
+	}
+}
+
+func (d *statsDevice) SetPipeline(p *Pipeline) {
+	old := d.pipeline
+	if !d.havePipeline {
+		// Everything is set the first time.
+		old = Pipeline{Program: ^p.Program, Blend: p.Blend + 1, DepthTest: !p.DepthTest,
+			DepthWrite: !p.DepthWrite, DepthFunc: p.DepthFunc + 1, Cull: !p.Cull}
+	}
+
+	if p.Program != old.Program {
+		d.frame.ShaderSwitches++
+	}
+	for _, changed := range []bool{
+		p.Blend != old.Blend,
+		p.DepthTest != old.DepthTest,
+		p.DepthWrite != old.DepthWrite,
+		p.DepthFunc != old.DepthFunc,
+		p.Cull != old.Cull,
+	} {
+		if changed {
+			d.frame.StateChanges++
+		}
+	}
+
+	d.pipeline, d.havePipeline = *p, true
+	d.Device.SetPipeline(p)
+}
+
+// useProgram counts a shader switch when a uniform of another program is set, the device uses that program.
+func (d *statsDevice) useProgram(program uint32) {
+	if !d.havePipeline || d.pipeline.Program != program {
+		d.frame.ShaderSwitches++
+	}
+	d.pipeline.Program = program
+}
+
+func (d *statsDevice) SetUniformInt(program uint32, loc int32, i int32) {
+	d.useProgram(program)
+	d.Device.SetUniformInt(program, loc, i)
+}
+
+func (d *statsDevice) SetUniformFloat(program uint32, loc int32, f float32) {
+	d.useProgram(program)
+	d.Device.SetUniformFloat(program, loc, f)
+}
+
+func (d *statsDevice) SetUniformVec2(program uint32, loc int32, v []mgl32.Vec2) {
+	d.useProgram(program)
+	d.Device.SetUniformVec2(program, loc, v)
+}
+
+func (d *statsDevice) SetUniformVec3(program uint32, loc int32, v []mgl32.Vec3) {
+	d.useProgram(program)
+	d.Device.SetUniformVec3(program, loc, v)
+}
+
+func (d *statsDevice) SetUniformMat4(program uint32, loc int32, m []mgl32.Mat4) {
+	d.useProgram(program)
+	d.Device.SetUniformMat4(program, loc, m)
+}
+
+func (d *statsDevice) SetViewport(x, y, width, height int32) {
+	if v := [4]int32{x, y, width, height}; v != d.viewport {
+		d.frame.StateChanges++
+		d.viewport = v
+	}
+	d.Device.SetViewport(x, y, width, height)
+}
+
+// countDraw adds a draw call of count vertices to the frame.
+func (d *statsDevice) countDraw(mode Primitive, count, instances int32) {
+	d.frame.DrawCalls++
+	d.frame.Vertices += int(count * instances)
+
+	switch mode {
+	case Triangles:
+		d.frame.Triangles += int(count / 3 * instances)
+	case TriangleStrip:
+		if count > 2 {
+			d.frame.Triangles += int((count - 2) * instances)
+		}
+	}
+}
+
+func (d *statsDevice) Draw(mode Primitive, first, count int32) {
+	d.countDraw(mode, count, 1)
+	d.Device.Draw(mode, first, count)
+}
+
+func (d *statsDevice) DrawIndexed(mode Primitive, count int32) {
+	d.countDraw(mode, count, 1)
+	d.Device.DrawIndexed(mode, count)
+}
+
+func (d *statsDevice) DrawInstanced(mode Primitive, first, count, instances int32) {
+	d.countDraw(mode, count, instances)
+	d.Device.DrawInstanced(mode, first, count, instances)
+}
+
+// megabytes formats a size in bytes.
+func megabytes(b int64) string {
+	return fmt.Sprintf("%.1f MB", float64(b)/(1024.0*1024.0))
+}
+
+// RenderStats draws the statistics of the last frame as text, in pixels from the top left of the screen. Draw
+// it last. Drawing it adds to the next frame's statistics.
+func RenderStats(x, y float32) {
+	s := Stats()
+	lines := []string{
+		fmt.Sprintf("draw calls: %v", s.DrawCalls),
+		fmt.Sprintf("triangles: %v", s.Triangles),
+		fmt.Sprintf("vertices: %v", s.Vertices),
+		fmt.Sprintf("state changes: %v", s.StateChanges),
+		fmt.Sprintf("texture binds: %v", s.TextureBinds),
+		fmt.Sprintf("shader switches: %v", s.ShaderSwitches),
+		"buffers: " + megabytes(s.Memory.Buffers),
+		"textures: " + megabytes(s.Memory.Textures),
+		"render targets: " + megabytes(s.Memory.RenderTargets),
+	}
+
+	addTextBox(x, y, lines, mgl32.Vec4{1.0, 1.0, 1.0, 1.0})
+	flushRects()
+}
@@ -0,0 +1,105 @@
+package gfx
+
+import (
+	"testing"
+)
+
+// freshStats counts the recorder from zero, the returned func puts the device of TestMain back.
+func freshStats() (*statsDevice, func()) {
+	old := device
+	s := wrapStats(recorder)
+	device = s
+	return s, func() { device = old }
+}
+
+func TestStatsCounting(t *testing.T) {
+	s, restore := freshStats()
+	defer restore()
+
+	s.BeginFrame()
+	a := Pipeline{Program: 1, Blend: BlendNone, DepthTest: true}
+	b := a
+	b.Program, b.Blend = 2, BlendAlpha
+	// The first pipeline sets everything, the same one again changes nothing.
+	s.SetPipeline(&a)
+	s.SetPipeline(&a)
+	s.SetPipeline(&b)
+	// A uniform of another program uses that program.
+	s.SetUniformFloat(2, 0, 1.0)
+	s.SetUniformFloat(3, 0, 1.0)
+
+	s.BindTexture(0, 10)
+	s.BindTexture(0, 10)
+	s.BindTexture(1, 10)
+	s.BindVertexArray(5)
+	s.BindVertexArray(5)
+	s.SetViewport(0, 0, 800, 600)
+	s.SetViewport(0, 0, 800, 600)
+
+	s.DrawIndexed(Triangles, 36)
+	s.DrawInstanced(Triangles, 0, 6, 10)
+	s.Draw(TriangleStrip, 0, 4)
+	s.Draw(Lines, 0, 2)
+
+	// It's only returned once the next frame starts.
+	if got := Stats(); got.DrawCalls != 0 {
+		t.Errorf("the frame counted %v draw calls before it ended", got.DrawCalls)
+	}
+	s.BeginFrame()
+
+	want := FrameStats{
+		DrawCalls: 4, Triangles: 12 + 20 + 2, Vertices: 36 + 60 + 4 + 2,
+		// 5 for the first pipeline, the blend of the second, the vertex array and the viewport.
+		StateChanges: 8,
+		TextureBinds: 2, ShaderSwitches: 3,
+	}
+	if got := Stats(); got != want {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+
+	// Nothing was drawn in the new frame.
+	s.BeginFrame()
+	if got := Stats(); got != (FrameStats{}) {
+		t.Errorf("an empty frame gave %+v", got)
+	}
+}
+
+func TestStatsMemory(t *testing.T) {
+	s, restore := freshStats()
+	defer restore()
+
+	buf := s.CreateBuffer(make([]float32, 10), StaticDraw)
+	if m := Stats().Memory; m.Buffers != 40 {
+		t.Errorf("the buffer is %v bytes, want 40", m.Buffers)
+	}
+	s.UpdateBuffer(buf, make([]float32, 20), StaticDraw)
+	if m := Stats().Memory; m.Buffers != 80 {
+		t.Errorf("the updated buffer is %v bytes, want 80", m.Buffers)
+	}
+
+	// A third more for the mipmaps.
+	tex := s.CreateTexture(TextureDesc{Width: 4, Height: 4, Format: RGBA8, Mipmaps: true}, nil)
+	levels := s.CreateTextureLevels(TextureDesc{Width: 8, Height: 8, Format: BC1}, [][]byte{make([]byte, 32),
+		make([]byte, 8)})
+	target := s.CreateTexture(TextureDesc{Width: 8, Height: 8, Format: RGBA16F}, nil)
+	if m := Stats().Memory; m.Textures != 85+40+512 || m.RenderTargets != 0 {
+		t.Errorf("got %+v, want 637 bytes of textures", m)
+	}
+
+	fbo, err := s.CreateFramebuffer(FramebufferAttachment{ColorAttachment, target})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := Stats().Memory; m.Textures != 125 || m.RenderTargets != 512 || m.Total() != 125+512+80 {
+		t.Errorf("got %+v, want the target moved to the render targets", m)
+	}
+
+	s.DeleteFramebuffer(fbo)
+	for _, id := range []uint32{tex, levels, target} {
+		s.DeleteTexture(id)
+	}
+	s.DeleteBuffer(buf)
+	if m := Stats().Memory; m != (MemoryStats{}) {
+		t.Errorf("got %+v after deleting everything", m)
+	}
+}
@@ -0,0 +1,116 @@
+package gfx
+
+import (
+	"fmt"
+
+	"GopherGL/src/camera"
+	"GopherGL/src/terrain"
+)
+
+var terrainShader *Shader
+
+// TerrainMaterial blends up to four tiling textures, using the channels of a splat map.
+type TerrainMaterial struct {
+	splat  *Texture
+	layers [4]*Texture
+	Tiling float32
+}
+
+// CreateTerrainMaterial takes in a splat map and up to four layer textures. The red channel of the splat map
+// is the amount of the first layer, green the second and so on. Tiling is how often the layers repeat. The error
+// is about the first texture that couldn't be loaded.
+func CreateTerrainMaterial(splatFile string, layerFiles []string, tiling float32) (*TerrainMaterial, error) {
+	if len(layerFiles) == 0 || len(layerFiles) > 4 {
+		return nil, fmt.Errorf("a terrain material needs 1 to 4 layers, not %v", len(layerFiles))
+	}
+
+	m := &TerrainMaterial{Tiling: tiling}
+
+	// The splat map is stretched over the whole terrain.
+	o := DefaultTextureOptions()
+	o.Wrap = ClampToEdge
+	var err error
+	m.splat, err = LoadTexture(splatFile, o)
+	if err != nil {
+		return nil, err
+	}
+
+	// The layers are repeated, and seen at a low angle far away.
+	o = DefaultTextureOptions()
+	o.Anisotropy = 8
+	for i := range m.layers {
+		if i >= len(layerFiles) {
+			// Unused layers have a weight of 0 anyway.
+			m.layers[i] = m.layers[0]
+			continue
+		}
+
+		m.layers[i], err = LoadTexture(layerFiles[i], o)
+		if err != nil {
+			// Free what was loaded before.
+			m.splat.Delete()
+			for _, t := range m.layers[:i] {
+				t.Delete()
+			}
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// terrainChunk is a chunk with a vertex array for every level of detail.
+type terrainChunk struct {
+	chunk terrain.Chunk
+	vaos  []uint32
+	sizes []int32
+}
+
+// TerrainEntity is a terrain uploaded to the GPU.
+type TerrainEntity struct {
+	Terrain *terrain.Terrain
+	mat     *TerrainMaterial
+	chunks  []terrainChunk
+}
+
+// CreateTerrainEntity uploads every level of detail of every chunk of the terrain.
+func CreateTerrainEntity(t *terrain.Terrain, mat *TerrainMaterial) *TerrainEntity {
+	e := &TerrainEntity{Terrain: t, mat: mat}
+
+	for _, c := range t.Chunks() {
+		tc := terrainChunk{chunk: c}
+		for level := 0; level < t.Levels; level++ {
+			vao, size, _ := uploadMesh(t.ChunkMesh(c, level))
+			tc.vaos = append(tc.vaos, vao)
+			tc.sizes = append(tc.sizes, size)
+		}
+		e.chunks = append(e.chunks, tc)
+	}
+
+	return e
+}
+
+// RenderTerrain draws every chunk of the terrain, at a level of detail based on the distance to the camera.
+func RenderTerrain(c *camera.Camera, t *TerrainEntity, dl *DirectionalLight) {
+	t.mat.splat.bind(0)
+	for i, l := range t.mat.layers {
+		l.bind(1 + i)
+	}
+
+	p := defaultPipeline(terrainShader.program)
+	device.SetPipeline(&p)
+	setCamera(c)
+	setLights(dl)
+	terrainShader.SetUniformInt32("mat.splat", 0)
+	terrainShader.SetUniformInt32("mat.layer0", 1)
+	terrainShader.SetUniformInt32("mat.layer1", 2)
+	terrainShader.SetUniformInt32("mat.layer2", 3)
+	terrainShader.SetUniformInt32("mat.layer3", 4)
+	terrainShader.SetUniformFloat("mat.tiling", t.mat.Tiling)
+
+	for _, tc := range t.chunks {
+		level := t.Terrain.SelectLOD(tc.chunk, c.Pos)
+		device.BindVertexArray(tc.vaos[level])
+		device.DrawIndexed(Triangles, tc.sizes[level])
+	}
+}
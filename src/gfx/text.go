@@ -0,0 +1,60 @@
+package gfx
+
+import (
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// glyphs is a tiny font for debug text. Every glyph is 3x5 pixels, the bits are the rows from the top, with the
+// highest bit on the left.
+var glyphs = map[rune]uint16{
+	'0': 0x7b6f, '1': 0x2c97, '2': 0x73e7, '3': 0x72cf, '4': 0x5bc9, '5': 0x79cf,
+	'6': 0x79ef, '7': 0x7292, '8': 0x7bef, '9': 0x7bcf, 'A': 0x2bed, 'B': 0x6bae,
+	'C': 0x3923, 'D': 0x6b6e, 'E': 0x79a7, 'F': 0x79a4, 'G': 0x396b, 'H': 0x5bed,
+	'I': 0x7497, 'J': 0x126a, 'K': 0x5bad, 'L': 0x4927, 'M': 0x5fed, 'N': 0x6b6d,
+	'O': 0x2b6a, 'P': 0x6ba4, 'Q': 0x2b73, 'R': 0x6bad, 'S': 0x388e, 'T': 0x7492,
+	'U': 0x5b6f, 'V': 0x5b6a, 'W': 0x5bfd, 'X': 0x5aad, 'Y': 0x5a92, 'Z': 0x72a7,
+	':': 0x0410, '.': 0x0002, '/': 0x12a4, '-': 0x01c0, '%': 0x52a5, '(': 0x1491,
+	')': 0x4494, ',': 0x0014, '_': 0x0007, '\'': 0x2400, '"': 0x5a00, '=': 0x0e38,
+	'[': 0x6926, ']': 0x324b, '<': 0x1511, '>': 0x4454, '+': 0x05d0, '*': 0x5540,
+	'!': 0x2482, '?': 0x6282, '#': 0x5f7d, '`': 0x4400,
+}
+
+// textWidth returns the width of the text in pixels.
+func textWidth(text string, scale float32) float32 {
+	return float32(len([]rune(text))) * 4.0 * scale
+}
+
+// addText adds the text as rectangles, one for every pixel of the font, to be drawn by flushRects. Lower case
+// letters are drawn as upper case, characters the font doesn't have as spaces.
+func addText(x, y, scale float32, text string, c mgl32.Vec4) {
+	for i, r := range []rune(strings.ToUpper(text)) {
+		g := glyphs[r]
+		for bit := 0; bit < 15; bit++ {
+			if g&(1<<uint(14-bit)) == 0 {
+				continue
+			}
+			px := x + (float32(i)*4.0+float32(bit%3))*scale
+			py := y + float32(bit/3)*scale
+			addRect(px, py, scale, scale, c)
+		}
+	}
+}
+
+// addTextBox adds lines of text on a dark background, to be drawn by flushRects.
+func addTextBox(x, y float32, lines []string, c mgl32.Vec4) {
+	const scale, lineHeight = 2.0, 7.0 * 2.0
+
+	width := float32(0.0)
+	for _, l := range lines {
+		if w := textWidth(l, scale); w > width {
+			width = w
+		}
+	}
+	addRect(x, y, width+8.0, float32(len(lines))*lineHeight+6.0, mgl32.Vec4{0.0, 0.0, 0.0, 0.7})
+
+	for i, l := range lines {
+		addText(x+4.0, y+4.0+float32(i)*lineHeight, scale, l, c)
+	}
+}
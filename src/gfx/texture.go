@@ -0,0 +1,464 @@
+package gfx
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/disintegration/imaging"
+
+	"GopherGL/src/gputex"
+)
+
+// MipFilter is how the mipmaps are chosen when a texture is smaller on screen than it is.
+type MipFilter int
+
+// The mip filters.
+const (
+	// MipNone only samples the full texture, the mipmaps aren't used.
+	MipNone MipFilter = iota
+	// MipNearest samples the mipmap closest in size.
+	MipNearest
+	// MipLinear blends the two mipmaps closest in size.
+	MipLinear
+)
+
+// SamplerOptions is how a texture is sampled.
+type SamplerOptions struct {
+	// MinFilter is for when the texture is smaller on screen, MagFilter for when it's bigger. They're Nearest or
+	// Linear, the mipmaps are chosen by MipFilter.
+	MinFilter, MagFilter Filter
+	MipFilter            MipFilter
+	Wrap                 Wrap
+	// Anisotropy sharpens textures seen at an angle, like a floor. 0 and 1 are off, it's limited to what the GPU
+	// does.
+	Anisotropy float32
+}
+
+// mipFilters are the device filters for a min filter and a mip filter.
+var mipFilters = map[[2]int]Filter{
+	{int(Nearest), int(MipNone)}:    Nearest,
+	{int(Linear), int(MipNone)}:     Linear,
+	{int(Nearest), int(MipNearest)}: NearestMipmapNearest,
+	{int(Linear), int(MipNearest)}:  LinearMipmapNearest,
+	{int(Nearest), int(MipLinear)}:  NearestMipmapLinear,
+	{int(Linear), int(MipLinear)}:   LinearMipmapLinear,
+}
+
+// desc returns the options for the device.
+func (o SamplerOptions) desc() (SamplerDesc, error) {
+	min, ok := mipFilters[[2]int{int(o.MinFilter), int(o.MipFilter)}]
+	if !ok || o.MagFilter != Nearest && o.MagFilter != Linear {
+		return SamplerDesc{}, fmt.Errorf("the min and mag filters are Nearest or Linear, with a MipFilter for the mipmaps")
+	}
+
+	return SamplerDesc{min, o.MagFilter, o.Wrap, o.Anisotropy}, nil
+}
+
+// TextureOptions is how a texture is stored and sampled.
+type TextureOptions struct {
+	SamplerOptions
+	// Format is how the pixels are stored on the GPU.
+	Format TextureFormat
+	// SRGB is for RGBA8 textures with colors in sRGB, like most images. They're converted to linear when sampled.
+	SRGB bool
+	// Mipmaps generates the smaller versions of the texture, a MipFilter needs them.
+	Mipmaps bool
+}
+
+// DefaultTextureOptions returns the options for a color texture: RGBA8, repeating, with mipmaps and trilinear
+// filtering.
+func DefaultTextureOptions() TextureOptions {
+	return TextureOptions{
+		SamplerOptions: SamplerOptions{MinFilter: Linear, MagFilter: Linear, MipFilter: MipLinear, Wrap: Repeat},
+		Format:         RGBA8,
+		Mipmaps:        true,
+	}
+}
+
+// format returns the format of the texture on the device.
+func (o TextureOptions) format() (TextureFormat, error) {
+	if !o.SRGB {
+		return o.Format, nil
+	}
+	if o.Format != RGBA8 && o.Format != SRGBA8 {
+		return 0, fmt.Errorf("only RGBA8 textures can be sRGB")
+	}
+	return SRGBA8, nil
+}
+
+// Texture is a 2D texture on the GPU, with its own sampling options or a Sampler shared with other textures.
+type Texture struct {
+	id            uint32
+	width, height int32
+	opts          TextureOptions
+	// sampler is nil when the options of the texture are used.
+	sampler *Sampler
+	// bytes is an estimate of the memory it uses, see LoadedAssets.
+	bytes int64
+}
+
+// Sampler is how textures are sampled. One sampler can be used by many textures, see Texture.UseSampler.
+type Sampler struct {
+	id   uint32
+	opts SamplerOptions
+}
+
+// channels are the values per pixel, and whether they're []uint8 or []float32. Depth textures can't be created
+// with data.
+var channels = map[TextureFormat]struct {
+	n     int
+	float bool
+}{
+	RGBA8:   {4, false},
+	R8:      {1, false},
+	RG8:     {2, false},
+	SRGBA8:  {4, false},
+	RGB16F:  {3, true},
+	RGBA16F: {4, true},
+}
+
+// CreateTexture uploads a texture. The data is nil, or a []uint8 or []float32 with a value for every channel of
+// every pixel, the rows from the bottom up. It's an error when the data doesn't match the format, or the options
+// don't make sense.
+func CreateTexture(width, height int32, data interface{}, o TextureOptions) (*Texture, error) {
+	format, err := o.format()
+	if err != nil {
+		return nil, err
+	}
+	sampler, err := o.desc()
+	if err != nil {
+		return nil, err
+	}
+	if o.MipFilter != MipNone && !o.Mipmaps {
+		return nil, fmt.Errorf("mip filtering needs mipmaps")
+	}
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("a texture can't be %vx%v", width, height)
+	}
+
+	if data != nil {
+		c, ok := channels[format]
+		n := 0
+		switch d := data.(type) {
+		case []uint8:
+			ok, n = ok && !c.float, len(d)
+		case []float32:
+			ok, n = ok && c.float, len(d)
+		default:
+			ok = false
+		}
+		if !ok {
+			return nil, fmt.Errorf("a %T can't be uploaded to a texture with format %v", data, format)
+		}
+		if want := int(width) * int(height) * c.n; n != want {
+			return nil, fmt.Errorf("a %vx%v texture needs %v values, not %v", width, height, want, n)
+		}
+	}
+
+	desc := TextureDesc{
+		Width:      width,
+		Height:     height,
+		Format:     format,
+		MinFilter:  sampler.MinFilter,
+		MagFilter:  sampler.MagFilter,
+		Wrap:       sampler.Wrap,
+		Anisotropy: sampler.Anisotropy,
+		Mipmaps:    o.Mipmaps,
+	}
+	id := device.CreateTexture(desc, data)
+	return &Texture{id: id, width: width, height: height, opts: o, bytes: textureBytes(desc)}, nil
+}
+
+// LoadTexture reads an image file into a texture. Every format but the depth formats can be loaded, channels the
+// format doesn't have are dropped. DDS, KTX and KTX2 files are uploaded like they're stored instead, see
+// readContainer. The error is a *NotFoundError, a *DecodeError, or about the options.
+func LoadTexture(file string, o TextureOptions) (*Texture, error) {
+	d, err := readTexture(file, o, device.SupportsFormat)
+	if err != nil {
+		return nil, err
+	}
+	return d.upload()
+}
+
+// textureData is a texture file that was read and decoded, it only has to be uploaded. It's made without the
+// device, so it can be done on another goroutine, see LoadTextureAsync.
+type textureData struct {
+	file          string
+	width, height int32
+	o             TextureOptions
+	// data is for CreateTexture, levels for CreateTextureLevels.
+	data   interface{}
+	levels [][]byte
+}
+
+// readTexture reads and decodes a texture file, supports says which formats the device can sample.
+func readTexture(file string, o TextureOptions, supports func(TextureFormat) bool) (*textureData, error) {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".dds", ".ktx", ".ktx2":
+		return readContainer(file, o, supports)
+	}
+
+	img, err := readImage(file)
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := o.format()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", file, err)
+	}
+	c, ok := channels[format]
+	if !ok {
+		return nil, fmt.Errorf("%v: an image can't be loaded into a texture with format %v", file, format)
+	}
+
+	var data interface{}
+	size := img.Bounds().Size()
+	if c.float {
+		// 16 bits per channel, for images that have more than 8.
+		rgba := image.NewRGBA64(image.Rect(0, 0, size.X, size.Y))
+		draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+		values := make([]float32, 0, size.X*size.Y*c.n)
+		for i := 0; i < len(rgba.Pix); i += 8 {
+			for j := 0; j < c.n; j++ {
+				values = append(values, float32(uint16(rgba.Pix[i+j*2])<<8|uint16(rgba.Pix[i+j*2+1]))/65535.0)
+			}
+		}
+		data = values
+	} else {
+		rgba := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+		draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+		values := rgba.Pix
+		if c.n < 4 {
+			values = make([]uint8, 0, size.X*size.Y*c.n)
+			for i := 0; i < len(rgba.Pix); i += 4 {
+				values = append(values, rgba.Pix[i:i+c.n]...)
+			}
+		}
+		data = values
+	}
+
+	return &textureData{file: file, width: int32(size.X), height: int32(size.Y), o: o, data: data}, nil
+}
+
+// upload creates the texture.
+func (d *textureData) upload() (*Texture, error) {
+	if d.levels == nil {
+		t, err := CreateTexture(d.width, d.height, d.data, d.o)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", d.file, err)
+		}
+		return t, nil
+	}
+
+	// The options were checked by readContainer.
+	sampler, _ := d.o.desc()
+	desc := TextureDesc{
+		Width:      d.width,
+		Height:     d.height,
+		Format:     d.o.Format,
+		MinFilter:  sampler.MinFilter,
+		MagFilter:  sampler.MagFilter,
+		Wrap:       sampler.Wrap,
+		Anisotropy: sampler.Anisotropy,
+		Mipmaps:    d.o.Mipmaps,
+	}
+	id := device.CreateTextureLevels(desc, d.levels)
+	return &Texture{id: id, width: d.width, height: d.height, opts: d.o, bytes: levelsBytes(desc, d.levels)}, nil
+}
+
+// readImage decodes an image file and flips it, because OpenGL has 0, 0 in the bottom left.
+func readImage(file string) (image.Image, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fileError(file, err)
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, &DecodeError{File: file, Err: err}
+	}
+
+	return imaging.FlipV(img), nil
+}
+
+// containerFormats are the texture formats of the gputex formats, and their sRGB versions.
+var containerFormats = map[gputex.Format][2]TextureFormat{
+	gputex.RGBA8:      {RGBA8, SRGBA8},
+	gputex.BC1:        {BC1, BC1SRGB},
+	gputex.BC1A:       {BC1A, BC1ASRGB},
+	gputex.BC2:        {BC2, BC2SRGB},
+	gputex.BC3:        {BC3, BC3SRGB},
+	gputex.BC4:        {BC4, BC4},
+	gputex.BC5:        {BC5, BC5},
+	gputex.BC6H:       {BC6H, BC6H},
+	gputex.BC6HSigned: {BC6HSigned, BC6HSigned},
+	gputex.BC7:        {BC7, BC7SRGB},
+}
+
+// readContainer reads a DDS, KTX or KTX2 file with the mipmaps in it. The file decides the format, o.Format is
+// ignored, and it's sRGB when the file or o.SRGB says so and the format can be. Without mipmaps in the file
+// they're generated when o.Mipmaps is set and the texture isn't compressed, otherwise the MipFilter is MipNone.
+// The rows aren't flipped, see the gputex package.
+//
+// When the GPU can't sample the format the mipmaps are decoded on the CPU, to RGBA8 or RGBA16F for BC6H. That's
+// slower to load and takes 4 to 8 times the memory.
+func readContainer(file string, o TextureOptions, supports func(TextureFormat) bool) (*textureData, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fileError(file, err)
+	}
+	defer f.Close()
+
+	img, err := gputex.Read(f)
+	if err != nil {
+		return nil, &DecodeError{File: file, Err: err}
+	}
+
+	formats := containerFormats[img.Format]
+	format := formats[0]
+	o.SRGB = (img.SRGB || o.SRGB) && formats[1] != formats[0]
+	if o.SRGB {
+		format = formats[1]
+	}
+
+	levels := img.Levels
+	if !supports(format) {
+		if levels, err = decodeLevels(img); err != nil {
+			return nil, &DecodeError{File: file, Err: err}
+		}
+		switch {
+		case img.Format.HDR():
+			format = RGBA16F
+		case o.SRGB:
+			format = SRGBA8
+		default:
+			format = RGBA8
+		}
+	}
+
+	o.Format = format
+	o.Mipmaps = len(levels) > 1 || o.Mipmaps && !format.Compressed()
+	if !o.Mipmaps {
+		o.MipFilter = MipNone
+	}
+	if _, err := o.desc(); err != nil {
+		return nil, fmt.Errorf("%v: %v", file, err)
+	}
+
+	return &textureData{file: file, width: int32(img.Width), height: int32(img.Height), o: o, levels: levels}, nil
+}
+
+// decodeLevels decodes the mipmaps to RGBA8, or to the bytes of RGBA floats for BC6H.
+func decodeLevels(img *gputex.Image) ([][]byte, error) {
+	levels := make([][]byte, len(img.Levels))
+	for i, l := range img.Levels {
+		w, h := img.LevelSize(i)
+		if !img.Format.HDR() {
+			pix, err := gputex.Decode(img.Format, w, h, l)
+			if err != nil {
+				return nil, err
+			}
+			levels[i] = pix
+			continue
+		}
+
+		values, err := gputex.DecodeFloat(img.Format, w, h, l)
+		if err != nil {
+			return nil, err
+		}
+		pix := make([]byte, len(values)*4)
+		for j, v := range values {
+			binary.LittleEndian.PutUint32(pix[j*4:], math.Float32bits(v))
+		}
+		levels[i] = pix
+	}
+
+	return levels, nil
+}
+
+// Size returns the width and height of the texture in pixels.
+func (t *Texture) Size() (int32, int32) {
+	return t.width, t.height
+}
+
+// Options returns how the texture is stored and sampled.
+func (t *Texture) Options() TextureOptions {
+	return t.opts
+}
+
+// SetSampling changes how the texture is sampled, when it doesn't use a Sampler. The format and mipmaps can't
+// change.
+func (t *Texture) SetSampling(o SamplerOptions) error {
+	s, err := o.desc()
+	if err != nil {
+		return err
+	}
+	if o.MipFilter != MipNone && !t.opts.Mipmaps {
+		return fmt.Errorf("mip filtering needs mipmaps")
+	}
+
+	t.opts.SamplerOptions = o
+	device.SetTextureParams(t.id, s)
+	return nil
+}
+
+// UseSampler samples the texture with the sampler instead of its own options, nil goes back to its own options.
+func (t *Texture) UseSampler(s *Sampler) error {
+	if s != nil && s.opts.MipFilter != MipNone && !t.opts.Mipmaps {
+		return fmt.Errorf("the sampler has mip filtering, the texture doesn't have mipmaps")
+	}
+
+	t.sampler = s
+	return nil
+}
+
+// bind binds the texture and its sampler to a texture unit.
+func (t *Texture) bind(unit int) {
+	var sampler uint32
+	if t.sampler != nil {
+		sampler = t.sampler.id
+	}
+	bindTexture(unit, t.id, sampler)
+}
+
+// Delete frees the texture. A Sampler it uses isn't deleted.
+func (t *Texture) Delete() {
+	device.DeleteTexture(t.id)
+	t.id = 0
+}
+
+// bindTexture binds a texture to a unit, with a sampler or 0 to use the options of the texture. A sampler stays
+// bound to its unit, so every texture is bound with this.
+func bindTexture(unit int, id, sampler uint32) {
+	device.BindTexture(unit, id)
+	device.BindSampler(unit, sampler)
+}
+
+// CreateSampler creates a sampler that textures can share.
+func CreateSampler(o SamplerOptions) (*Sampler, error) {
+	s, err := o.desc()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sampler{id: device.CreateSampler(s), opts: o}, nil
+}
+
+// Options returns how the sampler samples.
+func (s *Sampler) Options() SamplerOptions {
+	return s.opts
+}
+
+// Delete frees the sampler. Textures using it have to use another one, or their own options.
+func (s *Sampler) Delete() {
+	device.DeleteSampler(s.id)
+	s.id = 0
+}
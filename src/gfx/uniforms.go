@@ -0,0 +1,163 @@
+package gfx
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"GopherGL/src/camera"
+	"GopherGL/src/std140"
+)
+
+// MaxPointLights is how many point lights are drawn at the same time, lights.glsl has the same limit.
+const MaxPointLights = 8
+
+// frameBlock is the Frame block in frame.glsl, the fields are named like the members.
+type frameBlock struct {
+	view, projection mgl32.Mat4
+	viewPos          mgl32.Vec3
+	time             float32
+}
+
+// lightsBlock is the Lights block in lights.glsl.
+type lightsBlock struct {
+	sun             directionalLightBlock
+	pointLights     [MaxPointLights]pointLightBlock
+	pointLightCount int32
+}
+
+// directionalLightBlock is the DirectionalLight struct in lights.glsl.
+type directionalLightBlock struct {
+	direction mgl32.Vec3
+	intensity float32
+	color     mgl32.Vec3
+}
+
+// pointLightBlock is the PointLight struct in lights.glsl.
+type pointLightBlock struct {
+	position          mgl32.Vec3
+	constant          float32
+	color             mgl32.Vec3
+	linear, quadratic float32
+}
+
+// uniformBuffer is the buffer of a uniform block every program can use. It's only uploaded when the data changed,
+// so setting it for every Entity costs a comparison.
+type uniformBuffer struct {
+	block   string
+	binding uint32
+	id      uint32
+	// data is what was uploaded last, a frameBlock or lightsBlock.
+	data interface{}
+}
+
+var (
+	frameUniforms  = &uniformBuffer{block: "Frame", binding: 0, data: frameBlock{}}
+	lightsUniforms = &uniformBuffer{block: "Lights", binding: 1, data: lightsBlock{}}
+	uniformBuffers = []*uniformBuffer{frameUniforms, lightsUniforms}
+
+	// frameTime is the time of the frame in seconds, set by BeginFrame.
+	frameTime float32
+	// pointLights are the lights SetPointLights was called with.
+	pointLights []*PointLight
+)
+
+// initUniformBuffers creates the buffers and binds them to their binding points.
+func initUniformBuffers() error {
+	for _, u := range uniformBuffers {
+		data, err := std140.Encode(u.data)
+		if err != nil {
+			return fmt.Errorf("uniform block %v: %v", u.block, err)
+		}
+		u.id = device.CreateBuffer(data, DynamicDraw)
+		device.BindUniformBuffer(u.binding, u.id)
+	}
+
+	return nil
+}
+
+// set uploads the data, if it changed.
+func (u *uniformBuffer) set(data interface{}) {
+	if data == u.data {
+		return
+	}
+	u.data = data
+
+	// The layout was checked by initUniformBuffers.
+	buf, _ := std140.Encode(data)
+	device.UpdateBuffer(u.id, buf, DynamicDraw)
+}
+
+// bindBlocks binds the uniform blocks of a program to the buffers, and checks that the members are where the Go
+// side puts them. Offsets the device doesn't know aren't checked.
+func bindBlocks(program uint32) error {
+	blocks := device.UniformBlocks(program)
+	if len(blocks) == 0 {
+		return nil
+	}
+	uniforms := device.ActiveUniforms(program)
+
+	for _, b := range blocks {
+		var u *uniformBuffer
+		for _, ub := range uniformBuffers {
+			if ub.block == b.Name {
+				u = ub
+			}
+		}
+		if u == nil {
+			return fmt.Errorf("there's no buffer for the uniform block %v", b.Name)
+		}
+		device.SetUniformBlockBinding(program, b.Name, u.binding)
+
+		offsets, _ := std140.Offsets(u.data)
+		size, _ := std140.Size(u.data)
+		if int(b.Size) > size {
+			return fmt.Errorf("the uniform block %v is %v bytes, the Go side only %v", b.Name, b.Size, size)
+		}
+		for _, v := range uniforms {
+			if v.Block != b.Name || v.Offset < 0 {
+				continue
+			}
+			// Arrays are named after their first element.
+			offset, ok := offsets[strings.TrimSuffix(v.Name, "[0]")]
+			switch {
+			case !ok:
+				return fmt.Errorf("the uniform block %v has %v, the Go side doesn't", b.Name, v.Name)
+			case offset != int(v.Offset):
+				return fmt.Errorf("%v is at %v in the uniform block %v, the Go side puts it at %v", v.Name, v.Offset,
+					b.Name, offset)
+			}
+		}
+	}
+
+	return nil
+}
+
+// setCamera uploads the camera for the shaders that include frame.glsl.
+func setCamera(c *camera.Camera) {
+	frameUniforms.set(frameBlock{view: c.View, projection: c.Proj, viewPos: c.Pos, time: frameTime})
+}
+
+// setLights uploads the sun and the point lights for the shaders that include lights.glsl.
+func setLights(sun *DirectionalLight) {
+	var l lightsBlock
+	l.sun = directionalLightBlock{sun.dir, sun.intensity, sun.color}
+	for i, p := range pointLights {
+		l.pointLights[i] = pointLightBlock{p.position, p.constant, p.color, p.linear, p.quadratic}
+	}
+	l.pointLightCount = int32(len(pointLights))
+
+	lightsUniforms.set(l)
+}
+
+// SetPointLights sets the point lights that light up the scene, at most MaxPointLights. The lights are read
+// every time an Entity is drawn, so they can be changed after this.
+func SetPointLights(lights ...*PointLight) error {
+	if len(lights) > MaxPointLights {
+		return fmt.Errorf("%v point lights, the maximum is %v", len(lights), MaxPointLights)
+	}
+	pointLights = append(pointLights[:0], lights...)
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package glsl
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Var is a uniform or an input of the vertex stage.
+type Var struct {
+	Name string
+	// Type is the GLSL type, like "vec3" or "sampler2D".
+	Type string
+	// Size is the length of an array, 1 for other variables.
+	Size int
+	// Location is from the layout of an input, -1 when there isn't one.
+	Location int
+	// Block is the uniform block the uniform is in, "" for the others.
+	Block string
+}
+
+var (
+	comments    = regexp.MustCompile(`(?s)//[^\n]*|/\*.*?\*/`)
+	directives  = regexp.MustCompile(`(?m)^\s*#.*$`)
+	structDecl  = regexp.MustCompile(`struct\s+(\w+)\s*\{([^}]*)\}\s*;`)
+	constDecl   = regexp.MustCompile(`const\s+u?int\s+(\w+)\s*=\s*(\d+)\s*;`)
+	uniformDecl = regexp.MustCompile(`(?:^|[;}\s])uniform\s+(?:(?:lowp|mediump|highp)\s+)?(\w+)\s+([^;{]+);`)
+	blockDecl   = regexp.MustCompile(`(?:^|[;}\s])uniform\s+(\w+)\s*\{([^}]*)\}\s*(\w*)\s*;`)
+	inputDecl   = regexp.MustCompile(`(?m)^\s*(?:layout\s*\(\s*location\s*=\s*(\d+)\s*\)\s*)?in\s+(?:(?:flat|smooth)\s+)?(\w+)\s+(\w+)\s*;`)
+	declName    = regexp.MustCompile(`^(\w+)\s*(?:\[\s*(\w+)\s*\])?$`)
+)
+
+// Declarations returns the uniforms and inputs declared in the source of a stage. Uniforms of a struct type are
+// split into their fields like OpenGL does, "mat.diffTex". The members of uniform blocks are uniforms too, with
+// Block set. It doesn't evaluate #if, so it can return variables a
+// compiler wouldn't see, and it doesn't know which ones the compiler drops because they aren't used.
+func Declarations(source string) (uniforms, inputs []Var, err error) {
+	source = comments.ReplaceAllString(source, "")
+	source = directives.ReplaceAllString(source, "")
+
+	consts := constants(source)
+	structs, err := structures(source, consts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, m := range uniformDecl.FindAllStringSubmatch(source, -1) {
+		vars, err := names(m[1], m[2], consts)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, v := range vars {
+			uniforms = append(uniforms, expand(v, structs)...)
+		}
+	}
+
+	for _, m := range blockDecl.FindAllStringSubmatch(source, -1) {
+		// Without an instance name the members are named on their own, with one OpenGL puts the name of the
+		// block in front.
+		prefix := ""
+		if m[3] != "" {
+			prefix = m[1] + "."
+		}
+		for _, decl := range strings.Split(m[2], ";") {
+			parts := strings.Fields(decl)
+			if len(parts) < 2 {
+				continue
+			}
+			vars, err := names(parts[0], strings.Join(parts[1:], " "), consts)
+			if err != nil {
+				return nil, nil, fmt.Errorf("uniform block %v: %v", m[1], err)
+			}
+			for _, v := range vars {
+				v.Name = prefix + v.Name
+				for _, u := range expand(v, structs) {
+					u.Block = m[1]
+					uniforms = append(uniforms, u)
+				}
+			}
+		}
+	}
+
+	for _, m := range inputDecl.FindAllStringSubmatch(source, -1) {
+		loc := -1
+		if m[1] != "" {
+			loc, _ = strconv.Atoi(m[1])
+		}
+		inputs = append(inputs, Var{Name: m[3], Type: m[2], Size: 1, Location: loc})
+	}
+
+	return uniforms, inputs, nil
+}
+
+// Structs returns the fields of the structs declared in the source of a stage, by the name of the struct.
+func Structs(source string) (map[string][]Var, error) {
+	source = comments.ReplaceAllString(source, "")
+	source = directives.ReplaceAllString(source, "")
+
+	return structures(source, constants(source))
+}
+
+// constants returns the integer constants, they can be the size of arrays.
+func constants(source string) map[string]int {
+	consts := make(map[string]int)
+	for _, m := range constDecl.FindAllStringSubmatch(source, -1) {
+		consts[m[1]], _ = strconv.Atoi(m[2])
+	}
+
+	return consts
+}
+
+// structures returns the fields of the structs, the comments and directives have to be removed already.
+func structures(source string, consts map[string]int) (map[string][]Var, error) {
+	structs := make(map[string][]Var)
+	for _, m := range structDecl.FindAllStringSubmatch(source, -1) {
+		var fields []Var
+		for _, f := range strings.Split(m[2], ";") {
+			parts := strings.Fields(f)
+			if len(parts) < 2 {
+				continue
+			}
+			vars, err := names(parts[0], strings.Join(parts[1:], " "), consts)
+			if err != nil {
+				return nil, fmt.Errorf("struct %v: %v", m[1], err)
+			}
+			fields = append(fields, vars...)
+		}
+		structs[m[1]] = fields
+	}
+
+	return structs, nil
+}
+
+// names splits a declaration like "a, b[4]" into variables of the type.
+func names(xtype, decl string, consts map[string]int) ([]Var, error) {
+	var vars []Var
+	for _, n := range strings.Split(decl, ",") {
+		m := declName.FindStringSubmatch(strings.TrimSpace(n))
+		if m == nil {
+			return nil, fmt.Errorf("can't read the declaration %q", strings.TrimSpace(n))
+		}
+
+		size := 1
+		if m[2] != "" {
+			var err error
+			if size, err = strconv.Atoi(m[2]); err != nil {
+				c, ok := consts[m[2]]
+				if !ok {
+					return nil, fmt.Errorf("unknown array size %v of %v", m[2], m[1])
+				}
+				size = c
+			}
+		}
+		vars = append(vars, Var{Name: m[1], Type: xtype, Size: size, Location: -1})
+	}
+
+	return vars, nil
+}
+
+// expand splits a variable of a struct type into its fields, every element of an array of structs on its own.
+func expand(v Var, structs map[string][]Var) []Var {
+	fields, ok := structs[v.Type]
+	if !ok {
+		return []Var{v}
+	}
+
+	var vars []Var
+	for i := 0; i < v.Size; i++ {
+		prefix := v.Name
+		if v.Size > 1 {
+			prefix = fmt.Sprintf("%v[%v]", v.Name, i)
+		}
+		for _, f := range fields {
+			f.Name = prefix + "." + f.Name
+			vars = append(vars, expand(f, structs)...)
+		}
+	}
+
+	return vars
+}
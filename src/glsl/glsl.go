@@ -0,0 +1,232 @@
+// Package glsl reads shader files. It splits them into stages on the #vertex, #geometry and #fragment lines,
+// replaces #include lines with the file and adds #defines. It remembers where every line came from, so compile
+// errors can point at the file and line that was written, instead of the line in the joined source.
+package glsl
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ReadFile reads the shader files, it can be replaced to load them from somewhere else.
+var ReadFile = ioutil.ReadFile
+
+// Origin is the file and line a line of a stage came from. Lines that were added, like the defines, have no file.
+type Origin struct {
+	File string
+	Line int
+}
+
+// String formats the origin as file:line, or only the file when the line isn't known.
+func (o Origin) String() string {
+	if o.File == "" {
+		return "(added)"
+	}
+	if o.Line == 0 {
+		return o.File
+	}
+	return fmt.Sprintf("%v:%v", o.File, o.Line)
+}
+
+// Error is something wrong in a shader file, or a file it includes. Err is an *os.PathError when a file couldn't
+// be read.
+type Error struct {
+	Origin Origin
+	Err    error
+}
+
+// Error returns where it went wrong and what.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v: %v", e.Origin, e.Err)
+}
+
+// Stage is the source of one shader stage, with the origin of every line.
+type Stage struct {
+	Source string
+	// Lines[i] is where line i+1 of the source came from.
+	Lines []Origin
+
+	included map[string]bool
+}
+
+// Origin returns where a line of the source came from, counting from 1 like compilers do.
+func (s *Stage) Origin(line int) (Origin, bool) {
+	if line < 1 || line > len(s.Lines) {
+		return Origin{}, false
+	}
+	return s.Lines[line-1], true
+}
+
+// logLine matches the line numbers in compile logs: "0:12(7)" from Mesa, "0(12)" from Nvidia and "0:12:" from
+// AMD and Intel. The 0 is the source string, there's only one.
+var logLine = regexp.MustCompile(`\b0(?::(\d+)|\((\d+)\))`)
+
+// MapLog replaces the line numbers in a compile log with the file and line they came from.
+func (s *Stage) MapLog(log string) string {
+	return logLine.ReplaceAllStringFunc(log, func(m string) string {
+		sub := logLine.FindStringSubmatch(m)
+		n := sub[1] + sub[2]
+
+		var line int
+		fmt.Sscan(n, &line)
+		if o, ok := s.Origin(line); ok {
+			return o.String()
+		}
+		return m
+	})
+}
+
+// Program is the stages of a shader file. Geometry is nil when the file doesn't have that stage.
+type Program struct {
+	Vertex, Geometry, Fragment *Stage
+	// Files is every file that was read, the shader file first. When one of them changes the shader has to be
+	// loaded again.
+	Files []string
+}
+
+// Load reads a shader file. The defines are names like "SKINNED", or a name and a value like "MAX_LIGHTS=4", they
+// are added to every stage right after the #version line. Lines before the first stage are ignored. The error is an
+// *os.PathError when the file can't be read, and an *Error for everything else.
+func Load(file string, defines ...string) (*Program, error) {
+	src, err := ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Program{Files: []string{file}}
+	var stage *Stage
+
+	for i, line := range strings.Split(string(src), "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		switch strings.TrimSpace(line) {
+		case "#vertex":
+			stage, err = p.newStage(&p.Vertex, file, i+1)
+		case "#geometry":
+			stage, err = p.newStage(&p.Geometry, file, i+1)
+		case "#fragment":
+			stage, err = p.newStage(&p.Fragment, file, i+1)
+		default:
+			if stage != nil {
+				err = p.addLine(stage, line, Origin{file, i + 1}, []string{file}, defines)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if p.Vertex == nil || p.Fragment == nil {
+		return nil, &Error{Origin{File: file}, errors.New("needs a #vertex and a #fragment stage")}
+	}
+
+	return p, nil
+}
+
+// newStage starts a stage, a file can only have each stage once.
+func (p *Program) newStage(s **Stage, file string, line int) (*Stage, error) {
+	if *s != nil {
+		return nil, &Error{Origin{file, line}, errors.New("the stage is there twice")}
+	}
+
+	*s = &Stage{included: make(map[string]bool)}
+	return *s, nil
+}
+
+// include matches an #include line, the file is relative to the file it's in.
+var include = regexp.MustCompile(`^\s*#include\s+"([^"]+)"\s*$`)
+
+// addLine adds a line to the stage. Included files are added line by line, stack is the files being included, to
+// find files that include themselves.
+func (p *Program) addLine(s *Stage, line string, o Origin, stack []string, defines []string) error {
+	if m := include.FindStringSubmatch(line); m != nil {
+		return p.include(s, filepath.Join(filepath.Dir(o.File), m[1]), o, stack, defines)
+	}
+
+	s.Source += line + "\n"
+	s.Lines = append(s.Lines, o)
+
+	// The defines have to come after the #version line, nothing can be before it.
+	if strings.HasPrefix(strings.TrimSpace(line), "#version") {
+		for _, d := range defines {
+			s.Source += "#define " + strings.Replace(d, "=", " ", 1) + "\n"
+			s.Lines = append(s.Lines, Origin{})
+		}
+	}
+
+	return nil
+}
+
+// include adds the lines of an included file to the stage. A file is only added once to a stage, so two files
+// can include the same struct.
+func (p *Program) include(s *Stage, file string, o Origin, stack []string, defines []string) error {
+	for _, f := range stack {
+		if f == file {
+			return &Error{o, fmt.Errorf("include cycle: %v", strings.Join(append(stack, file), " -> "))}
+		}
+	}
+	if s.included[file] {
+		return nil
+	}
+
+	src, err := ReadFile(file)
+	if err != nil {
+		return &Error{o, err}
+	}
+	p.addFile(file)
+	s.included[file] = true
+
+	stack = append(stack, file)
+	for i, line := range strings.Split(string(src), "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch strings.TrimSpace(line) {
+		case "#vertex", "#geometry", "#fragment":
+			return &Error{Origin{file, i + 1}, errors.New("included files can't have stages")}
+		}
+
+		if err := p.addLine(s, line, Origin{file, i + 1}, stack, defines); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addFile adds a file to Files, if it isn't there yet.
+func (p *Program) addFile(file string) {
+	for _, f := range p.Files {
+		if f == file {
+			return
+		}
+	}
+	p.Files = append(p.Files, file)
+}
+
+// VariantKey returns the same key for the same features in any order.
+func VariantKey(features []string) string {
+	sorted := append([]string(nil), features...)
+	sort.Strings(sorted)
+
+	return strings.Join(sorted, "+")
+}
+
+// Permutations returns every combination of the flags, from none of them to all of them.
+func Permutations(flags []string) [][]string {
+	var perms [][]string
+	for mask := 0; mask < 1<<uint(len(flags)); mask++ {
+		var p []string
+		for i, f := range flags {
+			if mask&(1<<uint(i)) != 0 {
+				p = append(p, f)
+			}
+		}
+		perms = append(perms, p)
+	}
+
+	return perms
+}
@@ -0,0 +1,195 @@
+package glsl
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// stub makes ReadFile read from the map, the returned func puts ioutil.ReadFile back.
+func stub(files map[string]string) func() {
+	old := ReadFile
+	ReadFile = func(file string) ([]byte, error) {
+		src, ok := files[filepath.ToSlash(file)]
+		if !ok {
+			return nil, &os.PathError{Op: "open", Path: file, Err: os.ErrNotExist}
+		}
+		return []byte(src), nil
+	}
+	return func() { ReadFile = old }
+}
+
+func TestLoad(t *testing.T) {
+	defer stub(map[string]string{
+		"shaders/main.glsl": strings.Join([]string{
+			"// Lines before the first stage are ignored.",
+			"#vertex",
+			"#version 330 core",
+			`#include "a.glsl"`,
+			`#include "lib/b.glsl"`,
+			"void main() {}",
+			"#fragment",
+			"#version 330 core",
+			`#include "a.glsl"`,
+			"void main() {}",
+		}, "\n"),
+		"shaders/a.glsl": "struct A { float x; };",
+		// Included files are relative to the file that includes them.
+		"shaders/lib/b.glsl": "#include \"../a.glsl\"\r\nfloat b;",
+	})()
+
+	p, err := Load("shaders/main.glsl", "SKINNED", "MAX_LIGHTS=4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	main, a, b := "shaders/main.glsl", filepath.FromSlash("shaders/a.glsl"), filepath.FromSlash("shaders/lib/b.glsl")
+	// a.glsl is only added once to the vertex stage, though b.glsl includes it too.
+	vertex := "#version 330 core\n#define SKINNED\n#define MAX_LIGHTS 4\nstruct A { float x; };\nfloat b;\n" +
+		"void main() {}\n"
+	if p.Vertex.Source != vertex {
+		t.Errorf("the vertex stage is\n%v\nwant\n%v", p.Vertex.Source, vertex)
+	}
+	lines := []Origin{{main, 3}, {}, {}, {a, 1}, {b, 2}, {main, 6}}
+	if !reflect.DeepEqual(p.Vertex.Lines, lines) {
+		t.Errorf("the vertex lines are %v, want %v", p.Vertex.Lines, lines)
+	}
+
+	// Every stage includes its own copy.
+	fragment := "#version 330 core\n#define SKINNED\n#define MAX_LIGHTS 4\nstruct A { float x; };\nvoid main() {}\n"
+	if p.Fragment.Source != fragment {
+		t.Errorf("the fragment stage is\n%v\nwant\n%v", p.Fragment.Source, fragment)
+	}
+	if p.Geometry != nil {
+		t.Errorf("there is a geometry stage")
+	}
+	if files := []string{main, a, b}; !reflect.DeepEqual(p.Files, files) {
+		t.Errorf("the files are %v, want %v", p.Files, files)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	stages := func(vertex string) string {
+		return "#vertex\n" + vertex + "\n#fragment\nvoid main() {}"
+	}
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		// origin is where the *Error points, and err is in its message. An empty origin means an *os.PathError.
+		origin Origin
+		err    string
+	}{
+		{
+			"missing file", map[string]string{}, Origin{}, "",
+		},
+		{
+			"missing include", map[string]string{"main.glsl": stages(`#include "no.glsl"`)},
+			Origin{"main.glsl", 2}, "no.glsl",
+		},
+		{
+			// The shortest cycle.
+			"includes itself",
+			map[string]string{"main.glsl": stages(`#include "a.glsl"`), "a.glsl": `#include "a.glsl"`},
+			Origin{"a.glsl", 1}, "include cycle: main.glsl -> a.glsl -> a.glsl",
+		},
+		{
+			"cycle", map[string]string{
+				"main.glsl": stages(`#include "a.glsl"`),
+				"a.glsl":    "float a;\n#include \"b.glsl\"",
+				"b.glsl":    `#include "a.glsl"`,
+			},
+			Origin{"b.glsl", 1}, "include cycle: main.glsl -> a.glsl -> b.glsl -> a.glsl",
+		},
+		{
+			"stage in include", map[string]string{"main.glsl": stages(`#include "a.glsl"`), "a.glsl": "\n#fragment"},
+			Origin{"a.glsl", 2}, "included files can't have stages",
+		},
+		{
+			"stage twice", map[string]string{"main.glsl": stages("") + "\n#vertex"},
+			Origin{"main.glsl", 5}, "the stage is there twice",
+		},
+		{
+			"no fragment stage", map[string]string{"main.glsl": "#vertex\nvoid main() {}"},
+			Origin{File: "main.glsl"}, "needs a #vertex and a #fragment stage",
+		},
+		{
+			"no vertex stage", map[string]string{"main.glsl": "#geometry\n#fragment"},
+			Origin{File: "main.glsl"}, "needs a #vertex and a #fragment stage",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer stub(test.files)()
+			_, err := Load("main.glsl")
+
+			if test.origin == (Origin{}) {
+				if _, ok := err.(*os.PathError); !ok {
+					t.Errorf("got %v, want an *os.PathError", err)
+				}
+				return
+			}
+			e, ok := err.(*Error)
+			if !ok {
+				t.Fatalf("got %v, want an *Error", err)
+			}
+			if e.Origin != test.origin || !strings.Contains(e.Error(), test.err) {
+				t.Errorf("got %v, want %q at %v", e, test.err, test.origin)
+			}
+		})
+	}
+}
+
+func TestMapLog(t *testing.T) {
+	s := &Stage{Lines: []Origin{{}, {"a.glsl", 4}, {"main.glsl", 12}}}
+
+	tests := []struct {
+		name, log, want string
+	}{
+		{"mesa", "0:3(7): error: `x' undeclared", "main.glsl:12(7): error: `x' undeclared"},
+		{"nvidia", "0(2) : error C1008: undefined variable \"x\"", "a.glsl:4 : error C1008: undefined variable \"x\""},
+		{"amd and intel", "ERROR: 0:3: 'x' : undeclared", "ERROR: main.glsl:12: 'x' : undeclared"},
+		{"added line", "0:1(1): error: bad define", "(added)(1): error: bad define"},
+		// Lines that don't exist and other numbers are left alone.
+		{"out of range", "0:9(1): error 10:3", "0:9(1): error 10:3"},
+		{"several", "0:2(1): a\n0:3(1): b", "a.glsl:4(1): a\nmain.glsl:12(1): b"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := s.MapLog(test.log); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPermutations(t *testing.T) {
+	want := [][]string{nil, {"A"}, {"B"}, {"A", "B"}, {"C"}, {"A", "C"}, {"B", "C"}, {"A", "B", "C"}}
+	if got := Permutations([]string{"A", "B", "C"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := Permutations(nil); len(got) != 1 || got[0] != nil {
+		t.Errorf("no flags gave %v, want only the empty permutation", got)
+	}
+}
+
+func TestVariantKey(t *testing.T) {
+	features := []string{"SKINNED", "FOG", "SHADOWS"}
+	key := VariantKey(features)
+	if key != "FOG+SHADOWS+SKINNED" {
+		t.Errorf("the key is %q", key)
+	}
+	if features[0] != "SKINNED" {
+		t.Errorf("the features were sorted in place")
+	}
+	if other := VariantKey([]string{"SHADOWS", "SKINNED", "FOG"}); other != key {
+		t.Errorf("another order gave %q, want %q", other, key)
+	}
+	if VariantKey(nil) != "" {
+		t.Errorf("no features gave %q", VariantKey(nil))
+	}
+}
@@ -0,0 +1,428 @@
+// Package gltf reads meshes, skins and animations from glTF 2.0 files, both .gltf and .glb.
+package gltf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// The parts of the JSON document we use.
+type document struct {
+	Nodes       []node       `json:"nodes"`
+	Meshes      []meshDef    `json:"meshes"`
+	Accessors   []accessor   `json:"accessors"`
+	BufferViews []bufferView `json:"bufferViews"`
+	Buffers     []buffer     `json:"buffers"`
+	Skins       []skin       `json:"skins"`
+	Animations  []animation  `json:"animations"`
+}
+
+type node struct {
+	Name        string       `json:"name"`
+	Children    []int        `json:"children"`
+	Mesh        *int         `json:"mesh"`
+	Skin        *int         `json:"skin"`
+	Translation *[3]float32  `json:"translation"`
+	Rotation    *[4]float32  `json:"rotation"`
+	Scale       *[3]float32  `json:"scale"`
+	Matrix      *[16]float32 `json:"matrix"`
+}
+
+type meshDef struct {
+	Name       string      `json:"name"`
+	Primitives []primitive `json:"primitives"`
+}
+
+type primitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices"`
+	Mode       *int           `json:"mode"`
+}
+
+type accessor struct {
+	BufferView    *int             `json:"bufferView"`
+	ByteOffset    int              `json:"byteOffset"`
+	ComponentType int              `json:"componentType"`
+	Normalized    bool             `json:"normalized"`
+	Count         int              `json:"count"`
+	Type          string           `json:"type"`
+	Sparse        *json.RawMessage `json:"sparse"`
+}
+
+type bufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride"`
+}
+
+type buffer struct {
+	URI        string `json:"uri"`
+	ByteLength int    `json:"byteLength"`
+}
+
+type skin struct {
+	Name                string `json:"name"`
+	InverseBindMatrices *int   `json:"inverseBindMatrices"`
+	Joints              []int  `json:"joints"`
+}
+
+type animation struct {
+	Name     string `json:"name"`
+	Channels []struct {
+		Sampler int `json:"sampler"`
+		Target  struct {
+			Node *int   `json:"node"`
+			Path string `json:"path"`
+		} `json:"target"`
+	} `json:"channels"`
+	Samplers []struct {
+		Input         int    `json:"input"`
+		Output        int    `json:"output"`
+		Interpolation string `json:"interpolation"`
+	} `json:"samplers"`
+}
+
+// Component types of accessors.
+const (
+	typeByte          = 5120
+	typeUnsignedByte  = 5121
+	typeShort         = 5122
+	typeUnsignedShort = 5123
+	typeUnsignedInt   = 5125
+	typeFloat         = 5126
+)
+
+// Magic numbers of the binary format.
+const (
+	glbMagic     = 0x46546C67
+	glbChunkJSON = 0x4E4F534A
+	glbChunkBIN  = 0x004E4942
+)
+
+// File is a loaded glTF file with all of its buffers.
+type File struct {
+	doc     document
+	buffers [][]byte
+	parents []int
+}
+
+// Load reads a .gltf or .glb file, and the buffers it refers to.
+func Load(file string) (*File, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File{}
+	var bin []byte
+
+	if len(data) >= 12 && binary.LittleEndian.Uint32(data) == glbMagic {
+		var js []byte
+		js, bin, err = splitGLB(data)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", file, err)
+		}
+		data = js
+	}
+
+	if err := json.Unmarshal(data, &f.doc); err != nil {
+		return nil, fmt.Errorf("%v: %v", file, err)
+	}
+
+	// Load the buffers, they are either embedded, in the binary chunk or next to the file.
+	for i, b := range f.doc.Buffers {
+		var buf []byte
+		switch {
+		case b.URI == "":
+			if bin == nil {
+				return nil, fmt.Errorf("%v: buffer %v has no uri and there is no binary chunk", file, i)
+			}
+			buf = bin
+		case strings.HasPrefix(b.URI, "data:"):
+			comma := strings.Index(b.URI, ",")
+			if comma < 0 || !strings.HasSuffix(b.URI[:comma], ";base64") {
+				return nil, fmt.Errorf("%v: buffer %v has an unsupported data uri", file, i)
+			}
+			buf, err = base64.StdEncoding.DecodeString(b.URI[comma+1:])
+			if err != nil {
+				return nil, fmt.Errorf("%v: buffer %v: %v", file, i, err)
+			}
+		default:
+			path, err := url.PathUnescape(b.URI)
+			if err != nil {
+				return nil, fmt.Errorf("%v: buffer %v: %v", file, i, err)
+			}
+			buf, err = ioutil.ReadFile(filepath.Join(filepath.Dir(file), path))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if len(buf) < b.ByteLength {
+			return nil, fmt.Errorf("%v: buffer %v is %v bytes, expected %v", file, i, len(buf), b.ByteLength)
+		}
+		f.buffers = append(f.buffers, buf)
+	}
+
+	// Remember the parent of every node, the file only stores children.
+	f.parents = make([]int, len(f.doc.Nodes))
+	for i := range f.parents {
+		f.parents[i] = -1
+	}
+	for i, n := range f.doc.Nodes {
+		for _, c := range n.Children {
+			if c < 0 || c >= len(f.doc.Nodes) {
+				return nil, fmt.Errorf("%v: node %v has child %v which doesn't exist", file, i, c)
+			}
+			f.parents[c] = i
+		}
+	}
+
+	// A node can't be its own ancestor, globalMatrix would never stop.
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(f.doc.Nodes))
+
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("%v: node %v is its own ancestor", file, i)
+		}
+
+		state[i] = visiting
+		if p := f.parents[i]; p >= 0 {
+			if err := visit(p); err != nil {
+				return err
+			}
+		}
+		state[i] = done
+
+		return nil
+	}
+
+	for i := range f.doc.Nodes {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// splitGLB returns the JSON and binary chunks of a .glb file.
+func splitGLB(data []byte) ([]byte, []byte, error) {
+	r := bytes.NewReader(data)
+	var header struct {
+		Magic, Version, Length uint32
+	}
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, nil, err
+	}
+	if header.Version != 2 {
+		return nil, nil, fmt.Errorf("glb version %v is unsupported", header.Version)
+	}
+	if int(header.Length) > len(data) {
+		return nil, nil, fmt.Errorf("glb is %v bytes, but the header says %v", len(data), header.Length)
+	}
+
+	var js, bin []byte
+	offset := 12
+	for offset+8 <= int(header.Length) {
+		length := int(binary.LittleEndian.Uint32(data[offset:]))
+		kind := binary.LittleEndian.Uint32(data[offset+4:])
+		offset += 8
+		if offset+length > int(header.Length) {
+			return nil, nil, fmt.Errorf("glb chunk runs past the end of the file")
+		}
+
+		switch kind {
+		case glbChunkJSON:
+			js = data[offset : offset+length]
+		case glbChunkBIN:
+			bin = data[offset : offset+length]
+		}
+		offset += length
+	}
+
+	if js == nil {
+		return nil, nil, fmt.Errorf("glb has no JSON chunk")
+	}
+
+	return js, bin, nil
+}
+
+// components returns the amount of components of an accessor type.
+func components(t string) int {
+	switch t {
+	case "SCALAR":
+		return 1
+	case "VEC2":
+		return 2
+	case "VEC3":
+		return 3
+	case "VEC4", "MAT2":
+		return 4
+	case "MAT3":
+		return 9
+	case "MAT4":
+		return 16
+	}
+	return 0
+}
+
+// componentSize returns the size in bytes of a component type.
+func componentSize(t int) int {
+	switch t {
+	case typeByte, typeUnsignedByte:
+		return 1
+	case typeShort, typeUnsignedShort:
+		return 2
+	case typeUnsignedInt, typeFloat:
+		return 4
+	}
+	return 0
+}
+
+// read calls fn for every component in the accessor, with its raw integer or float value.
+func (f *File) read(index int, fn func(i int, u uint32, v float32)) (int, error) {
+	if index < 0 || index >= len(f.doc.Accessors) {
+		return 0, fmt.Errorf("accessor %v doesn't exist", index)
+	}
+	a := f.doc.Accessors[index]
+	if a.Sparse != nil {
+		return 0, fmt.Errorf("accessor %v is sparse, this is unsupported", index)
+	}
+
+	n := components(a.Type)
+	size := componentSize(a.ComponentType)
+	if n == 0 || size == 0 {
+		return 0, fmt.Errorf("accessor %v has an unknown type", index)
+	}
+
+	// Accessors without a buffer view are all zeros.
+	if a.BufferView == nil {
+		for i := 0; i < a.Count*n; i++ {
+			fn(i, 0, 0.0)
+		}
+		return n, nil
+	}
+
+	if *a.BufferView < 0 || *a.BufferView >= len(f.doc.BufferViews) {
+		return 0, fmt.Errorf("accessor %v uses buffer view %v, which doesn't exist", index, *a.BufferView)
+	}
+	view := f.doc.BufferViews[*a.BufferView]
+	if view.Buffer < 0 || view.Buffer >= len(f.buffers) {
+		return 0, fmt.Errorf("buffer view %v uses buffer %v, which doesn't exist", *a.BufferView, view.Buffer)
+	}
+
+	stride := view.ByteStride
+	if stride == 0 {
+		stride = n * size
+	}
+	start := view.ByteOffset + a.ByteOffset
+	end := start + (a.Count-1)*stride + n*size
+	buf := f.buffers[view.Buffer]
+	if a.Count > 0 && (end > len(buf) || end > view.ByteOffset+view.ByteLength) {
+		return 0, fmt.Errorf("accessor %v runs past the end of its buffer", index)
+	}
+
+	for e := 0; e < a.Count; e++ {
+		for c := 0; c < n; c++ {
+			b := buf[start+e*stride+c*size:]
+			i := e*n + c
+
+			switch a.ComponentType {
+			case typeFloat:
+				fn(i, 0, math.Float32frombits(binary.LittleEndian.Uint32(b)))
+			case typeUnsignedInt:
+				u := binary.LittleEndian.Uint32(b)
+				fn(i, u, float32(u))
+			case typeUnsignedShort:
+				u := binary.LittleEndian.Uint16(b)
+				v := float32(u)
+				if a.Normalized {
+					v /= 65535.0
+				}
+				fn(i, uint32(u), v)
+			case typeShort:
+				s := int16(binary.LittleEndian.Uint16(b))
+				v := float32(s)
+				if a.Normalized {
+					v = float32(math.Max(float64(v)/32767.0, -1.0))
+				}
+				fn(i, uint32(s), v)
+			case typeUnsignedByte:
+				v := float32(b[0])
+				if a.Normalized {
+					v /= 255.0
+				}
+				fn(i, uint32(b[0]), v)
+			case typeByte:
+				s := int8(b[0])
+				v := float32(s)
+				if a.Normalized {
+					v = float32(math.Max(float64(v)/127.0, -1.0))
+				}
+				fn(i, uint32(s), v)
+			}
+		}
+	}
+
+	return n, nil
+}
+
+// readFloats returns all components of the accessor as floats, and the amount of components per element.
+func (f *File) readFloats(index int) ([]float32, int, error) {
+	var out []float32
+	n, err := f.read(index, func(i int, u uint32, v float32) {
+		out = append(out, v)
+	})
+
+	return out, n, err
+}
+
+// readUints returns all components of the accessor as integers, and the amount of components per element.
+func (f *File) readUints(index int) ([]uint32, int, error) {
+	var out []uint32
+	n, err := f.read(index, func(i int, u uint32, v float32) {
+		out = append(out, u)
+	})
+
+	return out, n, err
+}
+
+// localMatrix returns the transform of a node relative to its parent.
+func (n *node) localMatrix() mgl32.Mat4 {
+	if n.Matrix != nil {
+		return mgl32.Mat4(*n.Matrix)
+	}
+
+	return n.transform().Mat4()
+}
+
+// globalMatrix returns the transform of a node relative to the scene.
+func (f *File) globalMatrix(i int) mgl32.Mat4 {
+	m := mgl32.Ident4()
+	for ; i >= 0; i = f.parents[i] {
+		m = f.doc.Nodes[i].localMatrix().Mul4(m)
+	}
+
+	return m
+}
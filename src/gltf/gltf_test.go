@@ -0,0 +1,98 @@
+package gltf
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// load writes the JSON to a file and loads it.
+func load(t *testing.T, json string) (*File, error) {
+	dir, err := ioutil.TempDir("", "gltf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "test.gltf")
+	if err := ioutil.WriteFile(file, []byte(json), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return Load(file)
+}
+
+func TestNodeCycle(t *testing.T) {
+	for _, json := range []string{
+		`{"nodes": [{"children": [0]}]}`,
+		`{"nodes": [{"children": [1]}, {"children": [2]}, {"children": [0]}]}`,
+		`{"nodes": [{"children": [1]}, {"children": [2]}, {"children": [1]}]}`,
+	} {
+		_, err := load(t, json)
+		if err == nil || !strings.Contains(err.Error(), "its own ancestor") {
+			t.Errorf("%v: the error is %v", json, err)
+		}
+	}
+}
+
+func TestNodeHierarchy(t *testing.T) {
+	f, err := load(t, `{"nodes": [
+		{"children": [1], "translation": [1, 0, 0]},
+		{"children": [2], "translation": [0, 2, 0]},
+		{"translation": [0, 0, 3]}
+	]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []int{-1, 0, 1}; len(f.parents) != 3 || f.parents[0] != want[0] || f.parents[1] != want[1] ||
+		f.parents[2] != want[2] {
+		t.Errorf("the parents are %v, not %v", f.parents, want)
+	}
+	m := f.globalMatrix(2)
+	if m[12] != 1 || m[13] != 2 || m[14] != 3 {
+		t.Errorf("node 2 is at %v, %v, %v, not 1, 2, 3", m[12], m[13], m[14])
+	}
+}
+
+// skinned returns a file with a triangle that uses the given joints, and a node which uses skin 0 with 2 joints.
+func skinned(t *testing.T, joints [12]byte) (*File, error) {
+	return load(t, fmt.Sprintf(`{
+		"nodes": [{"mesh": 0, "skin": 0}, {}, {}],
+		"skins": [{"joints": [1, 2]}],
+		"meshes": [{"primitives": [{"attributes": {"POSITION": 0, "JOINTS_0": 1, "WEIGHTS_0": 2}}]}],
+		"accessors": [
+			{"componentType": 5126, "count": 3, "type": "VEC3"},
+			{"bufferView": 0, "componentType": 5121, "count": 3, "type": "VEC4"},
+			{"componentType": 5126, "count": 3, "type": "VEC4"}
+		],
+		"bufferViews": [{"buffer": 0, "byteLength": 12}],
+		"buffers": [{"uri": "data:application/octet-stream;base64,%v", "byteLength": 12}]
+	}`, base64.StdEncoding.EncodeToString(joints[:])))
+}
+
+func TestJointRange(t *testing.T) {
+	f, err := skinned(t, [12]byte{0, 1, 0, 0, 1, 1, 1, 1, 0, 0, 0, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := f.Mesh(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Skinned() || len(m.Joints) != 12 || m.Joints[1] != 1 {
+		t.Errorf("the joints are %v", m.Joints)
+	}
+
+	// The skin only has joints 0 and 1.
+	f, err = skinned(t, [12]byte{0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Mesh(0); err == nil || !strings.Contains(err.Error(), "joint 2 is out of range") {
+		t.Errorf("the error is %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package gltf
+
+import (
+	"fmt"
+	"math"
+
+	"GopherGL/src/mesh"
+)
+
+// MeshCount returns the amount of meshes in the file.
+func (f *File) MeshCount() int {
+	return len(f.doc.Meshes)
+}
+
+// MeshIndex returns the index of the mesh with the given name, or -1 if there is none.
+func (f *File) MeshIndex(name string) int {
+	for i, m := range f.doc.Meshes {
+		if m.Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Mesh returns mesh i, all of its triangle primitives are merged into one mesh.
+func (f *File) Mesh(i int) (*mesh.Mesh, error) {
+	if i < 0 || i >= len(f.doc.Meshes) {
+		return nil, fmt.Errorf("mesh %v doesn't exist", i)
+	}
+
+	m := &mesh.Mesh{}
+	joints := f.jointCount(i)
+	for p, prim := range f.doc.Meshes[i].Primitives {
+		// Only triangles are supported, which is the default mode.
+		if prim.Mode != nil && *prim.Mode != 4 {
+			continue
+		}
+		if err := f.appendPrimitive(m, prim, joints); err != nil {
+			return nil, fmt.Errorf("mesh %v primitive %v: %v", i, p, err)
+		}
+	}
+
+	return m, nil
+}
+
+// jointCount returns the least amount of joints of the skins the nodes with mesh i use, or -1 if none are skinned.
+func (f *File) jointCount(i int) int {
+	count := -1
+	for _, n := range f.doc.Nodes {
+		if n.Mesh == nil || *n.Mesh != i || n.Skin == nil || *n.Skin < 0 || *n.Skin >= len(f.doc.Skins) {
+			continue
+		}
+		if c := len(f.doc.Skins[*n.Skin].Joints); count < 0 || c < count {
+			count = c
+		}
+	}
+
+	return count
+}
+
+// appendPrimitive adds the vertices and indices of the primitive to the mesh. If joints isn't -1, the joint indices
+// have to be below it.
+func (f *File) appendPrimitive(m *mesh.Mesh, prim primitive, joints int) error {
+	posIndex, ok := prim.Attributes["POSITION"]
+	if !ok {
+		return fmt.Errorf("there are no positions")
+	}
+	pos, _, err := f.readFloats(posIndex)
+	if err != nil {
+		return err
+	}
+	count := len(pos) / 3
+
+	var uvs, normals []float32
+	if i, ok := prim.Attributes["TEXCOORD_0"]; ok {
+		if uvs, _, err = f.readFloats(i); err != nil {
+			return err
+		}
+	}
+	if i, ok := prim.Attributes["NORMAL"]; ok {
+		if normals, _, err = f.readFloats(i); err != nil {
+			return err
+		}
+	}
+	if len(uvs) != 0 && len(uvs) != count*2 || len(normals) != 0 && len(normals) != count*3 {
+		return fmt.Errorf("the attributes don't have the same amount of vertices")
+	}
+
+	base := uint32(m.VertexCount())
+	for v := 0; v < count; v++ {
+		var uv [2]float32
+		var n [3]float32
+		if len(uvs) != 0 {
+			// glTF has 0, 0 in the top left, OpenGL in the bottom left.
+			uv = [2]float32{uvs[v*2], 1.0 - uvs[v*2+1]}
+		}
+		if len(normals) != 0 {
+			copy(n[:], normals[v*3:])
+		}
+
+		m.Vertices = append(m.Vertices, pos[v*3], pos[v*3+1], pos[v*3+2], uv[0], uv[1], n[0], n[1], n[2])
+	}
+
+	// Joints and weights are only kept if every primitive has them.
+	ji, hasJoints := prim.Attributes["JOINTS_0"]
+	wi, hasWeights := prim.Attributes["WEIGHTS_0"]
+	if hasJoints && hasWeights && len(m.Joints) == int(base)*4 {
+		indices, _, err := f.readUints(ji)
+		if err != nil {
+			return err
+		}
+		weights, _, err := f.readFloats(wi)
+		if err != nil {
+			return err
+		}
+		if len(indices) != count*4 || len(weights) != count*4 {
+			return fmt.Errorf("joints and weights need 4 values per vertex")
+		}
+
+		for _, j := range indices {
+			if joints >= 0 && int(j) >= joints || j > math.MaxUint16 {
+				return fmt.Errorf("joint %v is out of range", j)
+			}
+			m.Joints = append(m.Joints, uint16(j))
+		}
+		m.Weights = append(m.Weights, weights...)
+	} else {
+		m.Joints, m.Weights = nil, nil
+	}
+
+	if prim.Indices == nil {
+		for v := 0; v < count; v++ {
+			m.Indices = append(m.Indices, base+uint32(v))
+		}
+		return nil
+	}
+
+	indices, _, err := f.readUints(*prim.Indices)
+	if err != nil {
+		return err
+	}
+	for _, idx := range indices {
+		if int(idx) >= count {
+			return fmt.Errorf("index %v is out of range", idx)
+		}
+		m.Indices = append(m.Indices, base+idx)
+	}
+
+	return nil
+}
@@ -0,0 +1,201 @@
+package gltf
+
+import (
+	"fmt"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"GopherGL/src/skeleton"
+)
+
+// SkinCount returns the amount of skins in the file.
+func (f *File) SkinCount() int {
+	return len(f.doc.Skins)
+}
+
+// jointIndices maps the nodes of skin i to their index in the skeleton.
+func (f *File) jointIndices(i int) map[int]int {
+	joints := make(map[int]int)
+	for j, n := range f.doc.Skins[i].Joints {
+		joints[n] = j
+	}
+
+	return joints
+}
+
+// Skin returns skin i as a skeleton.
+func (f *File) Skin(i int) (*skeleton.Skeleton, error) {
+	if i < 0 || i >= len(f.doc.Skins) {
+		return nil, fmt.Errorf("skin %v doesn't exist", i)
+	}
+	s := f.doc.Skins[i]
+	indices := f.jointIndices(i)
+
+	var inverseBinds []float32
+	if s.InverseBindMatrices != nil {
+		var err error
+		inverseBinds, _, err = f.readFloats(*s.InverseBindMatrices)
+		if err != nil {
+			return nil, fmt.Errorf("skin %v: %v", i, err)
+		}
+		if len(inverseBinds) != len(s.Joints)*16 {
+			return nil, fmt.Errorf("skin %v has %v joints, but a different amount of inverse bind matrices", i, len(s.Joints))
+		}
+	}
+
+	root := mgl32.Ident4()
+	joints := make([]skeleton.Joint, len(s.Joints))
+	for j, n := range s.Joints {
+		if n < 0 || n >= len(f.doc.Nodes) {
+			return nil, fmt.Errorf("skin %v uses node %v, which doesn't exist", i, n)
+		}
+		nd := &f.doc.Nodes[n]
+
+		joints[j] = skeleton.Joint{
+			Name:        nd.Name,
+			Parent:      -1,
+			Rest:        nd.transform(),
+			InverseBind: mgl32.Ident4(),
+		}
+		if inverseBinds != nil {
+			copy(joints[j].InverseBind[:], inverseBinds[j*16:])
+		}
+
+		if p, ok := indices[f.parents[n]]; ok {
+			joints[j].Parent = p
+		} else if f.parents[n] >= 0 {
+			// The nodes above the skeleton still move it.
+			root = f.globalMatrix(f.parents[n])
+		}
+	}
+
+	sk, err := skeleton.CreateSkeleton(joints)
+	if err != nil {
+		return nil, fmt.Errorf("skin %v: %v", i, err)
+	}
+	sk.Root = root
+
+	return sk, nil
+}
+
+// transform returns the local transform of the node.
+func (n *node) transform() skeleton.Transform {
+	t := skeleton.IdentTransform()
+	if n.Matrix != nil {
+		m := mgl32.Mat4(*n.Matrix)
+		t.Translation = m.Col(3).Vec3()
+		t.Scale = mgl32.Vec3{m.Col(0).Vec3().Len(), m.Col(1).Vec3().Len(), m.Col(2).Vec3().Len()}
+
+		// Take the scale out, so only the rotation is left.
+		for c := 0; c < 3; c++ {
+			for r := 0; r < 3; r++ {
+				m[c*4+r] /= t.Scale[c]
+			}
+		}
+		t.Rotation = mgl32.Mat4ToQuat(m)
+		return t
+	}
+
+	if n.Translation != nil {
+		t.Translation = mgl32.Vec3(*n.Translation)
+	}
+	if n.Rotation != nil {
+		t.Rotation = mgl32.Quat{W: n.Rotation[3], V: mgl32.Vec3{n.Rotation[0], n.Rotation[1], n.Rotation[2]}}
+	}
+	if n.Scale != nil {
+		t.Scale = mgl32.Vec3(*n.Scale)
+	}
+
+	return t
+}
+
+// Clips returns every animation in the file as a clip for skin i. Channels of other nodes are left out.
+func (f *File) Clips(i int) ([]*skeleton.Clip, error) {
+	if i < 0 || i >= len(f.doc.Skins) {
+		return nil, fmt.Errorf("skin %v doesn't exist", i)
+	}
+	indices := f.jointIndices(i)
+
+	var clips []*skeleton.Clip
+	for a, anim := range f.doc.Animations {
+		clip := &skeleton.Clip{Name: anim.Name}
+
+		for c, ch := range anim.Channels {
+			if ch.Target.Node == nil {
+				continue
+			}
+			joint, ok := indices[*ch.Target.Node]
+			if !ok {
+				continue
+			}
+
+			var path skeleton.Path
+			switch ch.Target.Path {
+			case "translation":
+				path = skeleton.PathTranslation
+			case "rotation":
+				path = skeleton.PathRotation
+			case "scale":
+				path = skeleton.PathScale
+			default:
+				// Morph target weights aren't supported.
+				continue
+			}
+
+			if ch.Sampler < 0 || ch.Sampler >= len(anim.Samplers) {
+				return nil, fmt.Errorf("animation %v channel %v uses sampler %v, which doesn't exist", a, c, ch.Sampler)
+			}
+			smp := anim.Samplers[ch.Sampler]
+
+			var interp skeleton.Interpolation
+			switch smp.Interpolation {
+			case "", "LINEAR":
+				interp = skeleton.InterpLinear
+			case "STEP":
+				interp = skeleton.InterpStep
+			case "CUBICSPLINE":
+				interp = skeleton.InterpCubicSpline
+			default:
+				return nil, fmt.Errorf("animation %v uses unknown interpolation %v", a, smp.Interpolation)
+			}
+
+			times, _, err := f.readFloats(smp.Input)
+			if err != nil {
+				return nil, fmt.Errorf("animation %v: %v", a, err)
+			}
+			values, _, err := f.readFloats(smp.Output)
+			if err != nil {
+				return nil, fmt.Errorf("animation %v: %v", a, err)
+			}
+
+			width := 3
+			if path == skeleton.PathRotation {
+				width = 4
+			}
+			keys := 1
+			if interp == skeleton.InterpCubicSpline {
+				keys = 3
+			}
+			if len(values) != len(times)*width*keys {
+				return nil, fmt.Errorf("animation %v channel %v has %v times but %v values", a, c, len(times), len(values))
+			}
+
+			if len(times) > 0 && times[len(times)-1] > clip.Duration {
+				clip.Duration = times[len(times)-1]
+			}
+			clip.Channels = append(clip.Channels, skeleton.Channel{
+				Joint:  joint,
+				Path:   path,
+				Interp: interp,
+				Times:  times,
+				Values: values,
+			})
+		}
+
+		if len(clip.Channels) > 0 {
+			clips = append(clips, clip)
+		}
+	}
+
+	return clips, nil
+}
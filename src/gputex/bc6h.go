@@ -0,0 +1,228 @@
+package gputex
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// bc6hMode is a mode of BC6H. The layout is written like in the format specification: r0 to r3 are the red
+// channel of the 4 endpoints, [9:0] are bits 0 to 9 in that order and [10:15] bits 15 down to 10.
+type bc6hMode struct {
+	layout string
+	// precision is the bits of the first endpoint, deltas the bits of the others, per channel.
+	precision int
+	deltas    [3]int
+	// transformed modes store the other endpoints as deltas to the first one.
+	transformed bool
+	regions     int
+
+	fields []bc6hField
+}
+
+// bc6hField is a bit of an endpoint.
+type bc6hField struct {
+	endpoint, channel, bit int
+}
+
+// bc6hModes are the modes by their mode bits, the 2 bit modes with 0 in the higher bits.
+var bc6hModes = map[int]*bc6hMode{
+	0x00: {"g2[4] b2[4] b3[4] r0[9:0] g0[9:0] b0[9:0] r1[4:0] g3[4] g2[3:0] g1[4:0] b3[0] g3[3:0] b1[4:0] b3[1] " +
+		"b2[3:0] r2[4:0] b3[2] r3[4:0] b3[3]", 10, [3]int{5, 5, 5}, true, 2, nil},
+	0x01: {"g2[5] g3[4] g3[5] r0[6:0] b3[0] b3[1] b2[4] g0[6:0] b2[5] b3[2] g2[4] b0[6:0] b3[3] b3[5] b3[4] " +
+		"r1[5:0] g2[3:0] g1[5:0] g3[3:0] b1[5:0] b2[3:0] r2[5:0] r3[5:0]", 7, [3]int{6, 6, 6}, true, 2, nil},
+	0x02: {"r0[9:0] g0[9:0] b0[9:0] r1[4:0] r0[10] g2[3:0] g1[3:0] g0[10] b3[0] g3[3:0] b1[3:0] b0[10] b3[1] " +
+		"b2[3:0] r2[4:0] b3[2] r3[4:0] b3[3]", 11, [3]int{5, 4, 4}, true, 2, nil},
+	0x06: {"r0[9:0] g0[9:0] b0[9:0] r1[3:0] r0[10] g3[4] g2[3:0] g1[4:0] g0[10] g3[3:0] b1[3:0] b0[10] b3[1] " +
+		"b2[3:0] r2[3:0] b3[0] b3[2] r3[3:0] g2[4] b3[3]", 11, [3]int{4, 5, 4}, true, 2, nil},
+	0x0a: {"r0[9:0] g0[9:0] b0[9:0] r1[3:0] r0[10] b2[4] g2[3:0] g1[3:0] g0[10] b3[0] g3[3:0] b1[4:0] b0[10] " +
+		"b2[3:0] r2[3:0] b3[1] b3[2] r3[3:0] b3[4] b3[3]", 11, [3]int{4, 4, 5}, true, 2, nil},
+	0x0e: {"r0[8:0] b2[4] g0[8:0] g2[4] b0[8:0] b3[4] r1[4:0] g3[4] g2[3:0] g1[4:0] b3[0] g3[3:0] b1[4:0] b3[1] " +
+		"b2[3:0] r2[4:0] b3[2] r3[4:0] b3[3]", 9, [3]int{5, 5, 5}, true, 2, nil},
+	0x12: {"r0[7:0] g3[4] b2[4] g0[7:0] b3[2] g2[4] b0[7:0] b3[3] b3[4] r1[5:0] g2[3:0] g1[4:0] b3[0] g3[3:0] " +
+		"b1[4:0] b3[1] b2[3:0] r2[5:0] r3[5:0]", 8, [3]int{6, 5, 5}, true, 2, nil},
+	0x16: {"r0[7:0] b3[0] b2[4] g0[7:0] g2[5] g2[4] b0[7:0] g3[5] b3[4] r1[4:0] g3[4] g2[3:0] g1[5:0] g3[3:0] " +
+		"b1[4:0] b3[1] b2[3:0] r2[4:0] b3[2] r3[4:0] b3[3]", 8, [3]int{5, 6, 5}, true, 2, nil},
+	0x1a: {"r0[7:0] b3[1] b2[4] g0[7:0] b2[5] g2[4] b0[7:0] b3[5] b3[4] r1[4:0] g3[4] g2[3:0] g1[4:0] b3[0] " +
+		"g3[3:0] b1[5:0] b2[3:0] r2[4:0] b3[2] r3[4:0] b3[3]", 8, [3]int{5, 5, 6}, true, 2, nil},
+	0x1e: {"r0[5:0] g3[4] b3[0] b3[1] b2[4] g0[5:0] g2[5] b2[5] b3[2] g2[4] b0[5:0] g3[5] b3[3] b3[5] b3[4] " +
+		"r1[5:0] g2[3:0] g1[5:0] g3[3:0] b1[5:0] b2[3:0] r2[5:0] r3[5:0]", 6, [3]int{6, 6, 6}, false, 2, nil},
+	0x03: {"r0[9:0] g0[9:0] b0[9:0] r1[9:0] g1[9:0] b1[9:0]", 10, [3]int{10, 10, 10}, false, 1, nil},
+	0x07: {"r0[9:0] g0[9:0] b0[9:0] r1[8:0] r0[10] g1[8:0] g0[10] b1[8:0] b0[10]", 11, [3]int{9, 9, 9}, true, 1,
+		nil},
+	0x0b: {"r0[9:0] g0[9:0] b0[9:0] r1[7:0] r0[10:11] g1[7:0] g0[10:11] b1[7:0] b0[10:11]", 12, [3]int{8, 8, 8},
+		true, 1, nil},
+	0x0f: {"r0[9:0] g0[9:0] b0[9:0] r1[3:0] r0[10:15] g1[3:0] g0[10:15] b1[3:0] b0[10:15]", 16, [3]int{4, 4, 4},
+		true, 1, nil},
+}
+
+// bc6hBits matches a part of a layout, like "g2[3:0]" or "b3[4]".
+var bc6hBits = regexp.MustCompile(`^([rgb])([0-3])\[(\d+)(?::(\d+))?\]$`)
+
+func init() {
+	for _, m := range bc6hModes {
+		for _, part := range strings.Fields(m.layout) {
+			s := bc6hBits.FindStringSubmatch(part)
+			if s == nil {
+				panic("gputex: broken BC6H layout " + part)
+			}
+			channel := strings.Index("rgb", s[1])
+			endpoint, _ := strconv.Atoi(s[2])
+			from, _ := strconv.Atoi(s[3])
+			to := from
+			if s[4] != "" {
+				to, _ = strconv.Atoi(s[4])
+			}
+
+			// The bit on the right comes first.
+			step := 1
+			if to > from {
+				step = -1
+			}
+			for bit := to; ; bit += step {
+				m.fields = append(m.fields, bc6hField{endpoint, channel, bit})
+				if bit == from {
+					break
+				}
+			}
+		}
+	}
+}
+
+// signExtend makes the highest of the bits the sign.
+func signExtend(v, bits int) int {
+	if v&(1<<uint(bits-1)) != 0 {
+		return v - 1<<uint(bits)
+	}
+	return v
+}
+
+// unquantize scales an endpoint to 16 bits, or 15 bits and a sign.
+func unquantize(v, bits int, signed bool) int {
+	if !signed {
+		switch {
+		case bits >= 15:
+			return v
+		case v == 0:
+			return 0
+		case v == 1<<uint(bits)-1:
+			return 0xffff
+		}
+		return (v<<16 + 0x8000) >> uint(bits)
+	}
+
+	if bits >= 16 {
+		return v
+	}
+	negative := v < 0
+	if negative {
+		v = -v
+	}
+	switch {
+	case v == 0:
+	case v >= 1<<uint(bits-1)-1:
+		v = 0x7fff
+	default:
+		v = (v<<15 + 0x4000) >> uint(bits-1)
+	}
+	if negative {
+		return -v
+	}
+	return v
+}
+
+// half returns the float of an interpolated value, scaled to the range of a half float.
+func half(v int, signed bool) float32 {
+	var bits uint16
+	switch {
+	case !signed:
+		bits = uint16(v * 31 >> 6)
+	case v < 0:
+		bits = 0x8000 | uint16(-v*31>>5)
+	default:
+		bits = uint16(v * 31 >> 5)
+	}
+
+	sign := uint32(bits>>15) << 31
+	exp, mant := uint32(bits>>10&0x1f), uint32(bits&0x3ff)
+	switch {
+	case exp == 0 && mant == 0:
+		return math.Float32frombits(sign)
+	case exp == 0:
+		// Denormal halfs are normal floats.
+		f := float32(mant) / (1 << 24)
+		if sign != 0 {
+			f = -f
+		}
+		return f
+	case exp == 0x1f:
+		return math.Float32frombits(sign | 0xff<<23 | mant<<13)
+	}
+	return math.Float32frombits(sign | (exp+112)<<23 | mant<<13)
+}
+
+// decodeBC6H decodes a BC6H block. Blocks with a reserved mode are black.
+func decodeBC6H(b []byte, block *[16][3]float32, signed bool) {
+	r := &bitReader{b: b}
+	code := r.read(2)
+	if code > 1 {
+		code |= r.read(3) << 2
+	}
+	m, ok := bc6hModes[code]
+	if !ok {
+		*block = [16][3]float32{}
+		return
+	}
+
+	var e [4][3]int
+	for _, f := range m.fields {
+		e[f.endpoint][f.channel] |= r.read(1) << uint(f.bit)
+	}
+	partition := 0
+	if m.regions == 2 {
+		partition = r.read(5)
+	}
+
+	n := m.regions * 2
+	for ch := 0; ch < 3; ch++ {
+		if signed {
+			e[0][ch] = signExtend(e[0][ch], m.precision)
+		}
+		for i := 1; i < n; i++ {
+			if m.transformed || signed {
+				e[i][ch] = signExtend(e[i][ch], m.deltas[ch])
+			}
+			if m.transformed {
+				e[i][ch] = (e[0][ch] + e[i][ch]) & (1<<uint(m.precision) - 1)
+				if signed {
+					e[i][ch] = signExtend(e[i][ch], m.precision)
+				}
+			}
+		}
+		for i := 0; i < n; i++ {
+			e[i][ch] = unquantize(e[i][ch], m.precision, signed)
+		}
+	}
+
+	bits := 4
+	if m.regions == 2 {
+		bits = 3
+	}
+	for i := range block {
+		s, n := 0, bits
+		if m.regions == 2 {
+			s = int(partitions2[partition] >> uint(i) & 1)
+			if i == anchors2[partition] {
+				n--
+			}
+		}
+		if i == 0 {
+			n--
+		}
+		w := bcWeights[bits][r.read(uint(n))]
+		for ch := 0; ch < 3; ch++ {
+			block[i][ch] = half(interpolate(e[2*s][ch], e[2*s+1][ch], w), signed)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package gputex
+
+// bc7Modes are the layouts of the 8 modes of BC7.
+var bc7Modes = [8]struct {
+	subsets, partitionBits, rotationBits, indexSelectionBits int
+	colorBits, alphaBits, endpointPBits, sharedPBits         int
+	indexBits, indexBits2                                    int
+}{
+	{3, 4, 0, 0, 4, 0, 1, 0, 3, 0},
+	{2, 6, 0, 0, 6, 0, 0, 1, 3, 0},
+	{3, 6, 0, 0, 5, 0, 0, 0, 2, 0},
+	{2, 6, 0, 0, 7, 0, 1, 0, 2, 0},
+	{1, 0, 2, 1, 5, 6, 0, 0, 2, 3},
+	{1, 0, 2, 0, 7, 8, 0, 0, 2, 2},
+	{1, 0, 0, 0, 7, 7, 1, 0, 4, 0},
+	{2, 6, 0, 0, 5, 5, 1, 0, 2, 0},
+}
+
+// bcWeights are the interpolation weights of 2, 3 and 4 bit indices, out of 64. BC6H uses them too.
+var bcWeights = [5][]int{
+	2: {0, 21, 43, 64},
+	3: {0, 9, 18, 27, 37, 46, 55, 64},
+	4: {0, 4, 9, 13, 17, 21, 26, 30, 34, 38, 43, 47, 51, 55, 60, 64},
+}
+
+// partitions2 are the partitions of 2 subsets, a set bit is a pixel of the second subset. BC6H uses the first 32.
+var partitions2 = [64]uint16{
+	0xcccc, 0x8888, 0xeeee, 0xecc8, 0xc880, 0xfeec, 0xfec8, 0xec80,
+	0xc800, 0xffec, 0xfe80, 0xe800, 0xffe8, 0xff00, 0xfff0, 0xf000,
+	0xf710, 0x008e, 0x7100, 0x08ce, 0x008c, 0x7310, 0x3100, 0x8cce,
+	0x088c, 0x3110, 0x6666, 0x366c, 0x17e8, 0x0ff0, 0x718e, 0x399c,
+	0xaaaa, 0xf0f0, 0x5a5a, 0x33cc, 0x3c3c, 0x55aa, 0x9696, 0xa55a,
+	0x73ce, 0x13c8, 0x324c, 0x3bdc, 0x6996, 0xc33c, 0x9966, 0x0660,
+	0x0272, 0x04e4, 0x4e40, 0x2720, 0xc936, 0x936c, 0x39c6, 0x639c,
+	0x9336, 0x9cc6, 0x817e, 0xe718, 0xccf0, 0x0fcc, 0x7744, 0xee22,
+}
+
+// anchors2 are the pixels of the second subset that have one index bit less.
+var anchors2 = [64]int{
+	15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15,
+	15, 2, 8, 2, 2, 8, 8, 15, 2, 8, 2, 2, 8, 8, 2, 2,
+	15, 15, 6, 8, 2, 8, 15, 15, 2, 8, 2, 2, 2, 15, 15, 6,
+	6, 2, 6, 8, 15, 15, 2, 2, 15, 15, 15, 15, 15, 2, 2, 15,
+}
+
+// partitions3 are the partitions of 3 subsets, the subset of every pixel.
+var partitions3 = [64]string{
+	"0011001102212222", "0001001122112221", "0000200122112211", "0222002200110111",
+	"0000000011221122", "0011001100220022", "0022002211111111", "0011001122112211",
+	"0000000011112222", "0000111111112222", "0000111122222222", "0012001200120012",
+	"0112011201120112", "0122012201220122", "0011011211221222", "0011200122002220",
+	"0001001101121122", "0111001120012200", "0000112211221122", "0022002200221111",
+	"0111011102220222", "0001000122212221", "0000001101220122", "0000110022102210",
+	"0122012200110000", "0012001211222222", "0110122112210110", "0000011012211221",
+	"0022110211020022", "0110011020022222", "0011012201220011", "0000200022112221",
+	"0000000211221222", "0222002200120011", "0011001200220222", "0120012001200120",
+	"0000111122220000", "0120120120120120", "0120201212010120", "0011220011220011",
+	"0011112222000011", "0101010122222222", "0000000021212121", "0022112200221122",
+	"0022001100220011", "0220122102201221", "0101222222220101", "0000212121212121",
+	"0101010101012222", "0222011102220111", "0002111200021112", "0000211221122112",
+	"0222011101110222", "0002111211120002", "0110011001102222", "0000000021122112",
+	"0110011022222222", "0022001100110022", "0022112211220022", "0000000000002112",
+	"0002000100020001", "0222122202221222", "0101222222222222", "0111201122012220",
+}
+
+// anchors3 are the pixels of the second and the third subset that have one index bit less.
+var anchors3 = [2][64]int{
+	{
+		3, 3, 15, 15, 8, 3, 15, 15, 8, 8, 6, 6, 6, 5, 3, 3,
+		3, 3, 8, 15, 3, 3, 6, 10, 5, 8, 8, 6, 8, 5, 15, 15,
+		8, 15, 3, 5, 6, 10, 8, 15, 15, 3, 15, 5, 15, 15, 15, 15,
+		3, 15, 5, 5, 5, 8, 5, 10, 5, 10, 8, 13, 15, 12, 3, 3,
+	},
+	{
+		15, 8, 8, 3, 15, 15, 3, 8, 15, 15, 15, 15, 15, 15, 15, 8,
+		15, 8, 15, 3, 15, 8, 15, 8, 3, 15, 6, 10, 15, 15, 10, 8,
+		15, 3, 15, 10, 10, 8, 9, 10, 6, 15, 8, 15, 3, 6, 6, 8,
+		15, 3, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 3, 15, 15, 8,
+	},
+}
+
+// subset returns the subset of a pixel.
+func subset(subsets, partition, i int) int {
+	switch subsets {
+	case 2:
+		return int(partitions2[partition] >> uint(i) & 1)
+	case 3:
+		return int(partitions3[partition][i] - '0')
+	}
+	return 0
+}
+
+// anchor returns whether the index of the pixel has one bit less, because it's the first of its subset.
+func anchor(subsets, partition, i int) bool {
+	switch {
+	case i == 0:
+		return true
+	case subsets == 2:
+		return i == anchors2[partition]
+	case subsets == 3:
+		return i == anchors3[0][partition] || i == anchors3[1][partition]
+	}
+	return false
+}
+
+// interpolate blends two endpoints with a weight out of 64.
+func interpolate(e0, e1, weight int) int {
+	return ((64-weight)*e0 + weight*e1 + 32) >> 6
+}
+
+// decodeBC7 decodes a BC7 block. Blocks with a reserved mode are transparent black.
+func decodeBC7(b []byte, block *[16][4]byte) {
+	mode := 0
+	for mode < 8 && b[0]>>uint(mode)&1 == 0 {
+		mode++
+	}
+	if mode == 8 {
+		*block = [16][4]byte{}
+		return
+	}
+	m := bc7Modes[mode]
+	r := &bitReader{b: b, pos: uint(mode + 1)}
+
+	partition := r.read(uint(m.partitionBits))
+	rotation := r.read(uint(m.rotationBits))
+	indexSelection := r.read(uint(m.indexSelectionBits))
+
+	// The endpoints, 2 per subset, RGBA.
+	var endpoints [6][4]int
+	n := m.subsets * 2
+	for ch := 0; ch < 3; ch++ {
+		for e := 0; e < n; e++ {
+			endpoints[e][ch] = r.read(uint(m.colorBits))
+		}
+	}
+	for e := 0; e < n; e++ {
+		endpoints[e][3] = r.read(uint(m.alphaBits))
+	}
+
+	// P-bits are an extra lowest bit, of every endpoint or shared by the endpoints of a subset.
+	colorBits, alphaBits := m.colorBits, m.alphaBits
+	if m.endpointPBits > 0 || m.sharedPBits > 0 {
+		var pbits [6]int
+		for e := 0; e < n; e++ {
+			if m.endpointPBits > 0 {
+				pbits[e] = r.read(1)
+			} else if e%2 == 0 {
+				pbits[e] = r.read(1)
+				pbits[e+1] = pbits[e]
+			}
+		}
+		for e := 0; e < n; e++ {
+			for ch := 0; ch < 4; ch++ {
+				endpoints[e][ch] = endpoints[e][ch]<<1 | pbits[e]
+			}
+		}
+		colorBits++
+		if alphaBits > 0 {
+			alphaBits++
+		}
+	}
+
+	// Expand to 8 bits by repeating the highest bits.
+	for e := 0; e < n; e++ {
+		for ch := 0; ch < 4; ch++ {
+			bits := colorBits
+			if ch == 3 {
+				bits = alphaBits
+			}
+			if bits == 0 {
+				endpoints[e][ch] = 255
+				continue
+			}
+			v := endpoints[e][ch] << uint(8-bits)
+			endpoints[e][ch] = v | v>>uint(bits)
+		}
+	}
+
+	var indices, indices2 [16]int
+	for i := range indices {
+		bits := m.indexBits
+		if anchor(m.subsets, partition, i) {
+			bits--
+		}
+		indices[i] = r.read(uint(bits))
+	}
+	if m.indexBits2 > 0 {
+		for i := range indices2 {
+			bits := m.indexBits2
+			if i == 0 {
+				bits--
+			}
+			indices2[i] = r.read(uint(bits))
+		}
+	}
+
+	for i := range block {
+		s := subset(m.subsets, partition, i)
+		e0, e1 := endpoints[2*s], endpoints[2*s+1]
+
+		colorIndex, colorBits := indices[i], m.indexBits
+		alphaIndex, alphaBits := indices[i], m.indexBits
+		if m.indexBits2 > 0 {
+			alphaIndex, alphaBits = indices2[i], m.indexBits2
+			if indexSelection == 1 {
+				colorIndex, colorBits, alphaIndex, alphaBits = alphaIndex, alphaBits, colorIndex, colorBits
+			}
+		}
+
+		var p [4]byte
+		for ch := 0; ch < 3; ch++ {
+			p[ch] = byte(interpolate(e0[ch], e1[ch], bcWeights[colorBits][colorIndex]))
+		}
+		p[3] = byte(interpolate(e0[3], e1[3], bcWeights[alphaBits][alphaIndex]))
+		if rotation > 0 {
+			p[rotation-1], p[3] = p[3], p[rotation-1]
+		}
+		block[i] = p
+	}
+}
@@ -0,0 +1,146 @@
+package gputex
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+var ddsMagic = []byte("DDS ")
+
+// The flags of the DDS header that are used.
+const (
+	ddsMipmapCount  = 0x20000
+	ddsAlphaPixels  = 0x1
+	ddsFourCC       = 0x4
+	ddsRGB          = 0x40
+	ddsCubemap      = 0x200
+	ddsVolume       = 0x200000
+	ddsHeaderSize   = 124
+	ddsDX10Size     = 20
+	ddsTexture2D    = 3
+	ddsMiscCubemap  = 0x4
+	ddsPixelFmtSize = 32
+)
+
+// ddsFourCCs are the formats of files without the DX10 header.
+var ddsFourCCs = map[string]Format{
+	"DXT1": BC1,
+	"DXT2": BC2,
+	"DXT3": BC2,
+	"DXT4": BC3,
+	"DXT5": BC3,
+	"ATI1": BC4,
+	"BC4U": BC4,
+	"ATI2": BC5,
+	"BC5U": BC5,
+}
+
+// dxgiFormats are the formats of the DX10 header, and whether they're sRGB. B8G8R8A8 is swapped to RGBA8.
+var dxgiFormats = map[uint32]struct {
+	format Format
+	srgb   bool
+}{
+	28: {RGBA8, false},
+	29: {RGBA8, true},
+	71: {BC1A, false},
+	72: {BC1A, true},
+	74: {BC2, false},
+	75: {BC2, true},
+	77: {BC3, false},
+	78: {BC3, true},
+	80: {BC4, false},
+	83: {BC5, false},
+	87: {RGBA8, false},
+	91: {RGBA8, true},
+	95: {BC6H, false},
+	96: {BC6HSigned, false},
+	98: {BC7, false},
+	99: {BC7, true},
+}
+
+// readDDS reads a DDS file, with or without the DX10 header. Cubemaps, volumes and arrays aren't 2D textures.
+func readDDS(data []byte) (*Image, error) {
+	if len(data) < 4+ddsHeaderSize {
+		return nil, fmt.Errorf("the DDS header is cut off")
+	}
+	h := data[4 : 4+ddsHeaderSize]
+	u32 := func(b []byte, off int) uint32 { return binary.LittleEndian.Uint32(b[off:]) }
+	if u32(h, 0) != ddsHeaderSize || u32(h, 72) != ddsPixelFmtSize {
+		return nil, fmt.Errorf("the DDS header has the wrong size")
+	}
+
+	flags, height, width, mipmaps := u32(h, 4), u32(h, 8), u32(h, 12), u32(h, 24)
+	pfFlags, fourCC, bits := u32(h, 76), string(h[80:84]), u32(h, 84)
+	rMask, gMask, bMask, aMask := u32(h, 88), u32(h, 92), u32(h, 96), u32(h, 100)
+	if caps2 := u32(h, 108); caps2&(ddsCubemap|ddsVolume) != 0 {
+		return nil, fmt.Errorf("DDS cubemaps and volume textures aren't supported")
+	}
+
+	img := &Image{Width: int(width), Height: int(height)}
+	data = data[4+ddsHeaderSize:]
+	bgra := false
+
+	switch {
+	case pfFlags&ddsFourCC != 0 && fourCC == "DX10":
+		if len(data) < ddsDX10Size {
+			return nil, fmt.Errorf("the DX10 header is cut off")
+		}
+		dxgi, dim, misc, arraySize := u32(data, 0), u32(data, 4), u32(data, 8), u32(data, 12)
+		if dim != ddsTexture2D || misc&ddsMiscCubemap != 0 || arraySize > 1 {
+			return nil, fmt.Errorf("only 2D DDS textures are supported")
+		}
+		f, ok := dxgiFormats[dxgi]
+		if !ok {
+			return nil, fmt.Errorf("the DXGI format %v isn't supported", dxgi)
+		}
+		img.Format, img.SRGB = f.format, f.srgb
+		bgra = dxgi == 87 || dxgi == 91
+		data = data[ddsDX10Size:]
+	case pfFlags&ddsFourCC != 0:
+		f, ok := ddsFourCCs[fourCC]
+		if !ok {
+			return nil, fmt.Errorf("the DDS format %q isn't supported", fourCC)
+		}
+		// Old files only say whether DXT1 has alpha with this flag.
+		if f == BC1 && pfFlags&ddsAlphaPixels != 0 {
+			f = BC1A
+		}
+		img.Format = f
+	case pfFlags&ddsRGB != 0 && bits == 32 && gMask == 0xff00 && (aMask == 0xff000000 || aMask == 0):
+		if rMask == 0xff && bMask == 0xff0000 {
+			img.Format = RGBA8
+		} else if rMask == 0xff0000 && bMask == 0xff {
+			img.Format, bgra = RGBA8, true
+		} else {
+			return nil, fmt.Errorf("the DDS masks %x %x %x %x aren't supported", rMask, gMask, bMask, aMask)
+		}
+	default:
+		return nil, fmt.Errorf("the DDS pixel format isn't supported")
+	}
+
+	if err := img.check(); err != nil {
+		return nil, err
+	}
+	count := 1
+	if flags&ddsMipmapCount != 0 {
+		count = int(mipmaps)
+	}
+	if err := img.levels(data, count); err != nil {
+		return nil, err
+	}
+
+	// The data was read by Read, it can be changed.
+	for _, l := range img.Levels {
+		for p := 0; p < len(l) && img.Format == RGBA8; p += 4 {
+			if bgra {
+				l[p], l[p+2] = l[p+2], l[p]
+			}
+			// Without alpha the byte is undefined.
+			if pfFlags&ddsRGB != 0 && aMask == 0 {
+				l[p+3] = 255
+			}
+		}
+	}
+
+	return img, nil
+}
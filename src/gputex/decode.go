@@ -0,0 +1,181 @@
+package gputex
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Decode decompresses a mipmap of the size to RGBA8, 4 bytes per pixel, the rows in the same order as the
+// blocks. BC4 is decoded to red and BC5 to red and green, like the GPU samples them. RGBA8 is returned as it is.
+// BC6H has floats, see DecodeFloat. The colors between the endpoints of BC1 to BC5 are rounded, GPUs don't all
+// round the same way and can be 1 off.
+func Decode(f Format, width, height int, data []byte) ([]byte, error) {
+	if f.HDR() {
+		return nil, fmt.Errorf("%v has floats, it can't be decoded to bytes", f)
+	}
+	if len(data) != LevelSize(f, width, height) {
+		return nil, fmt.Errorf("%vx%v %v is %v bytes, not %v", width, height, f, LevelSize(f, width, height), len(data))
+	}
+	if f == RGBA8 {
+		return data, nil
+	}
+
+	pix := make([]byte, width*height*4)
+	var block [16][4]byte
+	eachBlock(f, width, height, data, func(b []byte, x, y int) {
+		switch f {
+		case BC1, BC1A:
+			decodeBC1(b, &block, f == BC1A, true)
+		case BC2:
+			decodeBC1(b[8:], &block, false, false)
+			alpha := binary.LittleEndian.Uint64(b)
+			for i := range block {
+				block[i][3] = byte(alpha>>(4*uint(i))&0xf) * 17
+			}
+		case BC3:
+			decodeBC1(b[8:], &block, false, false)
+			decodeBC4(b, &block, 3)
+		case BC4:
+			block = [16][4]byte{}
+			decodeBC4(b, &block, 0)
+			for i := range block {
+				block[i][3] = 255
+			}
+		case BC5:
+			block = [16][4]byte{}
+			decodeBC4(b, &block, 0)
+			decodeBC4(b[8:], &block, 1)
+			for i := range block {
+				block[i][3] = 255
+			}
+		case BC7:
+			decodeBC7(b, &block)
+		}
+		putBlock(pix, width, height, x, y, func(i int, p []byte) { copy(p, block[i][:]) }, 4)
+	})
+
+	return pix, nil
+}
+
+// DecodeFloat decompresses a BC6H mipmap to RGBA floats, alpha is always 1.
+func DecodeFloat(f Format, width, height int, data []byte) ([]float32, error) {
+	if !f.HDR() {
+		return nil, fmt.Errorf("%v doesn't have floats", f)
+	}
+	if len(data) != LevelSize(f, width, height) {
+		return nil, fmt.Errorf("%vx%v %v is %v bytes, not %v", width, height, f, LevelSize(f, width, height), len(data))
+	}
+
+	pix := make([]float32, width*height*4)
+	var block [16][3]float32
+	eachBlock(f, width, height, data, func(b []byte, x, y int) {
+		decodeBC6H(b, &block, f == BC6HSigned)
+		for i := range block {
+			px, py := x+i%4, y+i/4
+			if px >= width || py >= height {
+				continue
+			}
+			p := pix[(py*width+px)*4:]
+			p[0], p[1], p[2], p[3] = block[i][0], block[i][1], block[i][2], 1.0
+		}
+	})
+
+	return pix, nil
+}
+
+// eachBlock calls fn with every block and the pixel it starts at.
+func eachBlock(f Format, width, height int, data []byte, fn func(b []byte, x, y int)) {
+	size := f.blockBytes()
+	for y := 0; y < height; y += 4 {
+		for x := 0; x < width; x += 4 {
+			fn(data[:size], x, y)
+			data = data[size:]
+		}
+	}
+}
+
+// putBlock copies the pixels of a block, the ones outside the texture are dropped.
+func putBlock(pix []byte, width, height, x, y int, get func(i int, p []byte), bpp int) {
+	for i := 0; i < 16; i++ {
+		px, py := x+i%4, y+i/4
+		if px < width && py < height {
+			get(i, pix[(py*width+px)*bpp:])
+		}
+	}
+}
+
+// rgb565 expands a 16 bit color to 8 bits per channel.
+func rgb565(c uint16) [4]byte {
+	r, g, b := byte(c>>11&0x1f), byte(c>>5&0x3f), byte(c&0x1f)
+	return [4]byte{r<<3 | r>>2, g<<2 | g>>4, b<<3 | b>>2, 255}
+}
+
+// decodeBC1 decodes the color part of BC1 to BC3. Only BC1 has the 3 color mode, alpha makes its black
+// transparent.
+func decodeBC1(b []byte, block *[16][4]byte, alpha, threeColors bool) {
+	c0, c1 := binary.LittleEndian.Uint16(b), binary.LittleEndian.Uint16(b[2:])
+	indices := binary.LittleEndian.Uint32(b[4:])
+
+	var colors [4][4]byte
+	colors[0], colors[1] = rgb565(c0), rgb565(c1)
+	for ch := 0; ch < 3; ch++ {
+		e0, e1 := int(colors[0][ch]), int(colors[1][ch])
+		if c0 > c1 || !threeColors {
+			colors[2][ch] = byte((2*e0 + e1 + 1) / 3)
+			colors[3][ch] = byte((e0 + 2*e1 + 1) / 3)
+		} else {
+			colors[2][ch] = byte((e0 + e1 + 1) / 2)
+		}
+	}
+	colors[2][3], colors[3][3] = 255, 255
+	if c0 <= c1 && threeColors && alpha {
+		colors[3][3] = 0
+	}
+
+	for i := range block {
+		block[i] = colors[indices>>(2*uint(i))&3]
+	}
+}
+
+// decodeBC4 decodes a BC4 block into a channel, it's also the alpha of BC3.
+func decodeBC4(b []byte, block *[16][4]byte, ch int) {
+	a0, a1 := int(b[0]), int(b[1])
+	var values [8]byte
+	values[0], values[1] = b[0], b[1]
+	if a0 > a1 {
+		for i := 1; i < 7; i++ {
+			values[i+1] = byte(((7-i)*a0 + i*a1 + 3) / 7)
+		}
+	} else {
+		for i := 1; i < 5; i++ {
+			values[i+1] = byte(((5-i)*a0 + i*a1 + 2) / 5)
+		}
+		values[6], values[7] = 0, 255
+	}
+
+	// 48 bits of 3 bit indices.
+	var bits uint64
+	for i := 7; i >= 2; i-- {
+		bits = bits<<8 | uint64(b[i])
+	}
+	for i := range block {
+		block[i][ch] = values[bits>>(3*uint(i))&7]
+	}
+}
+
+// bitReader reads the bits of a block, from the lowest bit of the first byte on.
+type bitReader struct {
+	b   []byte
+	pos uint
+}
+
+// read returns the next n bits.
+func (r *bitReader) read(n uint) int {
+	v := 0
+	for i := uint(0); i < n; i++ {
+		bit := int(r.b[r.pos>>3]>>(r.pos&7)) & 1
+		v |= bit << i
+		r.pos++
+	}
+	return v
+}
@@ -0,0 +1,279 @@
+package gputex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// bitWriter builds a block from the lowest bit of the first byte on, like bitReader reads it.
+type bitWriter struct {
+	b   [16]byte
+	pos uint
+}
+
+// write adds the lowest n bits of v.
+func (w *bitWriter) write(v int, n uint) {
+	for i := uint(0); i < n; i++ {
+		w.b[w.pos>>3] |= byte(v>>i&1) << (w.pos & 7)
+		w.pos++
+	}
+}
+
+// bc1Block returns the color part of BC1 to BC3, index i is the color of pixel i.
+func bc1Block(c0, c1 uint16, indices [16]int) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint16(b, c0)
+	binary.LittleEndian.PutUint16(b[2:], c1)
+	var bits uint32
+	for i, index := range indices {
+		bits |= uint32(index) << (2 * uint(i))
+	}
+	binary.LittleEndian.PutUint32(b[4:], bits)
+	return b
+}
+
+// bc4Block returns a BC4 block, index i is the value of pixel i.
+func bc4Block(a0, a1 byte, indices [16]int) []byte {
+	w := &bitWriter{}
+	w.write(int(a0), 8)
+	w.write(int(a1), 8)
+	for _, index := range indices {
+		w.write(index, 3)
+	}
+	return w.b[:8]
+}
+
+// rows returns the indices of a block where every row is the same.
+func rows(a, b, c, d int) [16]int {
+	var indices [16]int
+	for i := range indices {
+		indices[i] = [4]int{a, b, c, d}[i%4]
+	}
+	return indices
+}
+
+func TestDecode(t *testing.T) {
+	white, black, red := uint16(0xffff), uint16(0x0000), uint16(0x10<<11)
+	// Alpha 0, 1, 0 and 15 in every row.
+	alpha := []byte{0x10, 0xf0, 0x10, 0xf0, 0x10, 0xf0, 0x10, 0xf0}
+	tests := []struct {
+		name   string
+		format Format
+		block  []byte
+		// want are the 4 pixels of the first row, the block repeats them with every row.
+		want [4][4]byte
+	}{
+		{
+			// c0 > c1 has 4 colors, a third and two thirds of the way.
+			"BC1 4 colors", BC1, bc1Block(white, black, rows(0, 1, 2, 3)),
+			[4][4]byte{{255, 255, 255, 255}, {0, 0, 0, 255}, {170, 170, 170, 255}, {85, 85, 85, 255}},
+		},
+		{
+			// c0 <= c1 has 3 colors and black. Red 0x10 expands to 132.
+			"BC1 3 colors", BC1, bc1Block(black, red, rows(0, 1, 2, 3)),
+			[4][4]byte{{0, 0, 0, 255}, {132, 0, 0, 255}, {66, 0, 0, 255}, {0, 0, 0, 255}},
+		},
+		{
+			"BC1A transparent", BC1A, bc1Block(black, red, rows(0, 1, 2, 3)),
+			[4][4]byte{{0, 0, 0, 255}, {132, 0, 0, 255}, {66, 0, 0, 255}, {0, 0, 0, 0}},
+		},
+		{
+			// The alpha is 4 bits per pixel, the colors always have 4 colors.
+			"BC2", BC2, append(alpha, bc1Block(black, red, rows(0, 1, 2, 3))...),
+			[4][4]byte{{0, 0, 0, 0}, {132, 0, 0, 17}, {44, 0, 0, 0}, {88, 0, 0, 255}},
+		},
+		{
+			// a0 > a1 has 8 values, in steps of a seventh.
+			"BC3", BC3, append(bc4Block(245, 0, rows(0, 1, 2, 3)), bc1Block(white, white, [16]int{})...),
+			[4][4]byte{{255, 255, 255, 245}, {255, 255, 255, 0}, {255, 255, 255, 210}, {255, 255, 255, 175}},
+		},
+		{
+			"BC4 8 values", BC4, bc4Block(245, 0, rows(4, 5, 6, 7)),
+			[4][4]byte{{140, 0, 0, 255}, {105, 0, 0, 255}, {70, 0, 0, 255}, {35, 0, 0, 255}},
+		},
+		{
+			// a0 <= a1 has 6 values in steps of a fifth, 0 and 255.
+			"BC4 6 values", BC4, bc4Block(0, 245, rows(2, 5, 6, 7)),
+			[4][4]byte{{49, 0, 0, 255}, {196, 0, 0, 255}, {0, 0, 0, 255}, {255, 0, 0, 255}},
+		},
+		{
+			"BC5", BC5, append(bc4Block(245, 0, rows(0, 1, 2, 3)), bc4Block(0, 245, rows(2, 5, 6, 7))...),
+			[4][4]byte{{245, 49, 0, 255}, {0, 196, 0, 255}, {210, 0, 0, 255}, {175, 255, 0, 255}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pix, err := Decode(test.format, 4, 4, test.block)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for y := 0; y < 4; y++ {
+				for x := 0; x < 4; x++ {
+					if got, want := pix[(y*4+x)*4:][:4], test.want[x][:]; !bytes.Equal(got, want) {
+						t.Errorf("pixel %v, %v is %v, want %v", x, y, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeBC7(t *testing.T) {
+	tests := []struct {
+		name  string
+		block func(w *bitWriter)
+		pixel func(i int) [4]byte
+	}{
+		{
+			// Mode 6 has one subset, 7 bit endpoints with a p-bit and 4 bit indices. Black to white, pixel i has
+			// index i.
+			"mode 6",
+			func(w *bitWriter) {
+				w.write(1<<6, 7)
+				for ch := 0; ch < 4; ch++ {
+					w.write(0, 7)
+					w.write(127, 7)
+				}
+				w.write(0, 1)
+				w.write(1, 1)
+				w.write(0, 3)
+				for i := 1; i < 16; i++ {
+					w.write(i, 4)
+				}
+			},
+			func(i int) [4]byte {
+				v := byte((bcWeights[4][i]*255 + 32) >> 6)
+				return [4]byte{v, v, v, v}
+			},
+		},
+		{
+			// Mode 1 has two subsets with 6 bit endpoints and a shared p-bit. Partition 0 puts the right half in
+			// the second subset, which is white, the first is black.
+			"mode 1 partition 0",
+			func(w *bitWriter) {
+				w.write(1<<1, 2)
+				w.write(0, 6)
+				for ch := 0; ch < 3; ch++ {
+					w.write(0, 6)
+					w.write(0, 6)
+					w.write(63, 6)
+					w.write(63, 6)
+				}
+				w.write(0, 1)
+				w.write(1, 1)
+			},
+			func(i int) [4]byte {
+				if i%4 >= 2 {
+					return [4]byte{255, 255, 255, 255}
+				}
+				return [4]byte{0, 0, 0, 255}
+			},
+		},
+		{
+			// Mode 5 with rotation 1 swaps red and alpha: black with alpha 255 becomes red with alpha 0.
+			"mode 5 rotation",
+			func(w *bitWriter) {
+				w.write(1<<5, 6)
+				w.write(1, 2)
+				w.write(0, 7*6)
+				w.write(255, 8)
+				w.write(255, 8)
+			},
+			func(i int) [4]byte { return [4]byte{255, 0, 0, 0} },
+		},
+		{
+			// A reserved mode is transparent black.
+			"reserved",
+			func(w *bitWriter) {},
+			func(i int) [4]byte { return [4]byte{} },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := &bitWriter{}
+			test.block(w)
+			pix, err := Decode(BC7, 4, 4, w.b[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 16; i++ {
+				want := test.pixel(i)
+				if got := pix[i*4 : i*4+4]; !bytes.Equal(got, want[:]) {
+					t.Errorf("pixel %v is %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeBC6H(t *testing.T) {
+	// Mode 0x03 has 10 bit endpoints without deltas. Pixel 0 has index 0, pixel 1 index 8 and the rest 15.
+	block := func(e0, e1 int) []byte {
+		w := &bitWriter{}
+		w.write(0x03, 5)
+		for _, e := range []int{e0, e0, e0, e1, e1, e1} {
+			w.write(e, 10)
+		}
+		w.write(0, 3)
+		w.write(8, 4)
+		for i := 2; i < 16; i++ {
+			w.write(15, 4)
+		}
+		return w.b[:]
+	}
+
+	tests := []struct {
+		name   string
+		format Format
+		block  []byte
+		want   [3]float32
+	}{
+		// The largest endpoint is the largest half float. Index 8 is 34/64 of the way, 0x41df as a half.
+		{"unsigned", BC6H, block(0, 1023), [3]float32{0.0, 2.935546875, 65504.0}},
+		// -511 and 511 are the smallest and largest, index 8 is just above 0 at 0x07c0.
+		{"signed", BC6HSigned, block(0x201, 511), [3]float32{-65504.0, 1.9375 / 16384.0, 65504.0}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pix, err := DecodeFloat(test.format, 4, 4, test.block)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 16; i++ {
+				want := test.want[2]
+				if i < 2 {
+					want = test.want[i]
+				}
+				if p := pix[i*4 : i*4+4]; p[0] != want || p[1] != want || p[2] != want || p[3] != 1.0 {
+					t.Errorf("pixel %v is %v, want %v", i, p, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeSizes(t *testing.T) {
+	// A 2x2 mipmap is the top left of its block.
+	pix, err := Decode(BC1, 2, 2, bc1Block(0xffff, 0x0000, rows(0, 1, 2, 3)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{255, 255, 255, 255, 0, 0, 0, 255, 255, 255, 255, 255, 0, 0, 0, 255}
+	if !bytes.Equal(pix, want) {
+		t.Errorf("got %v, want %v", pix, want)
+	}
+
+	if _, err := Decode(BC1, 4, 4, make([]byte, 7)); err == nil {
+		t.Errorf("a short block gave no error")
+	}
+	if _, err := Decode(BC6H, 4, 4, make([]byte, 16)); err == nil {
+		t.Errorf("BC6H decoded to bytes")
+	}
+	if _, err := DecodeFloat(BC7, 4, 4, make([]byte, 16)); err == nil {
+		t.Errorf("BC7 decoded to floats")
+	}
+}
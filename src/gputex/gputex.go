@@ -0,0 +1,172 @@
+// Package gputex reads textures that are stored the way the GPU samples them, from DDS, KTX and KTX2 files. The
+// data is BC1 to BC7 compressed or plain RGBA8, with the mipmaps already made, so it can be uploaded as it is. When
+// the GPU can't sample a format, Decode turns it into RGBA8 on the CPU.
+//
+// The files store the top row first, like images. Compressed data can't be flipped cheaply, so the textures are
+// upside down compared to gfx.LoadTexture, unless the file was saved bottom row first, like with texconv -vflip
+// or toktx --lower_left_maps_to_s0t0.
+package gputex
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// Format is how the pixels are stored.
+type Format int
+
+// The formats, BC formats store blocks of 4x4 pixels.
+const (
+	// RGBA8 is 4 bytes per pixel.
+	RGBA8 Format = iota
+	// BC1 is RGB in 8 bytes per block.
+	BC1
+	// BC1A is BC1 where a pixel can be transparent black.
+	BC1A
+	// BC2 is BC1 with 4 bits of alpha per pixel, 16 bytes per block.
+	BC2
+	// BC3 is BC1 with alpha compressed like BC4, 16 bytes per block.
+	BC3
+	// BC4 is one channel in 8 bytes per block.
+	BC4
+	// BC5 is two BC4 channels, 16 bytes per block.
+	BC5
+	// BC6H is RGB with half floats that aren't negative, 16 bytes per block.
+	BC6H
+	// BC6HSigned is BC6H with negative values.
+	BC6HSigned
+	// BC7 is RGBA in 16 bytes per block, with a better quality than BC3.
+	BC7
+)
+
+var formatNames = map[Format]string{
+	RGBA8: "RGBA8", BC1: "BC1", BC1A: "BC1A", BC2: "BC2", BC3: "BC3", BC4: "BC4", BC5: "BC5", BC6H: "BC6H",
+	BC6HSigned: "BC6HSigned", BC7: "BC7",
+}
+
+// String returns the name of the format.
+func (f Format) String() string {
+	if name, ok := formatNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// Compressed returns whether the format stores blocks.
+func (f Format) Compressed() bool {
+	return f != RGBA8
+}
+
+// HDR returns whether the format has floats, Decode can't decode those to bytes.
+func (f Format) HDR() bool {
+	return f == BC6H || f == BC6HSigned
+}
+
+// blockBytes returns the bytes of a block, or of a pixel for RGBA8.
+func (f Format) blockBytes() int {
+	switch f {
+	case RGBA8:
+		return 4
+	case BC1, BC1A, BC4:
+		return 8
+	}
+	return 16
+}
+
+// LevelSize returns the bytes of a mipmap of the size.
+func LevelSize(f Format, width, height int) int {
+	if !f.Compressed() {
+		return width * height * f.blockBytes()
+	}
+	return (width + 3) / 4 * ((height + 3) / 4) * f.blockBytes()
+}
+
+// Image is a texture with its mipmaps.
+type Image struct {
+	Format Format
+	// SRGB is set when the file says the colors are sRGB. Only RGBA8, BC1, BC2, BC3 and BC7 can be.
+	SRGB          bool
+	Width, Height int
+	// Levels are the mipmaps, the full size first. Every level is half the size of the one before, at least 1
+	// pixel. The files don't have to have them down to 1x1.
+	Levels [][]byte
+}
+
+// LevelSize returns the width and height of a mipmap.
+func (img *Image) LevelSize(level int) (int, int) {
+	w, h := img.Width>>uint(level), img.Height>>uint(level)
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	return w, h
+}
+
+// Read reads a DDS, KTX or KTX2 file, it's recognized by the first bytes.
+func Read(r io.Reader) (*Image, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(data, ddsMagic):
+		return readDDS(data)
+	case bytes.HasPrefix(data, ktxMagic):
+		return readKTX(data)
+	case bytes.HasPrefix(data, ktx2Magic):
+		return readKTX2(data)
+	}
+	return nil, fmt.Errorf("not a DDS, KTX or KTX2 file")
+}
+
+// Load reads a DDS, KTX or KTX2 file.
+func Load(file string) (*Image, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := Read(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", file, err)
+	}
+	return img, nil
+}
+
+// levels slices the mipmaps out of data, one after the other. It's used by DDS, KTX has sizes in between.
+func (img *Image) levels(data []byte, count int) error {
+	if count < 1 {
+		count = 1
+	}
+	for i := 0; i < count; i++ {
+		w, h := img.LevelSize(i)
+		size := LevelSize(img.Format, w, h)
+		if len(data) < size {
+			return fmt.Errorf("the data of mipmap %v is cut off", i)
+		}
+		img.Levels = append(img.Levels, data[:size:size])
+		data = data[size:]
+		if w == 1 && h == 1 {
+			break
+		}
+	}
+
+	return nil
+}
+
+// check makes sure the size is something a GPU takes.
+func (img *Image) check() error {
+	if img.Width < 1 || img.Height < 1 || img.Width > 1<<16 || img.Height > 1<<16 {
+		return fmt.Errorf("a texture can't be %vx%v", img.Width, img.Height)
+	}
+	if img.SRGB && img.Format != RGBA8 && img.Format != BC1 && img.Format != BC1A && img.Format != BC2 &&
+		img.Format != BC3 && img.Format != BC7 {
+		return fmt.Errorf("%v can't be sRGB", img.Format)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package gputex
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+// The files in testdata are made by hand, the data of every level counts up from a different byte so a level
+// read from the wrong place shows.
+var files = []struct {
+	file   string
+	format Format
+	srgb   bool
+	width  int
+	height int
+	// sizes are the bytes of every level, first is the first byte of the full size.
+	sizes []int
+	first byte
+}{
+	{"dxt1.dds", BC1, false, 8, 8, []int{32, 8, 8, 8}, 0x00},
+	{"bc7.dds", BC7, true, 4, 4, []int{16}, 0x40},
+	// B8G8R8A8, the first pixel is 1, 2, 3, 4 in the file and swapped to RGBA.
+	{"bgra8.dds", RGBA8, false, 2, 2, []int{16, 4}, 0x03},
+	{"bc1_le.ktx", BC1, true, 8, 4, []int{16, 8}, 0x80},
+	{"bc1_be.ktx", BC1, true, 8, 4, []int{16, 8}, 0x80},
+	// The full size comes after the small one in the file.
+	{"bc7.ktx2", BC7, false, 8, 8, []int{64, 16}, 0xb0},
+}
+
+func TestLoad(t *testing.T) {
+	for _, f := range files {
+		t.Run(f.file, func(t *testing.T) {
+			img, err := Load("testdata/" + f.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if img.Format != f.format || img.SRGB != f.srgb || img.Width != f.width || img.Height != f.height {
+				t.Errorf("got %vx%v %v sRGB %v, want %vx%v %v sRGB %v", img.Width, img.Height, img.Format, img.SRGB,
+					f.width, f.height, f.format, f.srgb)
+			}
+			var sizes []int
+			for _, l := range img.Levels {
+				sizes = append(sizes, len(l))
+			}
+			if !reflect.DeepEqual(sizes, f.sizes) {
+				t.Errorf("the levels are %v bytes, want %v", sizes, f.sizes)
+			}
+			if len(img.Levels) > 0 && img.Levels[0][0] != f.first {
+				t.Errorf("the first byte is %#x, want %#x", img.Levels[0][0], f.first)
+			}
+		})
+	}
+}
+
+func TestKTXByteOrder(t *testing.T) {
+	le, err := Load("testdata/bc1_le.ktx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	be, err := Load("testdata/bc1_be.ktx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(le, be) {
+		t.Errorf("the byte orders give different images, %+v and %+v", le, be)
+	}
+}
+
+func TestBGRASwap(t *testing.T) {
+	img, err := Load("testdata/bgra8.dds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := img.Levels[0][:4], []byte{3, 2, 1, 4}; !bytes.Equal(got, want) {
+		t.Errorf("the first pixel is %v, want %v", got, want)
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	for _, f := range files {
+		data, err := ioutil.ReadFile("testdata/" + f.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Every level is needed, so any cut is an error.
+		for n := 0; n < len(data); n++ {
+			if _, err := Read(bytes.NewReader(data[:n])); err == nil {
+				t.Errorf("%v cut off at %v bytes gave no error", f.file, n)
+			}
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package gputex
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+var (
+	ktxMagic  = []byte("\xabKTX 11\xbb\r\n\x1a\n")
+	ktx2Magic = []byte("\xabKTX 20\xbb\r\n\x1a\n")
+)
+
+// glFormats are the internal formats of KTX files, and whether they're sRGB.
+var glFormats = map[uint32]struct {
+	format Format
+	srgb   bool
+}{
+	0x8058: {RGBA8, false},      // GL_RGBA8
+	0x8C43: {RGBA8, true},       // GL_SRGB8_ALPHA8
+	0x83F0: {BC1, false},        // GL_COMPRESSED_RGB_S3TC_DXT1_EXT
+	0x83F1: {BC1A, false},       // GL_COMPRESSED_RGBA_S3TC_DXT1_EXT
+	0x83F2: {BC2, false},        // GL_COMPRESSED_RGBA_S3TC_DXT3_EXT
+	0x83F3: {BC3, false},        // GL_COMPRESSED_RGBA_S3TC_DXT5_EXT
+	0x8C4C: {BC1, true},         // GL_COMPRESSED_SRGB_S3TC_DXT1_EXT
+	0x8C4D: {BC1A, true},        // GL_COMPRESSED_SRGB_ALPHA_S3TC_DXT1_EXT
+	0x8C4E: {BC2, true},         // GL_COMPRESSED_SRGB_ALPHA_S3TC_DXT3_EXT
+	0x8C4F: {BC3, true},         // GL_COMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT
+	0x8DBB: {BC4, false},        // GL_COMPRESSED_RED_RGTC1
+	0x8DBD: {BC5, false},        // GL_COMPRESSED_RG_RGTC2
+	0x8E8C: {BC7, false},        // GL_COMPRESSED_RGBA_BPTC_UNORM
+	0x8E8D: {BC7, true},         // GL_COMPRESSED_SRGB_ALPHA_BPTC_UNORM
+	0x8E8E: {BC6HSigned, false}, // GL_COMPRESSED_RGB_BPTC_SIGNED_FLOAT
+	0x8E8F: {BC6H, false},       // GL_COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT
+}
+
+// vkFormats are the formats of KTX2 files, which uses the Vulkan names.
+var vkFormats = map[uint32]struct {
+	format Format
+	srgb   bool
+}{
+	37:  {RGBA8, false},      // VK_FORMAT_R8G8B8A8_UNORM
+	43:  {RGBA8, true},       // VK_FORMAT_R8G8B8A8_SRGB
+	131: {BC1, false},        // VK_FORMAT_BC1_RGB_UNORM_BLOCK
+	132: {BC1, true},         // VK_FORMAT_BC1_RGB_SRGB_BLOCK
+	133: {BC1A, false},       // VK_FORMAT_BC1_RGBA_UNORM_BLOCK
+	134: {BC1A, true},        // VK_FORMAT_BC1_RGBA_SRGB_BLOCK
+	135: {BC2, false},        // VK_FORMAT_BC2_UNORM_BLOCK
+	136: {BC2, true},         // VK_FORMAT_BC2_SRGB_BLOCK
+	137: {BC3, false},        // VK_FORMAT_BC3_UNORM_BLOCK
+	138: {BC3, true},         // VK_FORMAT_BC3_SRGB_BLOCK
+	139: {BC4, false},        // VK_FORMAT_BC4_UNORM_BLOCK
+	141: {BC5, false},        // VK_FORMAT_BC5_UNORM_BLOCK
+	143: {BC6H, false},       // VK_FORMAT_BC6H_UFLOAT_BLOCK
+	144: {BC6HSigned, false}, // VK_FORMAT_BC6H_SFLOAT_BLOCK
+	145: {BC7, false},        // VK_FORMAT_BC7_UNORM_BLOCK
+	146: {BC7, true},         // VK_FORMAT_BC7_SRGB_BLOCK
+}
+
+// readKTX reads a KTX 1 file. Arrays, cubemaps and 3D textures aren't supported.
+func readKTX(data []byte) (*Image, error) {
+	const headerSize = 64
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("the KTX header is cut off")
+	}
+
+	// The file is in the byte order of the machine that wrote it, the endianness field says which.
+	var order binary.ByteOrder = binary.LittleEndian
+	switch binary.LittleEndian.Uint32(data[12:]) {
+	case 0x04030201:
+	case 0x01020304:
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("the KTX endianness is broken")
+	}
+	u32 := func(off int) uint32 { return order.Uint32(data[off:]) }
+
+	glType, internalFormat := u32(16), u32(28)
+	width, height, depth := u32(36), u32(40), u32(44)
+	arrays, faces, mipmaps, kvBytes := u32(48), u32(52), u32(56), u32(60)
+	if depth > 0 || arrays > 0 || faces > 1 {
+		return nil, fmt.Errorf("only 2D KTX textures are supported")
+	}
+
+	f, ok := glFormats[internalFormat]
+	// Compressed formats have no type, RGBA8 has to be bytes.
+	if !ok || f.format == RGBA8 && glType != 0x1401 || f.format != RGBA8 && glType != 0 {
+		return nil, fmt.Errorf("the KTX format %#x with type %#x isn't supported", internalFormat, glType)
+	}
+	img := &Image{Format: f.format, SRGB: f.srgb, Width: int(width), Height: int(height)}
+	if height == 0 {
+		// 1D textures have a height of 0.
+		img.Height = 1
+	}
+	if err := img.check(); err != nil {
+		return nil, err
+	}
+
+	off := headerSize + int(kvBytes)
+	count := int(mipmaps)
+	if count == 0 {
+		// 0 asks the loader to make the mipmaps, there's only the full size.
+		count = 1
+	}
+	for i := 0; i < count; i++ {
+		if off+4 > len(data) {
+			return nil, fmt.Errorf("mipmap %v is missing", i)
+		}
+		size := int(u32(off))
+		off += 4
+
+		w, h := img.LevelSize(i)
+		if size != LevelSize(img.Format, w, h) {
+			return nil, fmt.Errorf("mipmap %v is %v bytes, %vx%v %v is %v", i, size, w, h, img.Format,
+				LevelSize(img.Format, w, h))
+		}
+		if off+size > len(data) {
+			return nil, fmt.Errorf("the data of mipmap %v is cut off", i)
+		}
+		img.Levels = append(img.Levels, data[off:off+size:off+size])
+		// Every level starts on 4 bytes.
+		off += (size + 3) / 4 * 4
+	}
+
+	return img, nil
+}
+
+// readKTX2 reads a KTX 2 file. Supercompressed files, like Basis Universal, aren't supported.
+func readKTX2(data []byte) (*Image, error) {
+	const headerSize = 80
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("the KTX2 header is cut off")
+	}
+	u32 := func(off int) uint32 { return binary.LittleEndian.Uint32(data[off:]) }
+	u64 := func(off int) uint64 { return binary.LittleEndian.Uint64(data[off:]) }
+
+	vkFormat, width, height, depth := u32(12), u32(20), u32(24), u32(28)
+	layers, faces, mipmaps, supercompression := u32(32), u32(36), u32(40), u32(44)
+	if depth > 0 || layers > 0 || faces > 1 {
+		return nil, fmt.Errorf("only 2D KTX2 textures are supported")
+	}
+	if supercompression != 0 {
+		return nil, fmt.Errorf("supercompressed KTX2 files aren't supported")
+	}
+
+	f, ok := vkFormats[vkFormat]
+	if !ok {
+		return nil, fmt.Errorf("the KTX2 format %v isn't supported", vkFormat)
+	}
+	img := &Image{Format: f.format, SRGB: f.srgb, Width: int(width), Height: int(height)}
+	if height == 0 {
+		img.Height = 1
+	}
+	if err := img.check(); err != nil {
+		return nil, err
+	}
+
+	count := int(mipmaps)
+	if count == 0 {
+		count = 1
+	}
+	if headerSize+count*24 > len(data) {
+		return nil, fmt.Errorf("the KTX2 level index is cut off")
+	}
+	// The index has the full size first, the data is the other way around.
+	for i := 0; i < count; i++ {
+		off, size := u64(headerSize+i*24), u64(headerSize+i*24+8)
+		w, h := img.LevelSize(i)
+		if size != uint64(LevelSize(img.Format, w, h)) {
+			return nil, fmt.Errorf("mipmap %v is %v bytes, %vx%v %v is %v", i, size, w, h, img.Format,
+				LevelSize(img.Format, w, h))
+		}
+		if off > uint64(len(data)) || size > uint64(len(data))-off {
+			return nil, fmt.Errorf("the data of mipmap %v is cut off", i)
+		}
+		img.Levels = append(img.Levels, data[off:off+size:off+size])
+	}
+
+	return img, nil
+}
@@ -0,0 +1,143 @@
+//go:build linux && cgo
+// +build linux,cgo
+
+// Package headless creates an OpenGL context without a window, for rendering in tests and on servers. It only needs
+// EGL, so it builds without the X11 libraries GLFW and the window package need.
+package headless
+
+/*
+#cgo LDFLAGS: -lEGL
+#include <stddef.h>
+#include <EGL/egl.h>
+#include <EGL/eglext.h>
+
+// createContext makes a core 3.3 context current without any surface, using the surfaceless platform of Mesa.
+// It returns 0 or the step that failed.
+static int createContext(EGLDisplay *dpy, EGLContext *ctx) {
+	PFNEGLGETPLATFORMDISPLAYEXTPROC getPlatformDisplay =
+		(PFNEGLGETPLATFORMDISPLAYEXTPROC)eglGetProcAddress("eglGetPlatformDisplayEXT");
+	if (getPlatformDisplay == NULL) {
+		return 1;
+	}
+
+	*dpy = getPlatformDisplay(EGL_PLATFORM_SURFACELESS_MESA, EGL_DEFAULT_DISPLAY, NULL);
+	if (*dpy == EGL_NO_DISPLAY || !eglInitialize(*dpy, NULL, NULL)) {
+		return 2;
+	}
+	if (!eglBindAPI(EGL_OPENGL_API)) {
+		return 3;
+	}
+
+	EGLint configAttribs[] = {
+		EGL_SURFACE_TYPE, 0,
+		EGL_RENDERABLE_TYPE, EGL_OPENGL_BIT,
+		EGL_NONE,
+	};
+	EGLConfig config;
+	EGLint count;
+	if (!eglChooseConfig(*dpy, configAttribs, &config, 1, &count) || count == 0) {
+		return 4;
+	}
+
+	EGLint contextAttribs[] = {
+		EGL_CONTEXT_MAJOR_VERSION, 3,
+		EGL_CONTEXT_MINOR_VERSION, 3,
+		EGL_CONTEXT_OPENGL_PROFILE_MASK, EGL_CONTEXT_OPENGL_CORE_PROFILE_BIT,
+		EGL_NONE,
+	};
+	*ctx = eglCreateContext(*dpy, config, EGL_NO_CONTEXT, contextAttribs);
+	if (*ctx == EGL_NO_CONTEXT) {
+		return 5;
+	}
+	if (!eglMakeCurrent(*dpy, EGL_NO_SURFACE, EGL_NO_SURFACE, *ctx)) {
+		return 6;
+	}
+
+	return 0;
+}
+
+static void destroyContext(EGLDisplay dpy, EGLContext ctx) {
+	eglMakeCurrent(dpy, EGL_NO_SURFACE, EGL_NO_SURFACE, EGL_NO_CONTEXT);
+	eglDestroyContext(dpy, ctx);
+	eglTerminate(dpy);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+
+	"GopherGL/src/gfx"
+)
+
+// Headless is an OpenGL context without a window, it renders to a gfx.Target instead.
+type Headless struct {
+	X, Y    uint32
+	Target  *gfx.Target
+	display C.EGLDisplay
+	context C.EGLContext
+}
+
+// Where createContext can fail, by its return value.
+var headlessSteps = [...]string{
+	1: "EGL_EXT_platform_base is not supported",
+	2: "the surfaceless platform is not available",
+	3: "desktop OpenGL is not supported",
+	4: "there is no matching config",
+	5: "creating an OpenGL 3.3 core context failed",
+	6: "making the context current failed",
+}
+
+// CreateHeadless creates an OpenGL context with EGL, without a display server. On a machine without a GPU set
+// LIBGL_ALWAYS_SOFTWARE=1 to have Mesa use its software rasterizer. Build with the egl tag, so go-gl loads the
+// functions through EGL instead of GLX.
+func CreateHeadless(x, y uint32) (*Headless, error) {
+	// Same as with a window, the context belongs to this thread.
+	runtime.LockOSThread()
+
+	h := &Headless{X: x, Y: y}
+	if step := C.createContext(&h.display, &h.context); step != 0 {
+		return nil, fmt.Errorf("creating a headless context failed: %v (EGL error 0x%x)", headlessSteps[step], C.eglGetError())
+	}
+
+	err := gl.Init()
+	if err != nil {
+		h.Close()
+		return nil, err
+	}
+	fmt.Println("OpenGL:", gl.GoStr(gl.GetString(gl.VERSION)), "(headless)")
+
+	// There is no default framebuffer, so everything is drawn to the target.
+	if gfx.CurrentDevice() == nil {
+		gfx.UseDevice(gfx.CreateGLDevice())
+	}
+	h.Target, err = gfx.CreateTarget(int32(x), int32(y))
+	if err != nil {
+		h.Close()
+		return nil, err
+	}
+	h.Target.Bind()
+
+	return h, nil
+}
+
+// Screenshot saves the target to a PNG file.
+func (h *Headless) Screenshot(file string) error {
+	img, err := gfx.CaptureFrame(h.Target)
+	if err != nil {
+		return err
+	}
+
+	return gfx.SavePNG(file, img)
+}
+
+// Close destroys the context.
+func (h *Headless) Close() {
+	if h.Target != nil {
+		h.Target.Delete()
+	}
+	C.destroyContext(h.display, h.context)
+}
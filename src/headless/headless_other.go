@@ -0,0 +1,29 @@
+//go:build !linux || !cgo
+// +build !linux !cgo
+
+package headless
+
+import (
+	"errors"
+
+	"GopherGL/src/gfx"
+)
+
+// Headless is an OpenGL context without a window, it's only supported on Linux.
+type Headless struct {
+	X, Y   uint32
+	Target *gfx.Target
+}
+
+// CreateHeadless always fails, headless contexts need EGL on Linux.
+func CreateHeadless(x, y uint32) (*Headless, error) {
+	return nil, errors.New("headless contexts are only supported on Linux with cgo")
+}
+
+// Screenshot does nothing here.
+func (h *Headless) Screenshot(file string) error {
+	return errors.New("headless contexts are only supported on Linux with cgo")
+}
+
+// Close does nothing here.
+func (h *Headless) Close() {}
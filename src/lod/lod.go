@@ -0,0 +1,116 @@
+// Package lod picks which level of detail of a mesh to draw. It doesn't need OpenGL, so it's easy to test.
+package lod
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Mode is what a Selector bases its choice on.
+type Mode int
+
+// The modes of a Selector.
+const (
+	// ByDistance uses the distance between the camera and the mesh.
+	ByDistance Mode = iota
+	// ByScreenSize uses how much of the screen height the mesh covers, see ScreenSize.
+	ByScreenSize
+)
+
+// Level is the point at which a level of detail is used. Level 0 is always used when no other level is.
+type Level struct {
+	// Distance is the distance from which this level is used, with ByDistance.
+	Distance float32
+	// ScreenSize is the screen size below which this level is used, with ByScreenSize.
+	ScreenSize float32
+}
+
+// Selector keeps track of the current level of an object, so it can prevent popping back and forth.
+type Selector struct {
+	Levels []Level
+	Mode   Mode
+
+	// Hysteresis is how far past a switch point, as a fraction, the metric has to be before switching.
+	// This keeps objects right on the switch point from flickering between levels.
+	Hysteresis float32
+	// FadeTime is how many seconds a cross-fade between two levels takes, 0 switches at once.
+	FadeTime float32
+
+	current, previous int
+	fade              float32
+}
+
+// CreateSelector returns a selector with a bit of hysteresis and no fading.
+func CreateSelector(mode Mode, levels ...Level) *Selector {
+	return &Selector{
+		Levels:     levels,
+		Mode:       mode,
+		Hysteresis: 0.1,
+		fade:       1.0,
+	}
+}
+
+// ScreenSize returns the part of the screen height covered by a sphere with a radius at a distance.
+// Fov is the vertical field of view in degrees, like the camera.
+func ScreenSize(radius, distance, fov float32) float32 {
+	if distance <= radius {
+		return 1.0
+	}
+	half := math.Tan(float64(mgl32.DegToRad(fov)) / 2.0)
+
+	return radius / (distance * float32(half))
+}
+
+// target returns the level for metric m, with the switch points moved by bias.
+func (s *Selector) target(m, bias float32) int {
+	level := 0
+	for i := 1; i < len(s.Levels); i++ {
+		switch s.Mode {
+		case ByDistance:
+			if m >= s.Levels[i].Distance*(1.0+bias) {
+				level = i
+			}
+		case ByScreenSize:
+			if m <= s.Levels[i].ScreenSize*(1.0-bias) {
+				level = i
+			}
+		}
+	}
+
+	return level
+}
+
+// Update picks the level for the metric, which is the distance or the screen size depending on the mode.
+// Dt is the time since the last update, for fading. It returns the level to draw, and while fading also
+// the previous level and how far the fade is, from 0 to 1. When not fading, fade is 1.
+func (s *Selector) Update(m, dt float32) (level, previous int, fade float32) {
+	// Going to a lower detail needs to be a bit past the switch point, and going back a bit before it.
+	next := s.current
+	if up := s.target(m, s.Hysteresis); up > s.current {
+		next = up
+	} else if down := s.target(m, -s.Hysteresis); down < s.current {
+		next = down
+	}
+
+	if next != s.current {
+		s.previous, s.current = s.current, next
+		s.fade = 0.0
+	}
+
+	if s.FadeTime <= 0.0 {
+		s.fade = 1.0
+	} else if s.fade < 1.0 {
+		s.fade += dt / s.FadeTime
+		if s.fade > 1.0 {
+			s.fade = 1.0
+		}
+	}
+
+	return s.current, s.previous, s.fade
+}
+
+// Current returns the level picked by the last Update.
+func (s *Selector) Current() int {
+	return s.current
+}
@@ -0,0 +1,125 @@
+package lod
+
+import (
+	"math"
+	"testing"
+)
+
+// step is a metric, and the level Update should pick for it.
+type step struct {
+	m    float32
+	want int
+}
+
+func TestUpdateHysteresis(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		mode   Mode
+		levels []Level
+		steps  []step
+	}{
+		{
+			name:   "distance",
+			mode:   ByDistance,
+			levels: []Level{{}, {Distance: 10.0}, {Distance: 20.0}},
+			steps: []step{
+				{5.0, 0},
+				// Past the switch point, but not by 10%.
+				{10.5, 0},
+				{11.5, 1},
+				// Back before it, but not by 10%.
+				{9.5, 1},
+				{10.0, 1},
+				{8.5, 0},
+				// Levels can be skipped.
+				{25.0, 2},
+				{19.0, 2},
+				{17.5, 1},
+				{2.0, 0},
+			},
+		},
+		{
+			name:   "screen size",
+			mode:   ByScreenSize,
+			levels: []Level{{}, {ScreenSize: 0.5}, {ScreenSize: 0.25}},
+			steps: []step{
+				{0.6, 0},
+				{0.47, 0},
+				{0.44, 1},
+				{0.52, 1},
+				{0.56, 0},
+				{0.2, 2},
+				{0.26, 2},
+				{0.3, 1},
+			},
+		},
+	} {
+		s := CreateSelector(c.mode, c.levels...)
+		for i, step := range c.steps {
+			level, _, fade := s.Update(step.m, 0.1)
+			if level != step.want || s.Current() != step.want {
+				t.Errorf("%v, step %v: %v gives level %v, not %v", c.name, i, step.m, level, step.want)
+			}
+			if fade != 1.0 {
+				t.Errorf("%v, step %v: fading %v without a fade time", c.name, i, fade)
+			}
+		}
+	}
+}
+
+func TestUpdateWithoutHysteresis(t *testing.T) {
+	s := CreateSelector(ByDistance, Level{}, Level{Distance: 10.0})
+	s.Hysteresis = 0.0
+	for _, c := range []step{{9.99, 0}, {10.0, 1}, {9.99, 0}} {
+		if level, _, _ := s.Update(c.m, 0.0); level != c.want {
+			t.Errorf("%v gives level %v, not %v", c.m, level, c.want)
+		}
+	}
+}
+
+func TestUpdateFade(t *testing.T) {
+	s := CreateSelector(ByDistance, Level{}, Level{Distance: 10.0})
+	s.FadeTime = 1.0
+
+	steps := []struct {
+		m               float32
+		level, previous int
+		fade            float32
+	}{
+		{5.0, 0, 0, 1.0},
+		// The switch starts the fade, and the time of the update counts.
+		{20.0, 1, 0, 0.25},
+		{20.0, 1, 0, 0.5},
+		{20.0, 1, 0, 0.75},
+		{20.0, 1, 0, 1.0},
+		{20.0, 1, 0, 1.0},
+		// Going back fades the other way.
+		{5.0, 0, 1, 0.25},
+		// Switching again halfway starts over.
+		{20.0, 1, 0, 0.25},
+	}
+	for i, c := range steps {
+		level, previous, fade := s.Update(c.m, 0.25)
+		if level != c.level || previous != c.previous || math.Abs(float64(fade-c.fade)) > 1e-6 {
+			t.Errorf("step %v: level %v, previous %v and fade %v, not %v, %v and %v", i, level, previous, fade,
+				c.level, c.previous, c.fade)
+		}
+	}
+}
+
+func TestScreenSize(t *testing.T) {
+	for _, c := range []struct {
+		radius, distance, fov, want float32
+	}{
+		// tan(45) is 1, so the sphere covers its diameter over twice the distance.
+		{1.0, 10.0, 90.0, 0.1},
+		{2.0, 10.0, 90.0, 0.2},
+		{1.0, 10.0, 60.0, 0.1 * float32(math.Sqrt(3.0))},
+		// Inside the sphere it covers the screen.
+		{1.0, 0.5, 90.0, 1.0},
+	} {
+		if got := ScreenSize(c.radius, c.distance, c.fov); math.Abs(float64(got-c.want)) > 1e-5 {
+			t.Errorf("ScreenSize(%v, %v, %v) is %v, not %v", c.radius, c.distance, c.fov, got, c.want)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"log"
+	"math"
+
 	"github.com/go-gl/mathgl/mgl32"
 	"github.com/go-gl/glfw/v3.2/glfw"
 
@@ -8,15 +11,9 @@ import (
 	"GopherGL/src/gfx"
 	"GopherGL/src/window"
 	"GopherGL/src/input"
+	"GopherGL/src/tween"
 )
 
-// check is used to check errors.
-func check(err error) {
-	if err != nil {
-		panic(err)
-	}
-}
-
 // HandleInput handles all the input. This function will move into a seperate input package later.
 func handleInput(w *window.Window, movSpd float32, cam *camera.Camera) {
 	// Close the window.
@@ -47,9 +44,17 @@ func handleInput(w *window.Window, movSpd float32, cam *camera.Camera) {
 
 func main() {
 	window, err := window.CreateWindow(800, 600, "GopherGL", true)
-	check(err)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	gfx.InitRenderer()
+	if err := gfx.InitRenderer(); err != nil {
+		log.Fatal(err)
+	}
+	// Shaders and textures are loaded again when they're saved, the game works fine without it.
+	if err := gfx.EnableHotReload(); err != nil {
+		log.Println(err)
+	}
 
 	input.Init(window)
 	cam := camera.CreateCamera(mgl32.Vec3{0.0, 0.0, 3.0}, float32(window.X)/float32(window.Y), 90.0)
@@ -57,8 +62,19 @@ func main() {
 	// This is the sun of the scene.
 	sun := gfx.CreateDirectionalLight(mgl32.Vec3{0.5, -0.5, 0.0}, 1.0)
 
-	cubeMat := gfx.CreateMaterial("../res/containerTex.png", "../res/containerSpec.png", 1.0)
-	cube := gfx.CreateCube(0.0, 0.0, 0.0, 0.0, 0.0, 0.0, cubeMat)
+	// The material is loaded in the background, the cube is magenta until it's there. A missing texture shouldn't
+	// stop the game, then it stays magenta.
+	cubeMat := gfx.LoadMaterialAsync("../res/container.mat.json")
+	cube, err := gfx.CreateCube(0.0, 0.0, 0.0, 0.0, 0.0, 0.0, gfx.PlaceholderMaterial())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Rotate dirt cube, one full turn every 2 pi seconds.
+	anims := tween.Animator{}
+	anims.Add(tween.CreateAnimation(tween.Loop, tween.FromTo(0.0, 2.0*math.Pi, 2.0*math.Pi, tween.Linear, func(r float32) {
+		cube.SetRot(r, 0.0, r)
+	})))
 
 	// TODO: This should be handled differently. Most of it can be done when creating the objects.
 	// Set uniform.
@@ -73,13 +89,21 @@ func main() {
 		}
 
 		cam.Update()
-		
-		// Rotate dirt cube.
-		cube.SetRot(window.Time(), 0.0, window.Time())
+		anims.Update(window.DeltaTime())
 		
 		// OpenGL stuff.
 		gfx.BeginFrame()
+		if cubeMat != nil && cubeMat.Done() {
+			if err := cubeMat.Err(); err != nil {
+				log.Println(err)
+			} else {
+				cube.SetMaterial(cubeMat.Material())
+			}
+			cubeMat = nil
+		}
 		gfx.Render(cam, cube, sun)
+		gfx.RenderReloadError(10.0, 10.0)
+		gfx.RenderLoadingProgress(10.0, 10.0)
 
 		window.Update()
 	}
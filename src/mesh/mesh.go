@@ -0,0 +1,129 @@
+// Package mesh holds the vertex data of meshes on the CPU side, before the gfx package uploads it.
+package mesh
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// VertexSize is the amount of floats per vertex: position, texture coordinates and normal.
+const VertexSize = 8
+
+// Mesh is an indexed triangle mesh. Vertices are laid out as position (3), texture coordinates (2) and normal (3).
+type Mesh struct {
+	Vertices []float32
+	Indices  []uint32
+
+	// Joints and Weights are only set for skinned meshes. Every vertex has 4 of both.
+	Joints  []uint16
+	Weights []float32
+}
+
+// VertexCount returns the amount of vertices in the mesh.
+func (m *Mesh) VertexCount() int {
+	return len(m.Vertices) / VertexSize
+}
+
+// Skinned returns whether the mesh has joints and weights.
+func (m *Mesh) Skinned() bool {
+	return len(m.Joints) > 0 && len(m.Joints) == len(m.Weights)
+}
+
+// Radius returns the radius of a sphere around the origin of the mesh that contains every vertex.
+func (m *Mesh) Radius() float32 {
+	var r float32
+	for i := 0; i+2 < len(m.Vertices); i += VertexSize {
+		x, y, z := m.Vertices[i], m.Vertices[i+1], m.Vertices[i+2]
+		if d := x*x + y*y + z*z; d > r {
+			r = d
+		}
+	}
+
+	return float32(math.Sqrt(float64(r)))
+}
+
+// Bounds returns the minimum and maximum corner of the box around the mesh.
+func (m *Mesh) Bounds() (mgl32.Vec3, mgl32.Vec3) {
+	if len(m.Vertices) < 3 {
+		return mgl32.Vec3{}, mgl32.Vec3{}
+	}
+
+	min := mgl32.Vec3{m.Vertices[0], m.Vertices[1], m.Vertices[2]}
+	max := min
+	for i := 0; i+2 < len(m.Vertices); i += VertexSize {
+		for j := 0; j < 3; j++ {
+			if v := m.Vertices[i+j]; v < min[j] {
+				min[j] = v
+			} else if v > max[j] {
+				max[j] = v
+			}
+		}
+	}
+
+	return min, max
+}
+
+// Cube returns a cube of 1 by 1 by 1, with the center at the origin.
+func Cube() *Mesh {
+	vertices := []float32{
+		// positions      tex coords normals
+		// Back quad.
+		0.5, -0.5, -0.5, 0.0, 0.0, 0.0, 0.0, -1.0,
+		-0.5, -0.5, -0.5, 1.0, 0.0, 0.0, 0.0, -1.0,
+		-0.5, 0.5, -0.5, 1.0, 1.0, 0.0, 0.0, -1.0,
+		0.5, 0.5, -0.5, 0.0, 1.0, 0.0, 0.0, -1.0,
+
+		// Front quad.
+		-0.5, -0.5, 0.5, 0.0, 0.0, 0.0, 0.0, 1.0,
+		0.5, -0.5, 0.5, 1.0, 0.0, 0.0, 0.0, 1.0,
+		0.5, 0.5, 0.5, 1.0, 1.0, 0.0, 0.0, 1.0,
+		-0.5, 0.5, 0.5, 0.0, 1.0, 0.0, 0.0, 1.0,
+
+		// Left quad.
+		-0.5, -0.5, -0.5, 0.0, 0.0, -1.0, 0.0, 0.0,
+		-0.5, -0.5, 0.5, 1.0, 0.0, -1.0, 0.0, 0.0,
+		-0.5, 0.5, 0.5, 1.0, 1.0, -1.0, 0.0, 0.0,
+		-0.5, 0.5, -0.5, 0.0, 1.0, -1.0, 0.0, 0.0,
+
+		// Right quad.
+		0.5, -0.5, 0.5, 0.0, 0.0, 1.0, 0.0, 0.0,
+		0.5, -0.5, -0.5, 1.0, 0.0, 1.0, 0.0, 0.0,
+		0.5, 0.5, -0.5, 1.0, 1.0, 1.0, 0.0, 0.0,
+		0.5, 0.5, 0.5, 0.0, 1.0, 1.0, 0.0, 0.0,
+
+		// Bottom quad.
+		0.5, -0.5, 0.5, 1.0, 0.0, 0.0, -1.0, 0.0,
+		-0.5, -0.5, 0.5, 0.0, 0.0, 0.0, -1.0, 0.0,
+		-0.5, -0.5, -0.5, 0.0, 1.0, 0.0, -1.0, 0.0,
+		0.5, -0.5, -0.5, 1.0, 1.0, 0.0, -1.0, 0.0,
+
+		// Top quad.
+		-0.5, 0.5, 0.5, 0.0, 0.0, 0.0, 1.0, 0.0,
+		0.5, 0.5, 0.5, 1.0, 0.0, 0.0, 1.0, 0.0,
+		0.5, 0.5, -0.5, 1.0, 1.0, 0.0, 1.0, 0.0,
+		-0.5, 0.5, -0.5, 0.0, 1.0, 0.0, 1.0, 0.0,
+	}
+
+	indices := []uint32{
+		0, 2, 3,
+		0, 1, 2,
+
+		4, 6, 7,
+		4, 5, 6,
+
+		8, 10, 11,
+		8, 9, 10,
+
+		12, 14, 15,
+		12, 13, 14,
+
+		16, 18, 19,
+		16, 17, 18,
+
+		20, 22, 23,
+		20, 21, 22,
+	}
+
+	return &Mesh{Vertices: vertices, Indices: indices}
+}
@@ -0,0 +1,208 @@
+// Package particle simulates particle effects on the CPU, the gfx package takes care of drawing them.
+package particle
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Range is a minimum and maximum, every new particle picks a random value in between.
+type Range struct {
+	Min, Max float32
+}
+
+// sample returns a random value in the range.
+func (r Range) sample(rng *rand.Rand) float32 {
+	return r.Min + rng.Float32()*(r.Max-r.Min)
+}
+
+// Particle is a single particle, its size and color are recalculated every update.
+type Particle struct {
+	Pos, Vel           mgl32.Vec3
+	Age, Life          float32
+	Size               float32
+	Color              mgl32.Vec4
+	startSize, endSize float32
+}
+
+// Emitter spawns particles and simulates them. CreateEmitter sets useful defaults, a zero Emitter spawns nothing
+// until Max is set, and uses seed 0.
+type Emitter struct {
+	Pos mgl32.Vec3
+	// Area is the half size of the box around Pos in which particles spawn.
+	Area mgl32.Vec3
+	// Rate is the amount of particles spawned every second.
+	Rate float32
+	// Max is the maximum amount of particles alive at the same time.
+	Max int
+
+	Life  Range
+	Speed Range
+	// Dir is the direction particles start moving in, Spread is the angle of the cone around it in radians.
+	Dir    mgl32.Vec3
+	Spread float32
+
+	StartSize, EndSize   Range
+	StartColor, EndColor mgl32.Vec4
+
+	Gravity mgl32.Vec3
+	// Drag slows down the particles, 0 means no drag at all.
+	Drag float32
+
+	// Additive makes the particles add up to the color behind them, otherwise they're alpha blended.
+	Additive bool
+	// Workers is the amount of goroutines used for the simulation, 0 or 1 means it's done on the calling goroutine.
+	Workers int
+
+	Particles []Particle
+
+	rng      *rand.Rand
+	spawnAcc float32
+	burst    int
+}
+
+// CreateEmitter returns an emitter at pos. The seed makes the simulation deterministic.
+func CreateEmitter(pos mgl32.Vec3, seed int64) *Emitter {
+	return &Emitter{
+		Pos:        pos,
+		Rate:       10.0,
+		Max:        1000,
+		Life:       Range{1.0, 2.0},
+		Speed:      Range{1.0, 1.0},
+		Dir:        mgl32.Vec3{0.0, 1.0, 0.0},
+		StartSize:  Range{0.1, 0.1},
+		EndSize:    Range{0.1, 0.1},
+		StartColor: mgl32.Vec4{1.0, 1.0, 1.0, 1.0},
+		EndColor:   mgl32.Vec4{1.0, 1.0, 1.0, 0.0},
+		rng:        rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Burst spawns n particles during the next update, on top of the normal rate.
+func (e *Emitter) Burst(n int) {
+	e.burst += n
+}
+
+// Update spawns new particles and moves all of them dt seconds forward.
+func (e *Emitter) Update(dt float32) {
+	// Spawning has to happen in order on one goroutine, otherwise the random numbers would differ between runs.
+	e.spawnAcc += e.Rate * dt
+	n := int(e.spawnAcc)
+	e.spawnAcc -= float32(n)
+	n += e.burst
+	e.burst = 0
+
+	if e.rng == nil {
+		e.rng = rand.New(rand.NewSource(0))
+	}
+	for i := 0; i < n && len(e.Particles) < e.Max; i++ {
+		e.spawn()
+	}
+
+	if e.Workers <= 1 || len(e.Particles) < e.Workers {
+		e.simulate(e.Particles, dt)
+	} else {
+		// Every worker gets its own part of the slice, so the result doesn't depend on scheduling.
+		var wg sync.WaitGroup
+		chunk := (len(e.Particles) + e.Workers - 1) / e.Workers
+		for start := 0; start < len(e.Particles); start += chunk {
+			end := start + chunk
+			if end > len(e.Particles) {
+				end = len(e.Particles)
+			}
+
+			wg.Add(1)
+			go func(p []Particle) {
+				defer wg.Done()
+				e.simulate(p, dt)
+			}(e.Particles[start:end])
+		}
+		wg.Wait()
+	}
+
+	// Remove the dead particles, while keeping the order of the living ones.
+	alive := e.Particles[:0]
+	for _, p := range e.Particles {
+		if p.Age < p.Life {
+			alive = append(alive, p)
+		}
+	}
+	e.Particles = alive
+}
+
+// spawn adds a single new particle.
+func (e *Emitter) spawn() {
+	p := Particle{}
+	p.Pos = e.Pos.Add(mgl32.Vec3{
+		(e.rng.Float32()*2.0 - 1.0) * e.Area.X(),
+		(e.rng.Float32()*2.0 - 1.0) * e.Area.Y(),
+		(e.rng.Float32()*2.0 - 1.0) * e.Area.Z(),
+	})
+	p.Vel = e.randomDir().Mul(e.Speed.sample(e.rng))
+	p.Life = e.Life.sample(e.rng)
+	p.startSize = e.StartSize.sample(e.rng)
+	p.endSize = e.EndSize.sample(e.rng)
+	p.Size = p.startSize
+	p.Color = e.StartColor
+
+	e.Particles = append(e.Particles, p)
+}
+
+// randomDir returns a direction inside the cone around Dir.
+func (e *Emitter) randomDir() mgl32.Vec3 {
+	dir := e.Dir
+	if dir.Len() == 0.0 {
+		dir = mgl32.Vec3{0.0, 1.0, 0.0}
+	}
+	dir = dir.Normalize()
+
+	// Pick a random point on the cap of the cone, around the z axis.
+	cosMax := math.Cos(float64(e.Spread))
+	z := 1.0 - e.rng.Float64()*(1.0-cosMax)
+	r := math.Sqrt(1.0 - z*z)
+	phi := e.rng.Float64() * 2.0 * math.Pi
+	local := mgl32.Vec3{float32(r * math.Cos(phi)), float32(r * math.Sin(phi)), float32(z)}
+
+	// Rotate it so the z axis lines up with the direction.
+	up := mgl32.Vec3{0.0, 1.0, 0.0}
+	if math.Abs(float64(dir.Y())) > 0.99 {
+		up = mgl32.Vec3{1.0, 0.0, 0.0}
+	}
+	tx := up.Cross(dir).Normalize()
+	ty := dir.Cross(tx)
+
+	return tx.Mul(local.X()).Add(ty.Mul(local.Y())).Add(dir.Mul(local.Z()))
+}
+
+// simulate applies gravity and drag and updates size and color over the lifetime.
+func (e *Emitter) simulate(particles []Particle, dt float32) {
+	drag := float32(math.Exp(float64(-e.Drag * dt)))
+
+	for i := range particles {
+		p := &particles[i]
+		p.Age += dt
+
+		p.Vel = p.Vel.Add(e.Gravity.Mul(dt)).Mul(drag)
+		p.Pos = p.Pos.Add(p.Vel.Mul(dt))
+
+		t := p.Age / p.Life
+		if t > 1.0 {
+			t = 1.0
+		}
+		p.Size = p.startSize + (p.endSize-p.startSize)*t
+		p.Color = e.StartColor.Add(e.EndColor.Sub(e.StartColor).Mul(t))
+	}
+}
+
+// SortBackToFront sorts the particles from furthest to closest to the eye, alpha blending needs this.
+func (e *Emitter) SortBackToFront(eye mgl32.Vec3) {
+	sort.SliceStable(e.Particles, func(i, j int) bool {
+		di := e.Particles[i].Pos.Sub(eye)
+		dj := e.Particles[j].Pos.Sub(eye)
+		return di.Dot(di) > dj.Dot(dj)
+	})
+}
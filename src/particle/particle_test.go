@@ -0,0 +1,113 @@
+package particle
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// run updates a new emitter with the seed for a few seconds, with a burst at the start.
+func run(seed int64, workers int) []Particle {
+	e := CreateEmitter(mgl32.Vec3{1.0, 2.0, 3.0}, seed)
+	e.Area = mgl32.Vec3{0.5, 0.5, 0.5}
+	e.Spread = 0.5
+	e.Gravity = mgl32.Vec3{0.0, -9.8, 0.0}
+	e.Drag = 0.2
+	e.Workers = workers
+	e.Burst(20)
+	for i := 0; i < 100; i++ {
+		e.Update(1.0 / 60.0)
+	}
+	return e.Particles
+}
+
+func TestDeterministic(t *testing.T) {
+	a := run(42, 1)
+	b := run(42, 1)
+	if len(a) == 0 {
+		t.Fatal("no particles")
+	}
+	if len(a) != len(b) {
+		t.Fatalf("%v particles, then %v", len(a), len(b))
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("particle %v is %+v, then %+v", i, a[i], b[i])
+		}
+	}
+
+	// The workers only simulate, so the result is the same.
+	c := run(42, 4)
+	for i := range a {
+		if a[i] != c[i] {
+			t.Fatalf("particle %v is %+v with 4 workers, not %+v", i, c[i], a[i])
+		}
+	}
+
+	d := run(43, 1)
+	if len(d) == len(a) && d[0] == a[0] {
+		t.Error("another seed gives the same particles")
+	}
+}
+
+func TestBurstAndLifetime(t *testing.T) {
+	e := CreateEmitter(mgl32.Vec3{}, 1)
+	e.Rate = 0.0
+	e.Life = Range{1.0, 1.0}
+	e.Max = 15
+
+	e.Burst(10)
+	e.Update(0.5)
+	if len(e.Particles) != 10 {
+		t.Fatalf("%v particles after a burst of 10", len(e.Particles))
+	}
+	// More than Max isn't spawned.
+	e.Burst(10)
+	e.Update(0.25)
+	if len(e.Particles) != 15 {
+		t.Fatalf("%v particles, the max is 15", len(e.Particles))
+	}
+	if e.burst != 0 {
+		t.Errorf("%v particles of the burst are left over", e.burst)
+	}
+
+	// The first 10 are 1 second old now, and die.
+	e.Update(0.25)
+	if len(e.Particles) != 5 {
+		t.Fatalf("%v particles after the first burst died", len(e.Particles))
+	}
+	for _, p := range e.Particles {
+		if p.Age != 0.5 {
+			t.Errorf("a particle is %v seconds old, not 0.5", p.Age)
+		}
+		// The color is halfway from the start to the end color.
+		if p.Color[3] != 0.5 {
+			t.Errorf("alpha is %v halfway through the life, not 0.5", p.Color[3])
+		}
+	}
+	e.Update(0.5)
+	if len(e.Particles) != 0 {
+		t.Errorf("%v particles outlived their life", len(e.Particles))
+	}
+}
+
+func TestRate(t *testing.T) {
+	e := CreateEmitter(mgl32.Vec3{}, 1)
+	e.Rate = 10.0
+	e.Life = Range{100.0, 100.0}
+	for i := 0; i < 10; i++ {
+		e.Update(0.1)
+	}
+	if len(e.Particles) != 10 {
+		t.Errorf("%v particles after 1 second at 10 a second", len(e.Particles))
+	}
+}
+
+func TestZeroEmitter(t *testing.T) {
+	e := &Emitter{Rate: 10.0, Max: 10, Life: Range{1.0, 1.0}}
+	e.Burst(2)
+	e.Update(0.5)
+	if len(e.Particles) != 7 {
+		t.Errorf("%v particles, not 7", len(e.Particles))
+	}
+}
@@ -0,0 +1,59 @@
+package particle
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Smoke returns an emitter of slow, growing grey puffs that fade out while rising.
+func Smoke(pos mgl32.Vec3, seed int64) *Emitter {
+	e := CreateEmitter(pos, seed)
+	e.Area = mgl32.Vec3{0.2, 0.0, 0.2}
+	e.Rate = 20.0
+	e.Life = Range{3.0, 5.0}
+	e.Speed = Range{0.3, 0.6}
+	e.Spread = mgl32.DegToRad(15.0)
+	e.StartSize = Range{0.2, 0.4}
+	e.EndSize = Range{1.2, 1.8}
+	e.StartColor = mgl32.Vec4{0.4, 0.4, 0.4, 0.6}
+	e.EndColor = mgl32.Vec4{0.6, 0.6, 0.6, 0.0}
+	e.Gravity = mgl32.Vec3{0.0, 0.2, 0.0}
+	e.Drag = 0.5
+
+	return e
+}
+
+// Sparks returns an emitter of small, fast and bright particles that fall down. Use Burst for an impact.
+func Sparks(pos mgl32.Vec3, seed int64) *Emitter {
+	e := CreateEmitter(pos, seed)
+	e.Rate = 0.0
+	e.Life = Range{0.4, 0.9}
+	e.Speed = Range{3.0, 6.0}
+	e.Spread = mgl32.DegToRad(60.0)
+	e.StartSize = Range{0.03, 0.06}
+	e.EndSize = Range{0.01, 0.02}
+	e.StartColor = mgl32.Vec4{1.0, 0.8, 0.3, 1.0}
+	e.EndColor = mgl32.Vec4{1.0, 0.2, 0.0, 0.0}
+	e.Gravity = mgl32.Vec3{0.0, -9.81, 0.0}
+	e.Drag = 0.2
+	e.Additive = true
+
+	return e
+}
+
+// Dust returns an emitter of tiny particles drifting around in a large area.
+func Dust(pos mgl32.Vec3, seed int64) *Emitter {
+	e := CreateEmitter(pos, seed)
+	e.Area = mgl32.Vec3{5.0, 2.0, 5.0}
+	e.Rate = 30.0
+	e.Life = Range{4.0, 8.0}
+	e.Speed = Range{0.02, 0.1}
+	e.Spread = mgl32.DegToRad(180.0)
+	e.StartSize = Range{0.01, 0.03}
+	e.EndSize = Range{0.01, 0.03}
+	e.StartColor = mgl32.Vec4{0.8, 0.75, 0.6, 0.4}
+	e.EndColor = mgl32.Vec4{0.8, 0.75, 0.6, 0.0}
+	e.Gravity = mgl32.Vec3{0.0, -0.01, 0.0}
+	e.Drag = 1.0
+
+	return e
+}
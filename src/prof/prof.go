@@ -0,0 +1,307 @@
+// Package prof measures how long parts of a frame take, on the CPU and on the GPU. Mark the parts with Begin and
+// End, and call NextFrame once per frame. The gfx package calls it in BeginFrame and sets up the GPU timer.
+package prof
+
+import (
+	"time"
+)
+
+// GPUTimer records timestamps on the GPU. The results come in a few frames later, because the GPU runs behind.
+type GPUTimer interface {
+	// Timestamp records the GPU time once all commands before it are done, and returns an id for the result.
+	Timestamp() uint32
+	// Result returns the time in nanoseconds, and false if it isn't known yet.
+	Result(id uint32) (uint64, bool)
+	// Release gives the id back, once the result was read.
+	Release(id uint32)
+}
+
+// Sample is one Begin and End pair in a frame.
+type Sample struct {
+	Name string
+	// Depth is how many samples it's nested in.
+	Depth int
+	// Start is the time since the start of the frame, and CPU how long it took on the CPU.
+	Start, CPU time.Duration
+	// GPUStart and GPU are the same on the GPU, they're only valid when GPUDone is set.
+	GPUStart, GPU time.Duration
+	GPUDone       bool
+
+	gpuBegin, gpuEnd uint32
+}
+
+// Frame is everything measured between two calls to NextFrame.
+type Frame struct {
+	Index    int
+	Start    time.Time
+	Duration time.Duration
+	Samples  []Sample
+
+	gpuStart uint32
+	pending  int
+}
+
+// GPUDone returns whether all GPU times of the frame are known.
+func (f *Frame) GPUDone() bool {
+	return f.pending == 0
+}
+
+// Profiler keeps a history of frames.
+type Profiler struct {
+	// Enabled can be turned off to make Begin and End do nothing.
+	Enabled bool
+	// History is how many frames are kept.
+	History int
+	// GPU measures the GPU times, without it only the CPU is measured.
+	GPU GPUTimer
+
+	frames  []*Frame
+	current *Frame
+	stack   []int
+	index   int
+}
+
+// CreateProfiler returns a profiler that keeps the last history frames.
+func CreateProfiler(history int) *Profiler {
+	return &Profiler{Enabled: true, History: history}
+}
+
+// Begin starts measuring a part of the frame. Parts can be nested, every Begin needs an End.
+func (p *Profiler) Begin(name string) {
+	if !p.Enabled || p.current == nil {
+		return
+	}
+
+	s := Sample{Name: name, Depth: len(p.stack), Start: time.Since(p.current.Start)}
+	if p.timingGPU() {
+		s.gpuBegin = p.GPU.Timestamp()
+	}
+
+	p.stack = append(p.stack, len(p.current.Samples))
+	p.current.Samples = append(p.current.Samples, s)
+}
+
+// timingGPU returns whether the GPU times of the current frame are measured.
+func (p *Profiler) timingGPU() bool {
+	return p.GPU != nil && p.current.gpuStart != 0
+}
+
+// End stops measuring the last part that was started.
+func (p *Profiler) End() {
+	if !p.Enabled || p.current == nil || len(p.stack) == 0 {
+		return
+	}
+
+	s := &p.current.Samples[p.stack[len(p.stack)-1]]
+	p.stack = p.stack[:len(p.stack)-1]
+
+	s.CPU = time.Since(p.current.Start) - s.Start
+	if p.timingGPU() && s.gpuBegin != 0 {
+		s.gpuEnd = p.GPU.Timestamp()
+		p.current.pending++
+	}
+}
+
+// NextFrame ends the current frame and starts a new one.
+func (p *Profiler) NextFrame() {
+	now := time.Now()
+
+	if p.current != nil {
+		// Parts that weren't ended, end with the frame.
+		for len(p.stack) > 0 {
+			p.End()
+		}
+		p.current.Duration = now.Sub(p.current.Start)
+		p.frames = append(p.frames, p.current)
+	}
+
+	// Forget the oldest frames, and the GPU results they were still waiting on.
+	for len(p.frames) > p.History && len(p.frames) > 0 {
+		p.release(p.frames[0])
+		p.frames = p.frames[1:]
+	}
+
+	p.resolve()
+
+	p.current = &Frame{Index: p.index, Start: now}
+	p.index++
+	if p.GPU != nil && p.Enabled {
+		p.current.gpuStart = p.GPU.Timestamp()
+	}
+}
+
+// resolve reads the GPU results that came in.
+func (p *Profiler) resolve() {
+	if p.GPU == nil {
+		return
+	}
+
+	for _, f := range p.frames {
+		if f.pending == 0 {
+			continue
+		}
+
+		start, ok := p.GPU.Result(f.gpuStart)
+		if !ok {
+			// The frames after this one won't be done either.
+			return
+		}
+
+		for i := range f.Samples {
+			s := &f.Samples[i]
+			if s.GPUDone || s.gpuEnd == 0 {
+				continue
+			}
+
+			begin, ok1 := p.GPU.Result(s.gpuBegin)
+			end, ok2 := p.GPU.Result(s.gpuEnd)
+			if !ok1 || !ok2 {
+				continue
+			}
+			s.GPUStart = time.Duration(begin - start)
+			s.GPU = time.Duration(end - begin)
+			s.GPUDone = true
+			f.pending--
+		}
+
+		if f.pending == 0 {
+			p.release(f)
+		}
+	}
+}
+
+// release gives the GPU timestamps of the frame back.
+func (p *Profiler) release(f *Frame) {
+	if p.GPU == nil || f.gpuStart == 0 {
+		return
+	}
+
+	p.GPU.Release(f.gpuStart)
+	for i := range f.Samples {
+		s := &f.Samples[i]
+		if s.gpuBegin != 0 {
+			p.GPU.Release(s.gpuBegin)
+		}
+		if s.gpuEnd != 0 {
+			p.GPU.Release(s.gpuEnd)
+		}
+		s.gpuBegin, s.gpuEnd = 0, 0
+	}
+	f.gpuStart = 0
+}
+
+// Frames returns the finished frames, oldest first. The last ones might still be waiting on GPU times.
+func (p *Profiler) Frames() []*Frame {
+	return p.frames
+}
+
+// Latest returns the newest frame whose GPU times are all known, or nil.
+func (p *Profiler) Latest() *Frame {
+	for i := len(p.frames) - 1; i >= 0; i-- {
+		if p.frames[i].GPUDone() {
+			return p.frames[i]
+		}
+	}
+
+	return nil
+}
+
+// FPS returns the frames per second, averaged over the frames of the last second.
+func (p *Profiler) FPS() float32 {
+	var total time.Duration
+	n := 0
+	for i := len(p.frames) - 1; i >= 0 && total < time.Second; i-- {
+		total += p.frames[i].Duration
+		n++
+	}
+	if total == 0 {
+		return 0.0
+	}
+
+	return float32(float64(n) / total.Seconds())
+}
+
+// Stat is the average, minimum and maximum time of a part over the history.
+type Stat struct {
+	Name                string
+	Count               int
+	CPU, CPUMin, CPUMax time.Duration
+	GPU, GPUMin, GPUMax time.Duration
+}
+
+// Stats returns the times of every part, averaged over the history, in the order they first appear.
+func (p *Profiler) Stats() []Stat {
+	var stats []Stat
+	index := make(map[string]int)
+	gpuCount := make(map[string]int)
+
+	for _, f := range p.frames {
+		for _, s := range f.Samples {
+			i, ok := index[s.Name]
+			if !ok {
+				i = len(stats)
+				index[s.Name] = i
+				stats = append(stats, Stat{Name: s.Name, CPUMin: s.CPU})
+			}
+
+			st := &stats[i]
+			st.Count++
+			st.CPU += s.CPU
+			st.CPUMin, st.CPUMax = minDuration(st.CPUMin, s.CPU), maxDuration(st.CPUMax, s.CPU)
+			if s.GPUDone {
+				if gpuCount[s.Name] == 0 {
+					st.GPUMin = s.GPU
+				}
+				gpuCount[s.Name]++
+				st.GPU += s.GPU
+				st.GPUMin, st.GPUMax = minDuration(st.GPUMin, s.GPU), maxDuration(st.GPUMax, s.GPU)
+			}
+		}
+	}
+
+	for i := range stats {
+		stats[i].CPU /= time.Duration(stats[i].Count)
+		if n := gpuCount[stats[i].Name]; n > 0 {
+			stats[i].GPU /= time.Duration(n)
+		}
+	}
+
+	return stats
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Default is the profiler used by the package functions.
+var Default = CreateProfiler(240)
+
+// Begin starts measuring a part of the frame with the Default profiler.
+func Begin(name string) {
+	Default.Begin(name)
+}
+
+// End stops measuring the last part that was started with the Default profiler.
+func End() {
+	Default.End()
+}
+
+// NextFrame starts a new frame of the Default profiler.
+func NextFrame() {
+	Default.NextFrame()
+}
+
+// FPS returns the frames per second of the Default profiler, averaged over the last second.
+func FPS() float32 {
+	return Default.FPS()
+}
@@ -0,0 +1,208 @@
+package prof
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeTimer hands out ids counting up from 1. Once ready is set, the result of id is id microseconds.
+type fakeTimer struct {
+	next     uint32
+	ready    bool
+	live     map[uint32]bool
+	released []uint32
+}
+
+func createFakeTimer() *fakeTimer {
+	return &fakeTimer{live: make(map[uint32]bool)}
+}
+
+func (t *fakeTimer) Timestamp() uint32 {
+	t.next++
+	t.live[t.next] = true
+	return t.next
+}
+
+func (t *fakeTimer) Result(id uint32) (uint64, bool) {
+	return uint64(id) * uint64(time.Microsecond), t.ready
+}
+
+func (t *fakeTimer) Release(id uint32) {
+	if !t.live[id] {
+		panic("an id was released twice, or was never handed out")
+	}
+	delete(t.live, id)
+	t.released = append(t.released, id)
+}
+
+func TestNesting(t *testing.T) {
+	p := CreateProfiler(4)
+	p.NextFrame()
+	p.Begin("a")
+	p.Begin("b")
+	time.Sleep(2 * time.Millisecond)
+	p.End()
+	p.End()
+	p.Begin("c")
+	p.End()
+	p.NextFrame()
+
+	f := p.Frames()[0]
+	if len(f.Samples) != 3 {
+		t.Fatalf("got %v samples, want 3", len(f.Samples))
+	}
+	a, b, c := f.Samples[0], f.Samples[1], f.Samples[2]
+	if a.Name != "a" || a.Depth != 0 || b.Name != "b" || b.Depth != 1 || c.Name != "c" || c.Depth != 0 {
+		t.Errorf("the samples are %+v", f.Samples)
+	}
+	if b.CPU < 2*time.Millisecond || a.CPU < b.CPU {
+		t.Errorf("a took %v and b %v, want b at least 2ms and a longer", a.CPU, b.CPU)
+	}
+	if b.Start < a.Start || c.Start < a.Start+a.CPU || f.Duration < c.Start+c.CPU {
+		t.Errorf("the samples aren't in order: %+v in a frame of %v", f.Samples, f.Duration)
+	}
+}
+
+func TestUnendedSamples(t *testing.T) {
+	p := CreateProfiler(4)
+	p.NextFrame()
+	p.Begin("a")
+	p.Begin("b")
+	time.Sleep(time.Millisecond)
+	p.NextFrame()
+
+	if len(p.stack) != 0 {
+		t.Errorf("%v samples are still open", len(p.stack))
+	}
+	for _, s := range p.Frames()[0].Samples {
+		if s.CPU < time.Millisecond {
+			t.Errorf("%v took %v, want it ended with the frame", s.Name, s.CPU)
+		}
+	}
+
+	// An End without a Begin does nothing.
+	p.End()
+	p.Begin("c")
+	p.End()
+	if n := len(p.current.Samples); n != 1 {
+		t.Errorf("the new frame has %v samples, want 1", n)
+	}
+}
+
+func TestLateGPUResults(t *testing.T) {
+	timer := createFakeTimer()
+	p := CreateProfiler(4)
+	p.GPU = timer
+
+	// The frame starts at id 1, the sample is 2 and 3.
+	p.NextFrame()
+	p.Begin("a")
+	p.End()
+	p.NextFrame()
+
+	f := p.Frames()[0]
+	if f.GPUDone() || f.Samples[0].GPUDone || p.Latest() != nil {
+		t.Fatalf("the GPU times are done before the results came in")
+	}
+
+	timer.ready = true
+	p.NextFrame()
+	s := f.Samples[0]
+	if !f.GPUDone() || !s.GPUDone || s.GPUStart != time.Microsecond || s.GPU != time.Microsecond {
+		t.Errorf("the sample has GPU start %v and time %v, want 1µs and 1µs", s.GPUStart, s.GPU)
+	}
+	if p.Latest() == nil {
+		t.Errorf("there is no frame with GPU times")
+	}
+	if want := []uint32{1, 2, 3}; !reflect.DeepEqual(timer.released[:3], want) {
+		t.Errorf("released %v, want %v first", timer.released, want)
+	}
+}
+
+func TestHistoryReleases(t *testing.T) {
+	timer := createFakeTimer()
+	p := CreateProfiler(2)
+	p.GPU = timer
+
+	// The results never come, so only forgetting the frames gives the ids back.
+	for i := 0; i < 10; i++ {
+		p.NextFrame()
+		p.Begin("a")
+		p.End()
+	}
+	if len(p.Frames()) != 2 {
+		t.Errorf("%v frames are kept, want 2", len(p.Frames()))
+	}
+	// Two kept frames and the current one, with 3 ids each.
+	if len(timer.live) != 9 {
+		t.Errorf("%v ids are in use, want 9", len(timer.live))
+	}
+	if p.Frames()[0].Index != 7 {
+		t.Errorf("the oldest frame is %v, want 7", p.Frames()[0].Index)
+	}
+}
+
+func TestStats(t *testing.T) {
+	ms := time.Millisecond
+	p := CreateProfiler(4)
+	p.frames = []*Frame{
+		{Samples: []Sample{{Name: "a", CPU: 2 * ms, GPU: 4 * ms, GPUDone: true}, {Name: "b", CPU: 1 * ms}}},
+		{Samples: []Sample{{Name: "a", CPU: 4 * ms, GPU: 1 * ms, GPUDone: true}}},
+		// The GPU time isn't known yet, so it doesn't count.
+		{Samples: []Sample{{Name: "a", CPU: 6 * ms}, {Name: "b", CPU: 3 * ms}}},
+	}
+
+	want := []Stat{
+		{Name: "a", Count: 3, CPU: 4 * ms, CPUMin: 2 * ms, CPUMax: 6 * ms, GPU: 2500 * time.Microsecond, GPUMin: ms,
+			GPUMax: 4 * ms},
+		{Name: "b", Count: 2, CPU: 2 * ms, CPUMin: 1 * ms, CPUMax: 3 * ms},
+	}
+	if got := p.Stats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestWriteTrace(t *testing.T) {
+	origin := time.Now()
+	p := CreateProfiler(4)
+	p.frames = []*Frame{
+		{Start: origin, Duration: 10 * time.Millisecond, Samples: []Sample{
+			{Name: "a", Start: time.Millisecond, CPU: 2 * time.Millisecond, GPUStart: 3 * time.Millisecond,
+				GPU: time.Millisecond, GPUDone: true},
+		}},
+		{Start: origin.Add(10 * time.Millisecond), Duration: 5 * time.Millisecond, Samples: []Sample{
+			{Name: "b", Start: 0, CPU: time.Millisecond},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := p.WriteTrace(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var trace struct {
+		TraceEvents []traceEvent `json:"traceEvents"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &trace); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []traceEvent{
+		{"frame", "frame", "X", 0, 10000, 1, traceCPU},
+		{"a", "cpu", "X", 1000, 2000, 1, traceCPU},
+		{"a", "gpu", "X", 3000, 1000, 1, traceGPU},
+		{"frame", "frame", "X", 10000, 5000, 1, traceCPU},
+		{"b", "cpu", "X", 10000, 1000, 1, traceCPU},
+	}
+	if !reflect.DeepEqual(trace.TraceEvents, want) {
+		t.Errorf("got %+v\nwant %+v", trace.TraceEvents, want)
+	}
+
+	// Without frames it's still a valid trace.
+	buf.Reset()
+	if err := CreateProfiler(4).WriteTrace(&buf); err != nil || buf.String() != "{\"traceEvents\":[]}\n" {
+		t.Errorf("an empty profiler wrote %q, %v", buf.String(), err)
+	}
+}
@@ -0,0 +1,57 @@
+package prof
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// traceEvent is a complete event of the Chrome trace format, the times are in microseconds.
+type traceEvent struct {
+	Name      string  `json:"name"`
+	Category  string  `json:"cat"`
+	Phase     string  `json:"ph"`
+	Timestamp float64 `json:"ts"`
+	Duration  float64 `json:"dur"`
+	Process   int     `json:"pid"`
+	Thread    int     `json:"tid"`
+}
+
+// The threads the CPU and GPU times are shown on.
+const (
+	traceCPU = 1
+	traceGPU = 2
+)
+
+// WriteTrace writes the history in the Chrome trace format, which can be opened in chrome://tracing or Perfetto.
+// The GPU times are shown on their own row, starting at the same time as the frame on the CPU.
+func (p *Profiler) WriteTrace(w io.Writer) error {
+	events := []traceEvent{}
+	if len(p.frames) == 0 {
+		return json.NewEncoder(w).Encode(map[string]interface{}{"traceEvents": events})
+	}
+
+	origin := p.frames[0].Start
+	us := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Microsecond)
+	}
+
+	for _, f := range p.frames {
+		start := f.Start.Sub(origin)
+		events = append(events, traceEvent{"frame", "frame", "X", us(start), us(f.Duration), 1, traceCPU})
+
+		for _, s := range f.Samples {
+			events = append(events, traceEvent{s.Name, "cpu", "X", us(start + s.Start), us(s.CPU), 1, traceCPU})
+			if s.GPUDone {
+				events = append(events, traceEvent{s.Name, "gpu", "X", us(start + s.GPUStart), us(s.GPU), 1, traceGPU})
+			}
+		}
+	}
+
+	return json.NewEncoder(w).Encode(map[string]interface{}{"traceEvents": events})
+}
+
+// WriteTrace writes the history of the Default profiler in the Chrome trace format.
+func WriteTrace(w io.Writer) error {
+	return Default.WriteTrace(w)
+}
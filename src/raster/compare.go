@@ -0,0 +1,43 @@
+package raster
+
+import (
+	"fmt"
+	"image"
+)
+
+// Compare returns the largest difference of any channel between two images, and the average difference,
+// both from 0 to 255. Use it to check the OpenGL output against an image from the Renderer.
+func Compare(a, b image.Image) (max uint8, mean float64, err error) {
+	if a.Bounds().Size() != b.Bounds().Size() {
+		return 0, 0.0, fmt.Errorf("images have different sizes, %v and %v", a.Bounds().Size(), b.Bounds().Size())
+	}
+
+	size := a.Bounds().Size()
+	var sum uint64
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			r1, g1, b1, a1 := a.At(a.Bounds().Min.X+x, a.Bounds().Min.Y+y).RGBA()
+			r2, g2, b2, a2 := b.At(b.Bounds().Min.X+x, b.Bounds().Min.Y+y).RGBA()
+			for _, d := range [4]uint8{diff(r1, r2), diff(g1, g2), diff(b1, b2), diff(a1, a2)} {
+				sum += uint64(d)
+				if d > max {
+					max = d
+				}
+			}
+		}
+	}
+
+	if n := size.X * size.Y * 4; n > 0 {
+		mean = float64(sum) / float64(n)
+	}
+
+	return max, mean, nil
+}
+
+// diff returns the difference of two 16 bit channels, as 8 bit.
+func diff(a, b uint32) uint8 {
+	if a > b {
+		return uint8((a - b) >> 8)
+	}
+	return uint8((b - a) >> 8)
+}
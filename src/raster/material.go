@@ -0,0 +1,148 @@
+package raster
+
+import (
+	"image"
+	"image/draw"
+	"math"
+	"os"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"GopherGL/src/render"
+)
+
+// Texture is an image that's sampled like the textures in gfx: bilinear and clamped to the edges.
+type Texture struct {
+	width, height int
+	pix           []uint8
+}
+
+// CreateTexture copies the image into a texture.
+func CreateTexture(img image.Image) *Texture {
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	return &Texture{rgba.Rect.Dx(), rgba.Rect.Dy(), rgba.Pix}
+}
+
+// LoadTexture reads an image file into a texture.
+func LoadTexture(file string) (*Texture, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return CreateTexture(img), nil
+}
+
+// texel returns a pixel, with 0, 0 in the bottom left like OpenGL.
+func (t *Texture) texel(x, y int) mgl32.Vec4 {
+	x = clampInt(x, 0, t.width-1)
+	y = clampInt(t.height-1-y, 0, t.height-1)
+	p := t.pix[(y*t.width+x)*4:]
+
+	return mgl32.Vec4{float32(p[0]), float32(p[1]), float32(p[2]), float32(p[3])}.Mul(1.0 / 255.0)
+}
+
+// Sample returns the color at the texture coordinates.
+func (t *Texture) Sample(u, v float32) mgl32.Vec4 {
+	x, y := u*float32(t.width)-0.5, v*float32(t.height)-0.5
+	fx, fy := float32(math.Floor(float64(x))), float32(math.Floor(float64(y)))
+	ix, iy := int(fx), int(fy)
+	tx, ty := x-fx, y-fy
+
+	bottom := t.texel(ix, iy).Mul(1.0 - tx).Add(t.texel(ix+1, iy).Mul(tx))
+	top := t.texel(ix, iy+1).Mul(1.0 - tx).Add(t.texel(ix+1, iy+1).Mul(tx))
+
+	return bottom.Mul(1.0 - ty).Add(top.Mul(ty))
+}
+
+// Material is an albedo and specular texture, like gfx.Material.
+type Material struct {
+	Diffuse, Specular *Texture
+	Shininess         float32
+}
+
+// LoadMaterial reads the albedo and specular texture.
+func LoadMaterial(fileTex, fileSpec string, shininess float32) (*Material, error) {
+	diff, err := LoadTexture(fileTex)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := LoadTexture(fileSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Material{diff, spec, shininess}, nil
+}
+
+// fragmentShader is basic.glsl written in Go.
+type fragmentShader struct {
+	mat         *Material
+	sun         render.DirectionalLight
+	pointLights []render.PointLight
+	viewPos     mgl32.Vec3
+}
+
+// shade returns the color of a pixel.
+func (f *fragmentShader) shade(in *varyings) mgl32.Vec4 {
+	fragPos := mgl32.Vec3{in[0], in[1], in[2]}
+	u, v := in[3], in[4]
+	albedo := f.mat.Diffuse.Sample(u, v).Vec3()
+	specTex := f.mat.Specular.Sample(u, v).Vec3()
+
+	// Minimum light.
+	ambient := albedo.Mul(0.1)
+
+	// Diffuse lighting.
+	lightDir := f.sun.Dir.Mul(-1.0).Normalize()
+	norm := mgl32.Vec3{in[5], in[6], in[7]}.Normalize()
+	diff := float32(math.Max(float64(norm.Dot(lightDir)), 0.0))
+	diffuse := mulVec3(f.sun.Color.Mul(f.sun.Intensity*diff), albedo)
+
+	// Specularity, the shiny effect when right in the light.
+	viewDir := f.viewPos.Sub(fragPos).Normalize()
+	spec := shininess(viewDir, lightDir, norm)
+	specular := specTex.Mul(f.mat.Shininess * spec)
+
+	// The point lights, the same but fading with the distance.
+	for _, l := range f.pointLights {
+		dir := l.Position.Sub(fragPos).Normalize()
+		light := l.Color.Mul(attenuation(l, fragPos))
+		diff = float32(math.Max(float64(norm.Dot(dir)), 0.0))
+		diffuse = diffuse.Add(mulVec3(light.Mul(diff), albedo))
+		spec = shininess(viewDir, dir, norm)
+		specular = specular.Add(mulVec3(light.Mul(f.mat.Shininess*spec), specTex))
+	}
+
+	return ambient.Add(diffuse).Add(specular).Vec4(1.0)
+}
+
+// shininess is how much of the light going in lightDir is reflected towards the viewer.
+func shininess(viewDir, lightDir, norm mgl32.Vec3) float32 {
+	reflectDir := reflect(lightDir.Mul(-1.0), norm)
+	return float32(math.Pow(math.Max(float64(viewDir.Dot(reflectDir)), 0.0), 32))
+}
+
+// attenuation is the function of the same name in lights.glsl.
+func attenuation(l render.PointLight, pos mgl32.Vec3) float32 {
+	d := l.Position.Sub(pos).Len()
+	return 1.0 / (l.Constant + l.Linear*d + l.Quadratic*d*d)
+}
+
+// mulVec3 multiplies the vectors by component, like * does in GLSL.
+func mulVec3(a, b mgl32.Vec3) mgl32.Vec3 {
+	return mgl32.Vec3{a[0] * b[0], a[1] * b[1], a[2] * b[2]}
+}
+
+// reflect is the GLSL function of the same name.
+func reflect(i, n mgl32.Vec3) mgl32.Vec3 {
+	return i.Sub(n.Mul(2.0 * n.Dot(i)))
+}
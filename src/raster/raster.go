@@ -0,0 +1,271 @@
+// Package raster is a software renderer that draws the same way as the gfx package, without a GPU or OpenGL.
+// It's slow, but good for tests and for reference images to compare the OpenGL output against.
+package raster
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"GopherGL/src/camera"
+	"GopherGL/src/mesh"
+	"GopherGL/src/render"
+)
+
+// Entity is a mesh with a material and a transform, like gfx.Entity.
+type Entity struct {
+	Mesh  *mesh.Mesh
+	Trans mgl32.Mat4
+	Mat   *Material
+}
+
+// CreateEntity returns an Entity at the origin.
+func CreateEntity(m *mesh.Mesh, mat *Material) *Entity {
+	return &Entity{m, mgl32.Ident4(), mat}
+}
+
+// SetTransform replaces the transform.
+func (e *Entity) SetTransform(trans mgl32.Mat4) {
+	e.Trans = trans
+}
+
+// Renderer has a color and depth buffer. Like OpenGL, row 0 is the bottom of the image.
+type Renderer struct {
+	Width, Height int
+	color         []mgl32.Vec4
+	depth         []float32
+	pointLights   []render.PointLight
+}
+
+var _ render.Renderer = (*Renderer)(nil)
+
+// CreateRenderer returns a renderer with buffers of the size.
+func CreateRenderer(width, height int) *Renderer {
+	return &Renderer{
+		Width:  width,
+		Height: height,
+		color:  make([]mgl32.Vec4, width*height),
+		depth:  make([]float32, width*height),
+	}
+}
+
+// CreateEntity loads the textures with LoadMaterial and returns an Entity at the origin.
+func (r *Renderer) CreateEntity(m *mesh.Mesh, fileTex, fileSpec string, shininess float32) (render.Entity, error) {
+	mat, err := LoadMaterial(fileTex, fileSpec, shininess)
+	if err != nil {
+		return nil, err
+	}
+
+	return CreateEntity(m, mat), nil
+}
+
+// SetPointLights sets the point lights that light up the entities, at most render.MaxPointLights.
+func (r *Renderer) SetPointLights(lights ...render.PointLight) error {
+	if len(lights) > render.MaxPointLights {
+		return fmt.Errorf("%v point lights, the maximum is %v", len(lights), render.MaxPointLights)
+	}
+	r.pointLights = append(r.pointLights[:0], lights...)
+
+	return nil
+}
+
+// BeginFrame clears the buffers to the same color as gfx.BeginFrame.
+func (r *Renderer) BeginFrame() {
+	for i := range r.color {
+		r.color[i] = mgl32.Vec4{0.2, 0.3, 0.3, 1.0}
+		r.depth[i] = 1.0
+	}
+}
+
+// Image returns the color buffer as an image, with the top row first.
+func (r *Renderer) Image() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, r.Width, r.Height))
+	for y := 0; y < r.Height; y++ {
+		for x := 0; x < r.Width; x++ {
+			c := r.color[(r.Height-1-y)*r.Width+x]
+			img.SetRGBA(x, y, color.RGBA{toByte(c[0]), toByte(c[1]), toByte(c[2]), toByte(c[3])})
+		}
+	}
+
+	return img
+}
+
+// toByte converts a color channel the way OpenGL does for 8 bit buffers.
+func toByte(f float32) uint8 {
+	return uint8(math.Floor(float64(mgl32.Clamp(f, 0.0, 1.0))*255.0 + 0.5))
+}
+
+// Depth returns the depth of a pixel, from 0 at the near plane to 1 at the far plane.
+func (r *Renderer) Depth(x, y int) float32 {
+	return r.depth[y*r.Width+x]
+}
+
+// varyings are what the vertex shader passes to the fragment shader: position, texture coordinates and normal.
+type varyings [8]float32
+
+// vertex is a transformed vertex.
+type vertex struct {
+	clip mgl32.Vec4
+	out  varyings
+}
+
+// lerp interpolates between two vertices, for clipping.
+func (a vertex) lerp(b vertex, t float32) vertex {
+	v := vertex{clip: a.clip.Add(b.clip.Sub(a.clip).Mul(t))}
+	for i := range v.out {
+		v.out[i] = a.out[i] + (b.out[i]-a.out[i])*t
+	}
+
+	return v
+}
+
+// Render draws an Entity of this package with the same lighting as basic.glsl.
+func (r *Renderer) Render(c *camera.Camera, re render.Entity, sun render.DirectionalLight) {
+	e := re.(*Entity)
+	m := e.Mesh
+	mvp := c.Proj.Mul4(c.View).Mul4(e.Trans)
+	normalMat := e.Trans.Mat3().Inv().Transpose()
+
+	// Vertex shader.
+	verts := make([]vertex, m.VertexCount())
+	for i := range verts {
+		v := m.Vertices[i*mesh.VertexSize : (i+1)*mesh.VertexSize]
+		pos := mgl32.Vec4{v[0], v[1], v[2], 1.0}
+		world := e.Trans.Mul4x1(pos)
+		normal := normalMat.Mul3x1(mgl32.Vec3{v[5], v[6], v[7]})
+
+		verts[i].clip = mvp.Mul4x1(pos)
+		verts[i].out = varyings{world[0], world[1], world[2], v[3], v[4], normal[0], normal[1], normal[2]}
+	}
+
+	frag := fragmentShader{mat: e.Mat, sun: sun, pointLights: r.pointLights, viewPos: c.Pos}
+	for i := 0; i+2 < len(m.Indices); i += 3 {
+		tri := []vertex{verts[m.Indices[i]], verts[m.Indices[i+1]], verts[m.Indices[i+2]]}
+		for _, t := range clip(tri) {
+			r.rasterize(t, &frag)
+		}
+	}
+}
+
+// clip cuts the triangle by the near and far plane, and returns it as a fan of triangles. The other planes
+// don't need clipping, the rasterizer only looks at pixels on the screen anyway.
+func clip(poly []vertex) [][3]vertex {
+	// Inside is -w <= z <= w.
+	planes := []func(v mgl32.Vec4) float32{
+		func(v mgl32.Vec4) float32 { return v.Z() + v.W() },
+		func(v mgl32.Vec4) float32 { return v.W() - v.Z() },
+	}
+
+	for _, dist := range planes {
+		var out []vertex
+		for i := range poly {
+			a, b := poly[i], poly[(i+1)%len(poly)]
+			da, db := dist(a.clip), dist(b.clip)
+			if da >= 0.0 {
+				out = append(out, a)
+			}
+			if (da >= 0.0) != (db >= 0.0) {
+				out = append(out, a.lerp(b, da/(da-db)))
+			}
+		}
+		poly = out
+	}
+
+	var tris [][3]vertex
+	for i := 2; i < len(poly); i++ {
+		tris = append(tris, [3]vertex{poly[0], poly[i-1], poly[i]})
+	}
+
+	return tris
+}
+
+// rasterize fills the pixels whose centers are inside the triangle, with back faces culled like CreateWindow sets up.
+func (r *Renderer) rasterize(t [3]vertex, frag *fragmentShader) {
+	// Perspective divide and viewport transform.
+	var sx, sy, sz, invW [3]float32
+	for i, v := range t {
+		invW[i] = 1.0 / v.clip.W()
+		sx[i] = (v.clip.X()*invW[i]*0.5 + 0.5) * float32(r.Width)
+		sy[i] = (v.clip.Y()*invW[i]*0.5 + 0.5) * float32(r.Height)
+		sz[i] = v.clip.Z()*invW[i]*0.5 + 0.5
+	}
+
+	// Counter-clockwise triangles are the front faces.
+	area := (sx[1]-sx[0])*(sy[2]-sy[0]) - (sx[2]-sx[0])*(sy[1]-sy[0])
+	if area <= 0.0 {
+		return
+	}
+
+	minX := clampInt(int(floor3(sx)), 0, r.Width-1)
+	maxX := clampInt(int(ceil3(sx)), 0, r.Width-1)
+	minY := clampInt(int(floor3(sy)), 0, r.Height-1)
+	maxY := clampInt(int(ceil3(sy)), 0, r.Height-1)
+
+	for y := minY; y <= maxY; y++ {
+		py := float32(y) + 0.5
+		for x := minX; x <= maxX; x++ {
+			px := float32(x) + 0.5
+
+			// Barycentric coordinates, the weight of each vertex is the area of the opposite edge and the pixel.
+			var b [3]float32
+			inside := true
+			for i := 0; i < 3; i++ {
+				j, k := (i+1)%3, (i+2)%3
+				b[i] = (sx[k]-sx[j])*(py-sy[j]) - (sy[k]-sy[j])*(px-sx[j])
+				if b[i] < 0.0 || b[i] == 0.0 && !topLeft(sx[j], sy[j], sx[k], sy[k]) {
+					inside = false
+					break
+				}
+			}
+			if !inside {
+				continue
+			}
+			b[0], b[1], b[2] = b[0]/area, b[1]/area, b[2]/area
+
+			// Depth is linear in screen space.
+			i := y*r.Width + x
+			z := b[0]*sz[0] + b[1]*sz[1] + b[2]*sz[2]
+			if z >= r.depth[i] {
+				continue
+			}
+
+			// The rest isn't, so interpolate by 1/w and divide it out again.
+			pw := [3]float32{b[0] * invW[0], b[1] * invW[1], b[2] * invW[2]}
+			sum := pw[0] + pw[1] + pw[2]
+			var in varyings
+			for n := range in {
+				in[n] = (pw[0]*t[0].out[n] + pw[1]*t[1].out[n] + pw[2]*t[2].out[n]) / sum
+			}
+
+			r.depth[i] = z
+			r.color[i] = frag.shade(&in)
+		}
+	}
+}
+
+// topLeft returns whether the edge from a to b is a top or left edge, pixels exactly on those are drawn.
+// This keeps pixels on an edge shared by two triangles from being drawn twice or not at all.
+func topLeft(ax, ay, bx, by float32) bool {
+	return ay == by && bx < ax || by < ay
+}
+
+func floor3(v [3]float32) float32 {
+	return float32(math.Floor(math.Min(float64(v[0]), math.Min(float64(v[1]), float64(v[2])))))
+}
+
+func ceil3(v [3]float32) float32 {
+	return float32(math.Ceil(math.Max(float64(v[0]), math.Max(float64(v[1]), float64(v[2])))))
+}
+
+func clampInt(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
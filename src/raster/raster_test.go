@@ -0,0 +1,136 @@
+package raster
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"GopherGL/src/camera"
+	"GopherGL/src/mesh"
+	"GopherGL/src/render"
+)
+
+// quad returns a 2 by 2 quad at the origin, facing the camera.
+func quad() *mesh.Mesh {
+	return &mesh.Mesh{
+		Vertices: []float32{
+			-1.0, -1.0, 0.0, 0.0, 0.0, 0.0, 0.0, 1.0,
+			1.0, -1.0, 0.0, 1.0, 0.0, 0.0, 0.0, 1.0,
+			1.0, 1.0, 0.0, 1.0, 1.0, 0.0, 0.0, 1.0,
+			-1.0, 1.0, 0.0, 0.0, 1.0, 0.0, 0.0, 1.0,
+		},
+		Indices: []uint32{0, 1, 2, 0, 2, 3},
+	}
+}
+
+// writePNG writes a 1 by 1 image of the color.
+func writePNG(t *testing.T, file string, c color.RGBA) {
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	img.SetRGBA(0, 0, c)
+
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// drawQuad draws the quad with a white albedo and a specular texture of the gray level, only through the
+// render.Renderer interface.
+func drawQuad(t *testing.T, r render.Renderer, spec uint8, shininess float32, sun render.DirectionalLight,
+	lights []render.PointLight) {
+	dir, err := ioutil.TempDir("", "raster")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileTex, fileSpec := filepath.Join(dir, "diffuse.png"), filepath.Join(dir, "specular.png")
+	writePNG(t, fileTex, color.RGBA{255, 255, 255, 255})
+	writePNG(t, fileSpec, color.RGBA{spec, spec, spec, 255})
+
+	e, err := r.CreateEntity(quad(), fileTex, fileSpec, shininess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetPointLights(lights...); err != nil {
+		t.Fatal(err)
+	}
+	r.BeginFrame()
+	r.Render(camera.CreateCamera(mgl32.Vec3{0.0, 0.0, 3.0}, 1.0, 45.0), e, sun)
+}
+
+func TestRenderLitQuad(t *testing.T) {
+	front := mgl32.Vec3{0.0, 0.0, -1.0}
+	tests := []struct {
+		name      string
+		spec      uint8
+		shininess float32
+		sun       render.DirectionalLight
+		lights    []render.PointLight
+		// want is the color in the middle, from 0 to 1.
+		want mgl32.Vec3
+	}{
+		{
+			// Ambient plus the color of the sun times the intensity.
+			name: "sun color and intensity",
+			sun:  render.DirectionalLight{Dir: front, Color: mgl32.Vec3{1.0, 0.5, 0.25}, Intensity: 0.8},
+			want: mgl32.Vec3{0.9, 0.5, 0.3},
+		},
+		{
+			// The sun is behind the quad, the light 2 units in front adds 1 / (1 + 0.09*2 + 0.032*4) of its color.
+			name:   "point light",
+			sun:    render.DirectionalLight{Dir: front.Mul(-1.0), Color: mgl32.Vec3{1.0, 1.0, 1.0}, Intensity: 1.0},
+			lights: []render.PointLight{render.CreatePointLight(mgl32.Vec3{0.0, 0.0, 2.0}, mgl32.Vec3{0.0, 1.0, 0.0})},
+			want:   mgl32.Vec3{0.1, 0.1 + 1.0/1.308, 0.1},
+		},
+		{
+			// The reflection goes straight back to the camera, the specular texture times the shininess is added.
+			name:      "specular",
+			spec:      255,
+			shininess: 0.25,
+			sun:       render.DirectionalLight{Dir: front, Color: mgl32.Vec3{1.0, 1.0, 1.0}, Intensity: 0.5},
+			want:      mgl32.Vec3{0.85, 0.85, 0.85},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := CreateRenderer(32, 32)
+			drawQuad(t, r, test.spec, test.shininess, test.sun, test.lights)
+			img := r.Image()
+
+			got := img.RGBAAt(16, 16)
+			want := [3]uint8{toByte(test.want[0]), toByte(test.want[1]), toByte(test.want[2])}
+			for i, c := range [3]uint8{got.R, got.G, got.B} {
+				if d := int(c) - int(want[i]); d < -1 || d > 1 {
+					t.Fatalf("the middle is %v, want %v", got, want)
+				}
+			}
+
+			// The corners are outside the quad, they keep the clear color.
+			if c := img.RGBAAt(0, 0); c != (color.RGBA{51, 77, 77, 255}) {
+				t.Errorf("the corner is %v, want the clear color", c)
+			}
+			if r.Depth(16, 16) >= 1.0 {
+				t.Errorf("the depth in the middle wasn't written")
+			}
+		})
+	}
+}
+
+func TestTooManyPointLights(t *testing.T) {
+	r := CreateRenderer(1, 1)
+	if err := r.SetPointLights(make([]render.PointLight, render.MaxPointLights+1)...); err == nil {
+		t.Errorf("got no error for %v point lights", render.MaxPointLights+1)
+	}
+}
@@ -0,0 +1,49 @@
+// Package render is the draw interface the renderers share. Code that draws with a Renderer runs on the GPU with
+// gfx.Renderer, or without one with raster.Renderer, so it can run in tests and be compared between the two.
+package render
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"GopherGL/src/camera"
+	"GopherGL/src/mesh"
+)
+
+// MaxPointLights is how many point lights SetPointLights takes, the same for every renderer.
+const MaxPointLights = 8
+
+// DirectionalLight is a light like a sun, where only the direction matters. Color is multiplied by Intensity.
+type DirectionalLight struct {
+	Dir, Color mgl32.Vec3
+	Intensity  float32
+}
+
+// PointLight shines in all directions from its position, fading with the distance d by
+// 1 / (Constant + Linear*d + Quadratic*d*d).
+type PointLight struct {
+	Position, Color             mgl32.Vec3
+	Constant, Linear, Quadratic float32
+}
+
+// CreatePointLight returns a light at the position that reaches about 50 units, like gfx.CreatePointLight.
+func CreatePointLight(pos, color mgl32.Vec3) PointLight {
+	return PointLight{pos, color, 1.0, 0.09, 0.032}
+}
+
+// Entity is a mesh made ready to draw by a Renderer, it can only be drawn by the Renderer that made it.
+type Entity interface {
+	// SetTransform places the entity in the world.
+	SetTransform(trans mgl32.Mat4)
+}
+
+// Renderer draws entities with the Phong lighting of basic.glsl.
+type Renderer interface {
+	// CreateEntity makes an Entity of the mesh at the origin, with an albedo and specular texture file.
+	CreateEntity(m *mesh.Mesh, fileTex, fileSpec string, shininess float32) (Entity, error)
+	// SetPointLights sets the point lights for the entities rendered after it, at most MaxPointLights.
+	SetPointLights(lights ...PointLight) error
+	// BeginFrame clears the color and depth.
+	BeginFrame()
+	// Render draws the Entity lit by the sun and the point lights.
+	Render(c *camera.Camera, e Entity, sun DirectionalLight)
+}
@@ -0,0 +1,155 @@
+package skeleton
+
+import (
+	"math"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Path is the part of the joint transform a channel animates.
+type Path int
+
+// The parts of the transform a channel can animate.
+const (
+	PathTranslation Path = iota
+	PathRotation
+	PathScale
+)
+
+// Interpolation is how the values between two keyframes are calculated.
+type Interpolation int
+
+// The interpolation modes, they're the same as the ones in glTF.
+const (
+	InterpLinear Interpolation = iota
+	InterpStep
+	InterpCubicSpline
+)
+
+// Channel animates one part of the transform of one joint.
+type Channel struct {
+	Joint  int
+	Path   Path
+	Interp Interpolation
+	Times  []float32
+	// Values has 3 floats per key for translation and scale, and 4 (x, y, z, w) for rotation.
+	// With cubic splines every key has an in-tangent, value and out-tangent, in that order.
+	Values []float32
+}
+
+// Clip is a single animation, like walking or jumping.
+type Clip struct {
+	Name     string
+	Duration float32
+	Channels []Channel
+}
+
+// Sample writes the pose at time t to pose. Joints without a channel keep what was already in the pose,
+// so start with the rest pose. When loop is false the time is clamped to the duration.
+func (c *Clip) Sample(t float32, loop bool, pose Pose) {
+	if c.Duration > 0.0 {
+		if loop {
+			t = float32(math.Mod(float64(t), float64(c.Duration)))
+			if t < 0.0 {
+				t += c.Duration
+			}
+		} else if t > c.Duration {
+			t = c.Duration
+		}
+	}
+	if t < 0.0 {
+		t = 0.0
+	}
+
+	for i := range c.Channels {
+		ch := &c.Channels[i]
+		if ch.Joint < 0 || ch.Joint >= len(pose) || len(ch.Times) == 0 {
+			continue
+		}
+
+		v := ch.sample(t)
+		switch ch.Path {
+		case PathTranslation:
+			pose[ch.Joint].Translation = mgl32.Vec3{v[0], v[1], v[2]}
+		case PathRotation:
+			pose[ch.Joint].Rotation = mgl32.Quat{W: v[3], V: mgl32.Vec3{v[0], v[1], v[2]}}.Normalize()
+		case PathScale:
+			pose[ch.Joint].Scale = mgl32.Vec3{v[0], v[1], v[2]}
+		}
+	}
+}
+
+// width returns the amount of floats of a single value.
+func (ch *Channel) width() int {
+	if ch.Path == PathRotation {
+		return 4
+	}
+	return 3
+}
+
+// value returns the value, or with cubic splines the tangent, of key k. Part is 0 for the in-tangent,
+// 1 for the value and 2 for the out-tangent.
+func (ch *Channel) value(k, part int) []float32 {
+	n := ch.width()
+	if ch.Interp != InterpCubicSpline {
+		return ch.Values[k*n : k*n+n]
+	}
+	i := (k*3 + part) * n
+	return ch.Values[i : i+n]
+}
+
+// sample returns the value of the channel at time t.
+func (ch *Channel) sample(t float32) mgl32.Vec4 {
+	var out mgl32.Vec4
+	n := ch.width()
+	last := len(ch.Times) - 1
+
+	// Find the first key after t.
+	k := sort.Search(len(ch.Times), func(i int) bool { return ch.Times[i] > t })
+	if k == 0 || k > last {
+		// Before the first or after the last key, hold the value.
+		if k > last {
+			k = last
+		}
+		copy(out[:n], ch.value(k, 1))
+		return out
+	}
+
+	k0, k1 := k-1, k
+	dt := ch.Times[k1] - ch.Times[k0]
+	s := (t - ch.Times[k0]) / dt
+	v0, v1 := ch.value(k0, 1), ch.value(k1, 1)
+
+	switch ch.Interp {
+	case InterpStep:
+		copy(out[:n], v0)
+
+	case InterpCubicSpline:
+		b0, a1 := ch.value(k0, 2), ch.value(k1, 0)
+		s2, s3 := s*s, s*s*s
+		h00 := 2.0*s3 - 3.0*s2 + 1.0
+		h10 := s3 - 2.0*s2 + s
+		h01 := -2.0*s3 + 3.0*s2
+		h11 := s3 - s2
+		for i := 0; i < n; i++ {
+			out[i] = h00*v0[i] + h10*dt*b0[i] + h01*v1[i] + h11*dt*a1[i]
+		}
+
+	default:
+		if ch.Path == PathRotation {
+			q0 := mgl32.Quat{W: v0[3], V: mgl32.Vec3{v0[0], v0[1], v0[2]}}
+			q1 := mgl32.Quat{W: v1[3], V: mgl32.Vec3{v1[0], v1[1], v1[2]}}
+			if q0.Dot(q1) < 0.0 {
+				q1 = q1.Scale(-1.0)
+			}
+			q := mgl32.QuatSlerp(q0, q1, s)
+			return mgl32.Vec4{q.V.X(), q.V.Y(), q.V.Z(), q.W}
+		}
+		for i := 0; i < n; i++ {
+			out[i] = v0[i] + (v1[i]-v0[i])*s
+		}
+	}
+
+	return out
+}
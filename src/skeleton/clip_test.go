@@ -0,0 +1,132 @@
+package skeleton
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// near returns whether the values are the same, give or take rounding.
+func near(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-5
+}
+
+func nearVec3(a, b mgl32.Vec3) bool {
+	return near(a[0], b[0]) && near(a[1], b[1]) && near(a[2], b[2])
+}
+
+// nearQuat also accepts -b, it's the same rotation.
+func nearQuat(a, b mgl32.Quat) bool {
+	if a.Dot(b) < 0.0 {
+		b = b.Scale(-1.0)
+	}
+	return near(a.W, b.W) && nearVec3(a.V, b.V)
+}
+
+// sampleTranslation samples a clip with one translation channel.
+func sampleTranslation(ch Channel, t float32, loop bool) mgl32.Vec3 {
+	c := Clip{Duration: ch.Times[len(ch.Times)-1], Channels: []Channel{ch}}
+	pose := Pose{IdentTransform()}
+	c.Sample(t, loop, pose)
+	return pose[0].Translation
+}
+
+func TestSampleLinear(t *testing.T) {
+	ch := Channel{Path: PathTranslation, Interp: InterpLinear, Times: []float32{0, 2, 4},
+		Values: []float32{0, 0, 0, 4, 0, 0, 4, 8, 0}}
+	for _, c := range []struct {
+		t    float32
+		loop bool
+		want mgl32.Vec3
+	}{
+		{0.0, false, mgl32.Vec3{0, 0, 0}},
+		{0.5, false, mgl32.Vec3{1, 0, 0}},
+		{2.0, false, mgl32.Vec3{4, 0, 0}},
+		{3.0, false, mgl32.Vec3{4, 4, 0}},
+		// Clamped to the duration, or wrapped around.
+		{9.0, false, mgl32.Vec3{4, 8, 0}},
+		{4.5, true, mgl32.Vec3{1, 0, 0}},
+		{-1.0, true, mgl32.Vec3{4, 4, 0}},
+	} {
+		if got := sampleTranslation(ch, c.t, c.loop); !nearVec3(got, c.want) {
+			t.Errorf("at %v (loop %v) it's %v, not %v", c.t, c.loop, got, c.want)
+		}
+	}
+}
+
+func TestSampleStep(t *testing.T) {
+	ch := Channel{Path: PathTranslation, Interp: InterpStep, Times: []float32{1, 2, 3},
+		Values: []float32{1, 0, 0, 2, 0, 0, 3, 0, 0}}
+	for _, c := range []struct {
+		t    float32
+		want float32
+	}{{0.0, 1}, {1.0, 1}, {1.99, 1}, {2.0, 2}, {2.5, 2}, {3.0, 3}} {
+		if got := sampleTranslation(ch, c.t, false); !near(got[0], c.want) {
+			t.Errorf("at %v it's %v, not %v", c.t, got[0], c.want)
+		}
+	}
+}
+
+func TestSampleCubicSpline(t *testing.T) {
+	// Every key is in-tangent, value, out-tangent. Key 0 goes out with a slope of 1 on x.
+	ch := Channel{Path: PathTranslation, Interp: InterpCubicSpline, Times: []float32{0, 2},
+		Values: []float32{
+			0, 0, 0, 0, 0, 0, 1, 0, 0,
+			0, 0, 0, 1, 1, 0, 0, 0, 0,
+		}}
+	// At s = 0.5: h00 = 0.5, h10 = 0.125, h01 = 0.5, h11 = -0.125. The tangents are scaled by the 2 seconds.
+	if got, want := sampleTranslation(ch, 1.0, false), (mgl32.Vec3{0.75, 0.5, 0}); !nearVec3(got, want) {
+		t.Errorf("halfway it's %v, not %v", got, want)
+	}
+	// At s = 0.25: h10 = 0.140625, h01 = 0.15625.
+	if got, want := sampleTranslation(ch, 0.5, false), (mgl32.Vec3{0.4375, 0.15625, 0}); !nearVec3(got, want) {
+		t.Errorf("a quarter of the way it's %v, not %v", got, want)
+	}
+	if got, want := sampleTranslation(ch, 2.0, false), (mgl32.Vec3{1, 1, 0}); !nearVec3(got, want) {
+		t.Errorf("at the end it's %v, not %v", got, want)
+	}
+}
+
+func TestSampleSlerp(t *testing.T) {
+	q0 := mgl32.QuatIdent()
+	q1 := mgl32.QuatRotate(math.Pi/2.0, mgl32.Vec3{0, 0, 1})
+	for _, flip := range []float32{1.0, -1.0} {
+		// -q1 is the same rotation, it still takes the short way around.
+		r := q1.Scale(flip)
+		ch := Channel{Joint: 0, Path: PathRotation, Times: []float32{0, 1},
+			Values: []float32{q0.V[0], q0.V[1], q0.V[2], q0.W, r.V[0], r.V[1], r.V[2], r.W}}
+		c := Clip{Duration: 1, Channels: []Channel{ch}}
+		pose := Pose{IdentTransform()}
+
+		c.Sample(0.5, false, pose)
+		want := mgl32.Quat{W: float32(math.Cos(math.Pi / 8.0)), V: mgl32.Vec3{0, 0, float32(math.Sin(math.Pi / 8.0))}}
+		if !nearQuat(pose[0].Rotation, want) {
+			t.Errorf("halfway it's %v, not %v", pose[0].Rotation, want)
+		}
+		// Slerp keeps the angular speed constant, a quarter of the time is a quarter of the angle.
+		c.Sample(0.25, false, pose)
+		want = mgl32.Quat{W: float32(math.Cos(math.Pi / 16.0)), V: mgl32.Vec3{0, 0, float32(math.Sin(math.Pi / 16.0))}}
+		if !nearQuat(pose[0].Rotation, want) {
+			t.Errorf("a quarter of the way it's %v, not %v", pose[0].Rotation, want)
+		}
+	}
+}
+
+func TestSampleKeepsOtherJoints(t *testing.T) {
+	c := Clip{Duration: 1, Channels: []Channel{
+		{Joint: 1, Path: PathScale, Times: []float32{0}, Values: []float32{2, 2, 2}},
+		// Joints that don't exist are skipped.
+		{Joint: 5, Path: PathScale, Times: []float32{0}, Values: []float32{3, 3, 3}},
+	}}
+	pose := Pose{IdentTransform(), IdentTransform()}
+	pose[0].Translation = mgl32.Vec3{1, 2, 3}
+
+	c.Sample(0.5, false, pose)
+	if pose[0].Translation != (mgl32.Vec3{1, 2, 3}) || pose[0].Scale != (mgl32.Vec3{1, 1, 1}) {
+		t.Errorf("joint 0 changed to %+v", pose[0])
+	}
+	if pose[1].Scale != (mgl32.Vec3{2, 2, 2}) {
+		t.Errorf("joint 1 is scaled by %v, not 2", pose[1].Scale)
+	}
+}
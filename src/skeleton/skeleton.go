@@ -0,0 +1,151 @@
+// Package skeleton samples and blends skeletal animation on the CPU, so poses can be worked out without a GPU.
+package skeleton
+
+import (
+	"fmt"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Transform is the local translation, rotation and scale of a joint.
+type Transform struct {
+	Translation mgl32.Vec3
+	Rotation    mgl32.Quat
+	Scale       mgl32.Vec3
+}
+
+// IdentTransform returns a transform that doesn't change anything.
+func IdentTransform() Transform {
+	return Transform{mgl32.Vec3{}, mgl32.QuatIdent(), mgl32.Vec3{1.0, 1.0, 1.0}}
+}
+
+// Mat4 returns the transform as a matrix, scaling first, then rotating and then translating.
+func (t Transform) Mat4() mgl32.Mat4 {
+	m := mgl32.Translate3D(t.Translation.X(), t.Translation.Y(), t.Translation.Z())
+	m = m.Mul4(t.Rotation.Mat4())
+	return m.Mul4(mgl32.Scale3D(t.Scale.X(), t.Scale.Y(), t.Scale.Z()))
+}
+
+// Joint is a single bone in the skeleton. Parent is -1 for the roots.
+type Joint struct {
+	Name        string
+	Parent      int
+	Rest        Transform
+	InverseBind mgl32.Mat4
+}
+
+// Skeleton is a hierarchy of joints.
+type Skeleton struct {
+	Joints []Joint
+	// Root is applied to all joints without a parent, for example the transform of the nodes above the skeleton.
+	Root mgl32.Mat4
+
+	// order has every parent before its children.
+	order []int
+}
+
+// Pose has a local transform for every joint in the skeleton.
+type Pose []Transform
+
+// CreateSkeleton checks the hierarchy of the joints and returns a skeleton.
+func CreateSkeleton(joints []Joint) (*Skeleton, error) {
+	s := &Skeleton{Joints: joints, Root: mgl32.Ident4()}
+
+	// Sort the joints so parents come first, this also catches cycles.
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(joints))
+
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("joint %v is its own ancestor", joints[i].Name)
+		}
+
+		state[i] = visiting
+		if p := joints[i].Parent; p >= 0 {
+			if p >= len(joints) {
+				return fmt.Errorf("joint %v has parent %v, but there are only %v joints", joints[i].Name, p, len(joints))
+			}
+			if err := visit(p); err != nil {
+				return err
+			}
+		}
+		state[i] = done
+		s.order = append(s.order, i)
+
+		return nil
+	}
+
+	for i := range joints {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// RestPose returns a new pose with every joint in its rest transform.
+func (s *Skeleton) RestPose() Pose {
+	p := make(Pose, len(s.Joints))
+	for i, j := range s.Joints {
+		p[i] = j.Rest
+	}
+
+	return p
+}
+
+// Globals calculates the model space matrix of every joint in the pose. If out is big enough it will be reused.
+func (s *Skeleton) Globals(p Pose, out []mgl32.Mat4) []mgl32.Mat4 {
+	if cap(out) < len(s.Joints) {
+		out = make([]mgl32.Mat4, len(s.Joints))
+	}
+	out = out[:len(s.Joints)]
+
+	for _, i := range s.order {
+		local := p[i].Mat4()
+		if parent := s.Joints[i].Parent; parent >= 0 {
+			out[i] = out[parent].Mul4(local)
+		} else {
+			out[i] = s.Root.Mul4(local)
+		}
+	}
+
+	return out
+}
+
+// Palette calculates the skinning matrices the vertex shader needs. If out is big enough it will be reused.
+func (s *Skeleton) Palette(p Pose, out []mgl32.Mat4) []mgl32.Mat4 {
+	out = s.Globals(p, out)
+	for i := range out {
+		out[i] = out[i].Mul4(s.Joints[i].InverseBind)
+	}
+
+	return out
+}
+
+// Blend mixes pose a and b, where w is the weight of b. The result is written to out, which may be a or b.
+func Blend(a, b Pose, w float32, out Pose) {
+	for i := range out {
+		ta, tb := a[i], b[i]
+
+		// Take the shortest way around.
+		rb := tb.Rotation
+		if ta.Rotation.Dot(rb) < 0.0 {
+			rb = rb.Scale(-1.0)
+		}
+
+		out[i] = Transform{
+			ta.Translation.Add(tb.Translation.Sub(ta.Translation).Mul(w)),
+			mgl32.QuatNlerp(ta.Rotation, rb, w),
+			ta.Scale.Add(tb.Scale.Sub(ta.Scale).Mul(w)),
+		}
+	}
+}
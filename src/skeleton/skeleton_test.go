@@ -0,0 +1,113 @@
+package skeleton
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// arm is a root at 1, 0, 0 and a child 2 up from it, turned 90 degrees around z.
+func arm(t *testing.T) *Skeleton {
+	root := IdentTransform()
+	root.Translation = mgl32.Vec3{1, 0, 0}
+	child := IdentTransform()
+	child.Translation = mgl32.Vec3{0, 2, 0}
+	child.Rotation = mgl32.QuatRotate(math.Pi/2.0, mgl32.Vec3{0, 0, 1})
+
+	// The child is listed first, so it has to be sorted after its parent.
+	s, err := CreateSkeleton([]Joint{
+		{Name: "hand", Parent: 1, Rest: child, InverseBind: mgl32.Translate3D(-1, -2, 0)},
+		{Name: "shoulder", Parent: -1, Rest: root, InverseBind: mgl32.Translate3D(-1, 0, 0)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// transform applies the matrix to a point.
+func transform(m mgl32.Mat4, p mgl32.Vec3) mgl32.Vec3 {
+	return m.Mul4x1(p.Vec4(1.0)).Vec3()
+}
+
+func TestPalette(t *testing.T) {
+	s := arm(t)
+	palette := s.Palette(s.RestPose(), nil)
+
+	// The hand joint is at 1, 2, 0 in the rest pose, so it stays put and the rest turns around it.
+	for _, c := range []struct {
+		joint   int
+		p, want mgl32.Vec3
+	}{
+		{0, mgl32.Vec3{1, 2, 0}, mgl32.Vec3{1, 2, 0}},
+		{0, mgl32.Vec3{2, 2, 0}, mgl32.Vec3{1, 3, 0}},
+		{0, mgl32.Vec3{1, 3, 0}, mgl32.Vec3{0, 2, 0}},
+		// The shoulder isn't moved, its matrix is the identity.
+		{1, mgl32.Vec3{5, 6, 7}, mgl32.Vec3{5, 6, 7}},
+	} {
+		if got := transform(palette[c.joint], c.p); !nearVec3(got, c.want) {
+			t.Errorf("joint %v moves %v to %v, not %v", c.joint, c.p, got, c.want)
+		}
+	}
+
+	// The root moves every joint.
+	s.Root = mgl32.Translate3D(0, 0, 10)
+	palette = s.Palette(s.RestPose(), palette)
+	if got, want := transform(palette[0], mgl32.Vec3{2, 2, 0}), (mgl32.Vec3{1, 3, 10}); !nearVec3(got, want) {
+		t.Errorf("with the root it's %v, not %v", got, want)
+	}
+}
+
+func TestGlobals(t *testing.T) {
+	s := arm(t)
+	pose := s.RestPose()
+	pose[1].Rotation = mgl32.QuatRotate(math.Pi, mgl32.Vec3{0, 0, 1})
+	globals := s.Globals(pose, nil)
+
+	// The shoulder turned half around, so the hand is 2 down from it.
+	if got, want := transform(globals[0], mgl32.Vec3{}), (mgl32.Vec3{1, -2, 0}); !nearVec3(got, want) {
+		t.Errorf("the hand is at %v, not %v", got, want)
+	}
+}
+
+func TestCreateSkeletonErrors(t *testing.T) {
+	if _, err := CreateSkeleton([]Joint{{Name: "a", Parent: 1}, {Name: "b", Parent: 0}}); err == nil {
+		t.Error("a cycle isn't an error")
+	}
+	if _, err := CreateSkeleton([]Joint{{Name: "a", Parent: 0}}); err == nil {
+		t.Error("a joint that's its own parent isn't an error")
+	}
+	if _, err := CreateSkeleton([]Joint{{Name: "a", Parent: 3}}); err == nil {
+		t.Error("a parent that doesn't exist isn't an error")
+	}
+}
+
+func TestBlend(t *testing.T) {
+	a := Pose{IdentTransform()}
+	b := Pose{{
+		Translation: mgl32.Vec3{2, 4, 6},
+		// -q is the same rotation, the blend still takes the short way around.
+		Rotation: mgl32.QuatRotate(math.Pi/2.0, mgl32.Vec3{0, 1, 0}).Scale(-1.0),
+		Scale:    mgl32.Vec3{3, 3, 3},
+	}}
+
+	out := make(Pose, 1)
+	Blend(a, b, 0.5, out)
+	if want := (mgl32.Vec3{1, 2, 3}); !nearVec3(out[0].Translation, want) {
+		t.Errorf("the translation is %v, not %v", out[0].Translation, want)
+	}
+	if want := (mgl32.Vec3{2, 2, 2}); !nearVec3(out[0].Scale, want) {
+		t.Errorf("the scale is %v, not %v", out[0].Scale, want)
+	}
+	want := mgl32.Quat{W: float32(math.Cos(math.Pi / 8.0)), V: mgl32.Vec3{0, float32(math.Sin(math.Pi / 8.0)), 0}}
+	if !nearQuat(out[0].Rotation, want) {
+		t.Errorf("the rotation is %v, not %v", out[0].Rotation, want)
+	}
+
+	// The weights at the ends give the poses back, out can be one of them.
+	Blend(a, b, 1.0, a)
+	if !nearVec3(a[0].Translation, b[0].Translation) || !nearQuat(a[0].Rotation, b[0].Rotation) {
+		t.Errorf("a weight of 1 gives %+v, not %+v", a[0], b[0])
+	}
+}
@@ -0,0 +1,178 @@
+package spatial
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// AABB is an axis aligned bounding box.
+type AABB struct {
+	Min, Max mgl32.Vec3
+}
+
+// Union returns the box around both boxes.
+func (a AABB) Union(b AABB) AABB {
+	for i := 0; i < 3; i++ {
+		a.Min[i] = float32(math.Min(float64(a.Min[i]), float64(b.Min[i])))
+		a.Max[i] = float32(math.Max(float64(a.Max[i]), float64(b.Max[i])))
+	}
+
+	return a
+}
+
+// Contains returns whether b is completely inside a.
+func (a AABB) Contains(b AABB) bool {
+	for i := 0; i < 3; i++ {
+		if b.Min[i] < a.Min[i] || b.Max[i] > a.Max[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Overlaps returns whether the boxes touch.
+func (a AABB) Overlaps(b AABB) bool {
+	for i := 0; i < 3; i++ {
+		if b.Max[i] < a.Min[i] || b.Min[i] > a.Max[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Grow returns the box made bigger by m on every side.
+func (a AABB) Grow(m float32) AABB {
+	d := mgl32.Vec3{m, m, m}
+	return AABB{a.Min.Sub(d), a.Max.Add(d)}
+}
+
+// Area returns the surface area, which is used as the cost of a node in the tree.
+func (a AABB) Area() float32 {
+	d := a.Max.Sub(a.Min)
+	return 2.0 * (d.X()*d.Y() + d.Y()*d.Z() + d.Z()*d.X())
+}
+
+// Center returns the middle of the box.
+func (a AABB) Center() mgl32.Vec3 {
+	return a.Min.Add(a.Max).Mul(0.5)
+}
+
+// DistanceSqr returns the squared distance from p to the closest point of the box, 0 if p is inside.
+func (a AABB) DistanceSqr(p mgl32.Vec3) float32 {
+	var d float32
+	for i := 0; i < 3; i++ {
+		if p[i] < a.Min[i] {
+			d += (a.Min[i] - p[i]) * (a.Min[i] - p[i])
+		} else if p[i] > a.Max[i] {
+			d += (p[i] - a.Max[i]) * (p[i] - a.Max[i])
+		}
+	}
+
+	return d
+}
+
+// Transform returns the box around a transformed by m.
+func (a AABB) Transform(m mgl32.Mat4) AABB {
+	// Start at the translation and add the extent of every axis, see Graphics Gems "Transforming Axis-Aligned
+	// Bounding Boxes".
+	out := AABB{m.Col(3).Vec3(), m.Col(3).Vec3()}
+	for c := 0; c < 3; c++ {
+		for r := 0; r < 3; r++ {
+			e, f := m.At(r, c)*a.Min[c], m.At(r, c)*a.Max[c]
+			if e > f {
+				e, f = f, e
+			}
+			out.Min[r] += e
+			out.Max[r] += f
+		}
+	}
+
+	return out
+}
+
+// Ray is a half line, Dir doesn't have to be normalized but distances are in multiples of it.
+type Ray struct {
+	Origin, Dir mgl32.Vec3
+}
+
+// Intersect returns the distance along the ray where it enters the box, and false if it misses.
+func (r Ray) Intersect(a AABB, maxDist float32) (float32, bool) {
+	tmin, tmax := float32(0.0), maxDist
+	for i := 0; i < 3; i++ {
+		if r.Dir[i] == 0.0 {
+			if r.Origin[i] < a.Min[i] || r.Origin[i] > a.Max[i] {
+				return 0.0, false
+			}
+			continue
+		}
+
+		inv := 1.0 / r.Dir[i]
+		t0, t1 := (a.Min[i]-r.Origin[i])*inv, (a.Max[i]-r.Origin[i])*inv
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		if t0 > tmin {
+			tmin = t0
+		}
+		if t1 < tmax {
+			tmax = t1
+		}
+		if tmin > tmax {
+			return 0.0, false
+		}
+	}
+
+	return tmin, true
+}
+
+// Plane is the plane dot(Normal, p) + D = 0, the normal points to the inside.
+type Plane struct {
+	Normal mgl32.Vec3
+	D      float32
+}
+
+// Frustum is the volume a camera can see, as six planes.
+type Frustum [6]Plane
+
+// FrustumFromMatrix extracts the planes of a projection * view matrix.
+func FrustumFromMatrix(m mgl32.Mat4) Frustum {
+	var f Frustum
+	r0, r1, r2, r3 := m.Row(0), m.Row(1), m.Row(2), m.Row(3)
+	rows := [6]mgl32.Vec4{
+		r3.Add(r0), r3.Sub(r0), // Left, right.
+		r3.Add(r1), r3.Sub(r1), // Bottom, top.
+		r3.Add(r2), r3.Sub(r2), // Near, far.
+	}
+
+	for i, v := range rows {
+		n := v.Vec3()
+		l := n.Len()
+		f[i] = Plane{n.Mul(1.0 / l), v.W() / l}
+	}
+
+	return f
+}
+
+// IntersectsAABB returns whether any part of the box is inside the frustum. It can give false positives
+// for big boxes near the corners, which is fine for culling.
+func (f *Frustum) IntersectsAABB(a AABB) bool {
+	for _, p := range f {
+		// The corner furthest along the normal.
+		var v mgl32.Vec3
+		for i := 0; i < 3; i++ {
+			if p.Normal[i] >= 0.0 {
+				v[i] = a.Max[i]
+			} else {
+				v[i] = a.Min[i]
+			}
+		}
+		if p.Normal.Dot(v)+p.D < 0.0 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,412 @@
+// Package spatial has a dynamic bounding volume hierarchy, to quickly find objects in an area, on a ray or in view.
+package spatial
+
+import (
+	"container/heap"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// null is the index used for no node.
+const null = -1
+
+type node struct {
+	// Box is the fat box, Tight is the real box of a leaf.
+	Box, Tight          AABB
+	parent, left, right int
+	height              int
+	data                interface{}
+}
+
+func (n *node) leaf() bool {
+	return n.left == null
+}
+
+// Tree is a dynamic AABB tree. Leaves get a margin around them, so small moves don't change the tree.
+type Tree struct {
+	// Margin is added around every object box.
+	Margin float32
+
+	nodes []node
+	root  int
+	free  []int
+	count int
+}
+
+// CreateTree returns an empty tree with the margin added around every box.
+func CreateTree(margin float32) *Tree {
+	return &Tree{Margin: margin, root: null}
+}
+
+// Len returns the amount of objects in the tree.
+func (t *Tree) Len() int {
+	return t.count
+}
+
+// alloc returns a new node, reusing removed ones.
+func (t *Tree) alloc() int {
+	if n := len(t.free); n > 0 {
+		id := t.free[n-1]
+		t.free = t.free[:n-1]
+		t.nodes[id] = node{parent: null, left: null, right: null}
+		return id
+	}
+
+	t.nodes = append(t.nodes, node{parent: null, left: null, right: null})
+	return len(t.nodes) - 1
+}
+
+// release puts a node on the free list.
+func (t *Tree) release(id int) {
+	t.nodes[id] = node{parent: null, left: null, right: null, height: -1}
+	t.free = append(t.free, id)
+}
+
+// Insert adds an object with a box and returns its id, which stays the same until it's removed.
+func (t *Tree) Insert(box AABB, data interface{}) int {
+	id := t.alloc()
+	t.nodes[id].Box = box.Grow(t.Margin)
+	t.nodes[id].Tight = box
+	t.nodes[id].data = data
+	t.insertLeaf(id)
+	t.count++
+
+	return id
+}
+
+// Remove takes an object out of the tree.
+func (t *Tree) Remove(id int) {
+	t.removeLeaf(id)
+	t.release(id)
+	t.count--
+}
+
+// Move updates the box of an object. The tree only changes when the box leaves the margin, then true is returned.
+func (t *Tree) Move(id int, box AABB) bool {
+	t.nodes[id].Tight = box
+	if t.nodes[id].Box.Contains(box) {
+		return false
+	}
+
+	t.removeLeaf(id)
+	t.nodes[id].Box = box.Grow(t.Margin)
+	t.insertLeaf(id)
+
+	return true
+}
+
+// Data returns what was passed to Insert.
+func (t *Tree) Data(id int) interface{} {
+	return t.nodes[id].data
+}
+
+// Bounds returns the box of an object, without the margin.
+func (t *Tree) Bounds(id int) AABB {
+	return t.nodes[id].Tight
+}
+
+// insertLeaf finds the best sibling for the leaf, using the surface area heuristic like Box2D.
+func (t *Tree) insertLeaf(leaf int) {
+	if t.root == null {
+		t.root = leaf
+		t.nodes[leaf].parent = null
+		return
+	}
+
+	box := t.nodes[leaf].Box
+	index := t.root
+	for !t.nodes[index].leaf() {
+		n := &t.nodes[index]
+		area := n.Box.Area()
+		combined := n.Box.Union(box).Area()
+
+		// The cost of making a new parent for this node and the leaf.
+		cost := 2.0 * combined
+		// The minimum cost of pushing the leaf further down.
+		inherit := 2.0 * (combined - area)
+
+		childCost := func(c int) float32 {
+			u := t.nodes[c].Box.Union(box).Area()
+			if t.nodes[c].leaf() {
+				return u + inherit
+			}
+			return u - t.nodes[c].Box.Area() + inherit
+		}
+		costLeft, costRight := childCost(n.left), childCost(n.right)
+
+		if cost < costLeft && cost < costRight {
+			break
+		}
+		if costLeft < costRight {
+			index = n.left
+		} else {
+			index = n.right
+		}
+	}
+
+	// Make a new parent for the sibling and the leaf.
+	sibling := index
+	oldParent := t.nodes[sibling].parent
+	parent := t.alloc()
+	t.nodes[parent].parent = oldParent
+	t.nodes[parent].Box = box.Union(t.nodes[sibling].Box)
+	t.nodes[parent].height = t.nodes[sibling].height + 1
+	t.nodes[parent].left = sibling
+	t.nodes[parent].right = leaf
+	t.nodes[sibling].parent = parent
+	t.nodes[leaf].parent = parent
+
+	if oldParent == null {
+		t.root = parent
+	} else if t.nodes[oldParent].left == sibling {
+		t.nodes[oldParent].left = parent
+	} else {
+		t.nodes[oldParent].right = parent
+	}
+
+	t.refit(parent)
+}
+
+// removeLeaf takes a leaf out and replaces its parent with its sibling.
+func (t *Tree) removeLeaf(leaf int) {
+	if leaf == t.root {
+		t.root = null
+		return
+	}
+
+	parent := t.nodes[leaf].parent
+	grandParent := t.nodes[parent].parent
+	sibling := t.nodes[parent].left
+	if sibling == leaf {
+		sibling = t.nodes[parent].right
+	}
+
+	if grandParent == null {
+		t.root = sibling
+		t.nodes[sibling].parent = null
+	} else {
+		if t.nodes[grandParent].left == parent {
+			t.nodes[grandParent].left = sibling
+		} else {
+			t.nodes[grandParent].right = sibling
+		}
+		t.nodes[sibling].parent = grandParent
+		t.refit(grandParent)
+	}
+
+	t.release(parent)
+	t.nodes[leaf].parent = null
+}
+
+// refit walks up from index, balancing and fixing the boxes and heights.
+func (t *Tree) refit(index int) {
+	for index != null {
+		index = t.balance(index)
+
+		n := &t.nodes[index]
+		l, r := &t.nodes[n.left], &t.nodes[n.right]
+		n.height = 1 + maxInt(l.height, r.height)
+		n.Box = l.Box.Union(r.Box)
+
+		index = n.parent
+	}
+}
+
+// balance does a rotation if one side of a is more than one level higher, and returns the new top node.
+func (t *Tree) balance(a int) int {
+	na := &t.nodes[a]
+	if na.leaf() || na.height < 2 {
+		return a
+	}
+
+	b, c := na.left, na.right
+	diff := t.nodes[c].height - t.nodes[b].height
+	if diff > 1 {
+		return t.rotate(a, c, b)
+	}
+	if diff < -1 {
+		return t.rotate(a, b, c)
+	}
+
+	return a
+}
+
+// rotate moves the high child up to the place of a.
+func (t *Tree) rotate(a, high, low int) int {
+	na, nh := &t.nodes[a], &t.nodes[high]
+	f, g := nh.left, nh.right
+
+	// High takes the place of a.
+	nh.left = a
+	nh.parent = na.parent
+	na.parent = high
+
+	if nh.parent == null {
+		t.root = high
+	} else if t.nodes[nh.parent].left == a {
+		t.nodes[nh.parent].left = high
+	} else {
+		t.nodes[nh.parent].right = high
+	}
+
+	// The higher grandchild stays under high, the other one moves to a.
+	if t.nodes[f].height < t.nodes[g].height {
+		f, g = g, f
+	}
+	nh.right = f
+	if na.left == high {
+		na.left = g
+	} else {
+		na.right = g
+	}
+	t.nodes[g].parent = a
+
+	na.Box = t.nodes[low].Box.Union(t.nodes[g].Box)
+	na.height = 1 + maxInt(t.nodes[low].height, t.nodes[g].height)
+	nh.Box = na.Box.Union(t.nodes[f].Box)
+	nh.height = 1 + maxInt(na.height, t.nodes[f].height)
+
+	return high
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// query walks the tree, going into every node where test returns true. It stops when fn returns false.
+func (t *Tree) query(test func(b AABB) bool, fn func(id int) bool) {
+	if t.root == null {
+		return
+	}
+
+	stack := []int{t.root}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		n := &t.nodes[id]
+		if !test(n.Box) {
+			continue
+		}
+		if n.leaf() {
+			if test(n.Tight) && !fn(id) {
+				return
+			}
+			continue
+		}
+		stack = append(stack, n.left, n.right)
+	}
+}
+
+// QueryAABB calls fn for every object whose box overlaps the box. Return false from fn to stop.
+func (t *Tree) QueryAABB(box AABB, fn func(id int) bool) {
+	t.query(box.Overlaps, fn)
+}
+
+// QuerySphere calls fn for every object whose box overlaps the sphere. Return false from fn to stop.
+func (t *Tree) QuerySphere(center mgl32.Vec3, radius float32, fn func(id int) bool) {
+	t.query(func(b AABB) bool {
+		return b.DistanceSqr(center) <= radius*radius
+	}, fn)
+}
+
+// QueryFrustum calls fn for every object whose box is in the frustum. Return false from fn to stop.
+func (t *Tree) QueryFrustum(f Frustum, fn func(id int) bool) {
+	t.query(f.IntersectsAABB, fn)
+}
+
+// Raycast returns the closest object hit by the ray within maxDist, and the distance. Hit can do a more precise
+// test than the box, like checking the triangles, and return the real distance. Nil just uses the boxes.
+func (t *Tree) Raycast(r Ray, maxDist float32, hit func(id int, r Ray, maxDist float32) (float32, bool)) (int, float32, bool) {
+	best, bestDist := null, maxDist
+	if t.root == null {
+		return best, bestDist, false
+	}
+
+	stack := []int{t.root}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		n := &t.nodes[id]
+		if _, ok := r.Intersect(n.Box, bestDist); !ok {
+			continue
+		}
+
+		if !n.leaf() {
+			stack = append(stack, n.left, n.right)
+			continue
+		}
+
+		// Everything further than the best hit so far can be skipped from now on.
+		d, ok := r.Intersect(n.Tight, bestDist)
+		if ok && hit != nil {
+			d, ok = hit(id, r, bestDist)
+		}
+		if ok && d <= bestDist {
+			best, bestDist = id, d
+		}
+	}
+
+	return best, bestDist, best != null
+}
+
+// Nearest returns up to k objects closest to p, closest first, measured to their boxes.
+func (t *Tree) Nearest(p mgl32.Vec3, k int) []int {
+	var out []int
+	if t.root == null || k <= 0 {
+		return out
+	}
+
+	// Best first search, nodes are visited in order of the distance to their box.
+	q := &nodeQueue{{t.root, t.nodes[t.root].Box.DistanceSqr(p)}}
+	for q.Len() > 0 && len(out) < k {
+		item := heap.Pop(q).(queueItem)
+		n := &t.nodes[item.id]
+
+		if n.leaf() {
+			// The fat box was used to get here, now use the real one.
+			d := n.Tight.DistanceSqr(p)
+			if d > item.dist {
+				heap.Push(q, queueItem{item.id, d})
+				continue
+			}
+			out = append(out, item.id)
+			continue
+		}
+
+		heap.Push(q, queueItem{n.left, t.nodes[n.left].Box.DistanceSqr(p)})
+		heap.Push(q, queueItem{n.right, t.nodes[n.right].Box.DistanceSqr(p)})
+	}
+
+	return out
+}
+
+type queueItem struct {
+	id   int
+	dist float32
+}
+
+type nodeQueue []queueItem
+
+func (q nodeQueue) Len() int            { return len(q) }
+func (q nodeQueue) Less(i, j int) bool  { return q[i].dist < q[j].dist }
+func (q nodeQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *nodeQueue) Push(x interface{}) { *q = append(*q, x.(queueItem)) }
+func (q *nodeQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// Height returns the height of the tree, a leaf has height 0.
+func (t *Tree) Height() int {
+	if t.root == null {
+		return 0
+	}
+	return t.nodes[t.root].height
+}
@@ -0,0 +1,355 @@
+package spatial
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// randomBox returns a box of up to size on every side, somewhere in a cube of 200 around the origin.
+func randomBox(rng *rand.Rand, size float32) AABB {
+	var min, ext mgl32.Vec3
+	for i := 0; i < 3; i++ {
+		min[i] = rng.Float32()*200.0 - 100.0
+		ext[i] = rng.Float32() * size
+	}
+	return AABB{min, min.Add(ext)}
+}
+
+// randomPoint returns a point in a cube of 200 around the origin.
+func randomPoint(rng *rand.Rand) mgl32.Vec3 {
+	return mgl32.Vec3{rng.Float32()*200.0 - 100.0, rng.Float32()*200.0 - 100.0, rng.Float32()*200.0 - 100.0}
+}
+
+// randomTree fills a tree with n random boxes, the ids are mapped to the boxes.
+func randomTree(rng *rand.Rand, n int) (*Tree, map[int]AABB) {
+	t := CreateTree(1.0)
+	boxes := make(map[int]AABB)
+	for i := 0; i < n; i++ {
+		b := randomBox(rng, 10.0)
+		boxes[t.Insert(b, i)] = b
+	}
+	return t, boxes
+}
+
+// checkTree checks that every node contains its children, the parents are right and the tree is balanced.
+func checkTree(t *testing.T, tr *Tree, boxes map[int]AABB) {
+	if tr.Len() != len(boxes) {
+		t.Fatalf("the tree has %v objects, not %v", tr.Len(), len(boxes))
+	}
+
+	leaves := 0
+	var visit func(id, parent int) int
+	visit = func(id, parent int) int {
+		n := &tr.nodes[id]
+		if n.parent != parent {
+			t.Fatalf("node %v has parent %v, not %v", id, n.parent, parent)
+		}
+		if n.leaf() {
+			leaves++
+			if want, ok := boxes[id]; !ok || n.Tight != want || !n.Box.Contains(n.Tight) {
+				t.Fatalf("leaf %v has box %v and fat box %v, not %v", id, n.Tight, n.Box, want)
+			}
+			return 0
+		}
+
+		for _, c := range []int{n.left, n.right} {
+			if !n.Box.Contains(tr.nodes[c].Box) {
+				t.Fatalf("node %v doesn't contain its child %v", id, c)
+			}
+		}
+		hl, hr := visit(n.left, id), visit(n.right, id)
+		if hl-hr > 1 || hr-hl > 1 {
+			t.Fatalf("node %v has children of height %v and %v", id, hl, hr)
+		}
+		if h := maxInt(hl, hr) + 1; n.height != h {
+			t.Fatalf("node %v has height %v, not %v", id, n.height, h)
+		}
+		return n.height
+	}
+	if tr.root != null {
+		visit(tr.root, null)
+	}
+	if leaves != len(boxes) {
+		t.Fatalf("the tree has %v leaves, not %v", leaves, len(boxes))
+	}
+}
+
+// collect returns the ids a query calls fn with, sorted.
+func collect(query func(fn func(id int) bool)) []int {
+	var ids []int
+	query(func(id int) bool {
+		ids = append(ids, id)
+		return true
+	})
+	sort.Ints(ids)
+	return ids
+}
+
+// scan returns the ids of the boxes the test is true for, sorted.
+func scan(boxes map[int]AABB, test func(b AABB) bool) []int {
+	var ids []int
+	for id, b := range boxes {
+		if test(b) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func sameIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// checkQueries compares every query with a linear scan over the boxes.
+func checkQueries(t *testing.T, rng *rand.Rand, tr *Tree, boxes map[int]AABB) {
+	for i := 0; i < 50; i++ {
+		q := randomBox(rng, 40.0)
+		got := collect(func(fn func(id int) bool) { tr.QueryAABB(q, fn) })
+		if want := scan(boxes, q.Overlaps); !sameIDs(got, want) {
+			t.Fatalf("overlapping %v gives %v, not %v", q, got, want)
+		}
+
+		center, radius := randomPoint(rng), rng.Float32()*30.0
+		got = collect(func(fn func(id int) bool) { tr.QuerySphere(center, radius, fn) })
+		want := scan(boxes, func(b AABB) bool { return b.DistanceSqr(center) <= radius*radius })
+		if !sameIDs(got, want) {
+			t.Fatalf("the sphere at %v with radius %v gives %v, not %v", center, radius, got, want)
+		}
+
+		eye, target := randomPoint(rng), randomPoint(rng)
+		f := FrustumFromMatrix(mgl32.Perspective(mgl32.DegToRad(60.0), 1.5, 0.5, 80.0).Mul4(
+			mgl32.LookAtV(eye, target, mgl32.Vec3{0.0, 1.0, 0.0})))
+		got = collect(func(fn func(id int) bool) { tr.QueryFrustum(f, fn) })
+		if want := scan(boxes, f.IntersectsAABB); !sameIDs(got, want) {
+			t.Fatalf("the frustum from %v to %v gives %v, not %v", eye, target, got, want)
+		}
+
+		r := Ray{randomPoint(rng), randomPoint(rng).Normalize()}
+		id, d, ok := tr.Raycast(r, 150.0, nil)
+		wantID, wantDist := null, float32(150.0)
+		for bid, b := range boxes {
+			if bd, hit := r.Intersect(b, wantDist); hit && bd <= wantDist {
+				wantID, wantDist = bid, bd
+			}
+		}
+		// Another box can be hit at the same distance, so only the distance has to match.
+		if ok != (wantID != null) || ok && d != wantDist {
+			t.Fatalf("the ray %v hits %v at %v (%v), not %v at %v", r, id, d, ok, wantID, wantDist)
+		}
+		if ok {
+			if bd, hit := r.Intersect(boxes[id], 150.0); !hit || bd != d {
+				t.Fatalf("the ray %v doesn't hit %v at %v", r, id, d)
+			}
+		}
+
+		p, k := randomPoint(rng), 1+rng.Intn(10)
+		nearest := tr.Nearest(p, k)
+		dists := make([]float32, 0, len(boxes))
+		for _, b := range boxes {
+			dists = append(dists, b.DistanceSqr(p))
+		}
+		sort.Slice(dists, func(i, j int) bool { return dists[i] < dists[j] })
+		if len(dists) > k {
+			dists = dists[:k]
+		}
+		if len(nearest) != len(dists) {
+			t.Fatalf("%v nearest to %v, not %v", len(nearest), p, len(dists))
+		}
+		for j, nid := range nearest {
+			if d := boxes[nid].DistanceSqr(p); d != dists[j] {
+				t.Fatalf("nearest %v to %v is %v away, not %v", j, p, d, dists[j])
+			}
+		}
+	}
+}
+
+func TestQueries(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tr, boxes := randomTree(rng, 500)
+	checkTree(t, tr, boxes)
+	checkQueries(t, rng, tr, boxes)
+}
+
+func TestMoveAndRemove(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	tr, boxes := randomTree(rng, 300)
+
+	for round := 0; round < 5; round++ {
+		// Small moves stay inside the margin, big ones don't.
+		for id, b := range boxes {
+			offset := mgl32.Vec3{rng.Float32() - 0.5, rng.Float32() - 0.5, rng.Float32() - 0.5}
+			if rng.Intn(4) == 0 {
+				offset = offset.Mul(40.0)
+			}
+			moved := AABB{b.Min.Add(offset), b.Max.Add(offset)}
+			changed := tr.Move(id, moved)
+			if fat := b.Grow(tr.Margin); !changed && !fat.Contains(moved) {
+				t.Fatalf("moving %v out of its margin didn't change the tree", id)
+			}
+			boxes[id] = moved
+		}
+
+		// Remove some, and add new ones in their place.
+		for id := range boxes {
+			if rng.Intn(5) == 0 {
+				tr.Remove(id)
+				delete(boxes, id)
+			}
+		}
+		for i := 0; i < 30; i++ {
+			b := randomBox(rng, 10.0)
+			boxes[tr.Insert(b, i)] = b
+		}
+
+		checkTree(t, tr, boxes)
+		checkQueries(t, rng, tr, boxes)
+	}
+
+	for id := range boxes {
+		tr.Remove(id)
+	}
+	if tr.Len() != 0 || tr.root != null {
+		t.Errorf("the tree has %v objects after removing all", tr.Len())
+	}
+}
+
+func TestQueryStops(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	tr, _ := randomTree(rng, 100)
+	calls := 0
+	tr.QueryAABB(AABB{mgl32.Vec3{-200, -200, -200}, mgl32.Vec3{200, 200, 200}}, func(id int) bool {
+		calls++
+		return calls < 3
+	})
+	if calls != 3 {
+		t.Errorf("the query went on after fn returned false, %v calls", calls)
+	}
+}
+
+func TestRaycastHit(t *testing.T) {
+	tr := CreateTree(0.5)
+	near := tr.Insert(AABB{mgl32.Vec3{4, -1, -1}, mgl32.Vec3{6, 1, 1}}, nil)
+	far := tr.Insert(AABB{mgl32.Vec3{9, -1, -1}, mgl32.Vec3{11, 1, 1}}, nil)
+	r := Ray{mgl32.Vec3{}, mgl32.Vec3{1, 0, 0}}
+
+	if id, d, ok := tr.Raycast(r, 100.0, nil); !ok || id != near || d != 4.0 {
+		t.Errorf("the ray hits %v at %v, not %v at 4", id, d, near)
+	}
+	// A precise test can miss the closer box.
+	miss := func(id int, r Ray, maxDist float32) (float32, bool) {
+		if id == near {
+			return 0.0, false
+		}
+		return r.Intersect(tr.Bounds(id), maxDist)
+	}
+	if id, d, ok := tr.Raycast(r, 100.0, miss); !ok || id != far || d != 9.0 {
+		t.Errorf("the ray hits %v at %v, not %v at 9", id, d, far)
+	}
+	if _, _, ok := tr.Raycast(r, 3.0, nil); ok {
+		t.Error("the ray hits a box past its length")
+	}
+}
+
+const benchObjects = 10000
+
+func BenchmarkInsert(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	boxes := make([]AABB, benchObjects)
+	for i := range boxes {
+		boxes[i] = randomBox(rng, 10.0)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tr := CreateTree(1.0)
+		for j, box := range boxes {
+			tr.Insert(box, j)
+		}
+	}
+}
+
+func BenchmarkMove(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	tr, boxes := randomTree(rng, benchObjects)
+	ids := make([]int, 0, len(boxes))
+	for id := range boxes {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Objects drift a little every frame, now and then one leaves its margin.
+		id := ids[i%len(ids)]
+		box := tr.Bounds(id)
+		offset := mgl32.Vec3{float32(math.Sin(float64(i))) * 0.3, 0.1, float32(math.Cos(float64(i))) * 0.3}
+		tr.Move(id, AABB{box.Min.Add(offset), box.Max.Add(offset)})
+	}
+}
+
+func BenchmarkQueryFrustum(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	tr, _ := randomTree(rng, benchObjects)
+	f := FrustumFromMatrix(mgl32.Perspective(mgl32.DegToRad(60.0), 1.5, 0.5, 100.0).Mul4(
+		mgl32.LookAtV(mgl32.Vec3{0, 0, -100}, mgl32.Vec3{}, mgl32.Vec3{0, 1, 0})))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tr.QueryFrustum(f, func(id int) bool { return true })
+	}
+}
+
+func BenchmarkRaycast(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	tr, _ := randomTree(rng, benchObjects)
+	rays := make([]Ray, 256)
+	for i := range rays {
+		rays[i] = Ray{randomPoint(rng), randomPoint(rng).Normalize()}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tr.Raycast(rays[i%len(rays)], 300.0, nil)
+	}
+}
+
+func BenchmarkQueryAABB(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	tr, _ := randomTree(rng, benchObjects)
+	queries := make([]AABB, 256)
+	for i := range queries {
+		queries[i] = randomBox(rng, 20.0)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tr.QueryAABB(queries[i%len(queries)], func(id int) bool { return true })
+	}
+}
+
+func BenchmarkNearest(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	tr, _ := randomTree(rng, benchObjects)
+	points := make([]mgl32.Vec3, 256)
+	for i := range points {
+		points[i] = randomPoint(rng)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tr.Nearest(points[i%len(points)], 8)
+	}
+}
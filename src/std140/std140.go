@@ -0,0 +1,202 @@
+// Package std140 packs Go structs the way the std140 layout of GLSL uniform blocks wants them, so a block can be
+// uploaded as one buffer. A struct field is a member of the block, in the same order:
+//
+//	float32, int32, uint32, bool   float, int, uint, bool
+//	mgl32.Vec2, Vec3, Vec4         vec2, vec3, vec4
+//	mgl32.Mat3, Mat4               mat3, mat4
+//	a struct                       a struct
+//	an array                       an array, every element starts at a multiple of 16 bytes
+//
+// The member is named like the field, or like its std140 tag. The fields don't have to be exported.
+package std140
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+var (
+	vec2 = reflect.TypeOf(mgl32.Vec2{})
+	vec3 = reflect.TypeOf(mgl32.Vec3{})
+	vec4 = reflect.TypeOf(mgl32.Vec4{})
+	mat3 = reflect.TypeOf(mgl32.Mat3{})
+	mat4 = reflect.TypeOf(mgl32.Mat4{})
+)
+
+// roundUp rounds n up to a multiple of align.
+func roundUp(n, align int) int {
+	return (n + align - 1) / align * align
+}
+
+// layout returns the alignment and size of a type in bytes.
+func layout(t reflect.Type) (align, size int, err error) {
+	switch t {
+	case vec2:
+		return 8, 8, nil
+	case vec3:
+		return 16, 12, nil
+	case vec4:
+		return 16, 16, nil
+	case mat3:
+		// The columns are vec3s, in an array they're padded to vec4s.
+		return 16, 3 * 16, nil
+	case mat4:
+		return 16, 4 * 16, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Float32, reflect.Int32, reflect.Uint32, reflect.Bool:
+		return 4, 4, nil
+	case reflect.Array:
+		align, size, err := layout(t.Elem())
+		if err != nil {
+			return 0, 0, err
+		}
+		return roundUp(align, 16), t.Len() * roundUp(size, 16), nil
+	case reflect.Struct:
+		if t.NumField() == 0 {
+			// GLSL has no empty structs, and there's nothing to align it by.
+			return 0, 0, fmt.Errorf("%v is an empty struct", t)
+		}
+		offset, maxAlign := 0, 0
+		for i := 0; i < t.NumField(); i++ {
+			align, size, err := layout(t.Field(i).Type)
+			if err != nil {
+				return 0, 0, fmt.Errorf("%v: %v", t.Field(i).Name, err)
+			}
+			offset = roundUp(offset, align) + size
+			if align > maxAlign {
+				maxAlign = align
+			}
+		}
+		align := roundUp(maxAlign, 16)
+		return align, roundUp(offset, align), nil
+	}
+
+	return 0, 0, fmt.Errorf("%v can't be in a uniform block", t)
+}
+
+// Size returns the size of the block in bytes, including the padding at the end.
+func Size(block interface{}) (int, error) {
+	_, size, err := layout(reflect.TypeOf(block))
+	return size, err
+}
+
+// Encode packs the block. The struct has to be a value, not a pointer.
+func Encode(block interface{}) ([]byte, error) {
+	v := reflect.ValueOf(block)
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("a uniform block has to be a struct, not %v", v.Type())
+	}
+	_, size, err := layout(v.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, size)
+	encode(buf, 0, v)
+	return buf, nil
+}
+
+// encode writes the value at the offset, the layout is already known to be right.
+func encode(buf []byte, offset int, v reflect.Value) {
+	putFloat := func(off int, f float64) {
+		binary.LittleEndian.PutUint32(buf[off:], math.Float32bits(float32(f)))
+	}
+
+	switch v.Type() {
+	case vec2, vec3, vec4:
+		for i := 0; i < v.Len(); i++ {
+			putFloat(offset+i*4, v.Index(i).Float())
+		}
+		return
+	case mat3, mat4:
+		// Both are stored column by column, every column starts at a multiple of 16 bytes.
+		n := 3
+		if v.Type() == mat4 {
+			n = 4
+		}
+		for i := 0; i < v.Len(); i++ {
+			putFloat(offset+i/n*16+i%n*4, v.Index(i).Float())
+		}
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Float32:
+		putFloat(offset, v.Float())
+	case reflect.Int32:
+		binary.LittleEndian.PutUint32(buf[offset:], uint32(v.Int()))
+	case reflect.Uint32:
+		binary.LittleEndian.PutUint32(buf[offset:], uint32(v.Uint()))
+	case reflect.Bool:
+		if v.Bool() {
+			binary.LittleEndian.PutUint32(buf[offset:], 1)
+		}
+	case reflect.Array:
+		_, size, _ := layout(v.Type().Elem())
+		for i := 0; i < v.Len(); i++ {
+			encode(buf, offset+i*roundUp(size, 16), v.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			align, size, _ := layout(v.Field(i).Type())
+			offset = roundUp(offset, align)
+			encode(buf, offset, v.Field(i))
+			offset += size
+		}
+	}
+}
+
+// Offsets returns the offset of every member of the block, named like OpenGL names them: "sun.direction" for a
+// member of a struct, "lights[2].color" for an element of an array of structs, and "weights" for the first element
+// of an array of other types.
+func Offsets(block interface{}) (map[string]int, error) {
+	t := reflect.TypeOf(block)
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("a uniform block has to be a struct, not %v", t)
+	}
+	if _, _, err := layout(t); err != nil {
+		return nil, err
+	}
+
+	offsets := make(map[string]int)
+	fields(offsets, "", 0, t)
+	return offsets, nil
+}
+
+// fields adds the offsets of the fields of a struct, the names start with the prefix.
+func fields(offsets map[string]int, prefix string, offset int, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		align, size, _ := layout(f.Type)
+		offset = roundUp(offset, align)
+
+		name := f.Name
+		if tag := f.Tag.Get("std140"); tag != "" {
+			name = tag
+		}
+		member(offsets, prefix+name, offset, f.Type)
+		offset += size
+	}
+}
+
+// member adds the offset of a member, and of what's in it when it's a struct or an array of structs.
+func member(offsets map[string]int, name string, offset int, t reflect.Type) {
+	switch {
+	case t.Kind() == reflect.Struct:
+		fields(offsets, name+".", offset, t)
+	case t.Kind() == reflect.Array && t != vec2 && t != vec3 && t != vec4 && t != mat3 && t != mat4 &&
+		t.Elem().Kind() == reflect.Struct:
+		_, size, _ := layout(t.Elem())
+		for i := 0; i < t.Len(); i++ {
+			member(offsets, fmt.Sprintf("%v[%v]", name, i), offset+i*roundUp(size, 16), t.Elem())
+		}
+	default:
+		offsets[name] = offset
+	}
+}
@@ -0,0 +1,189 @@
+package std140
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// The blocks of gfx, frame.glsl and lights.glsl. They're copied, gfx can't be imported from here.
+type frameBlock struct {
+	view, projection mgl32.Mat4
+	viewPos          mgl32.Vec3
+	time             float32
+}
+
+type directionalLight struct {
+	direction mgl32.Vec3
+	intensity float32
+	color     mgl32.Vec3
+}
+
+type pointLight struct {
+	position          mgl32.Vec3
+	constant          float32
+	color             mgl32.Vec3
+	linear, quadratic float32
+}
+
+type lightsBlock struct {
+	sun             directionalLight
+	pointLights     [8]pointLight
+	pointLightCount int32
+}
+
+// arraysBlock has the arrays that are padded: every vec3 and every column of a mat3 takes 16 bytes.
+type arraysBlock struct {
+	scale   float32
+	points  [3]mgl32.Vec3
+	normals [2]mgl32.Mat3
+	weights [2]float32 `std140:"w"`
+	last    bool
+}
+
+// nestedBlock has a struct in a struct, the inner struct is aligned to 16 bytes.
+type nestedBlock struct {
+	a     float32
+	outer struct {
+		b     float32
+		inner struct {
+			c mgl32.Vec2
+			d float32
+		}
+		e float32
+	}
+	f float32
+}
+
+func TestOffsets(t *testing.T) {
+	tests := []struct {
+		name  string
+		block interface{}
+		size  int
+		want  map[string]int
+	}{
+		{
+			name:  "frame",
+			block: frameBlock{},
+			size:  144,
+			want:  map[string]int{"view": 0, "projection": 64, "viewPos": 128, "time": 140},
+		},
+		{
+			// The sun is 28 bytes, rounded up to 32. A point light is 36, rounded up to 48.
+			name:  "lights",
+			block: lightsBlock{},
+			size:  432,
+			want: map[string]int{
+				"sun.direction": 0, "sun.intensity": 12, "sun.color": 16,
+				"pointLights[0].position": 32, "pointLights[0].constant": 44, "pointLights[0].color": 48,
+				"pointLights[0].linear": 60, "pointLights[0].quadratic": 64,
+				"pointLights[1].position": 80, "pointLights[7].quadratic": 32 + 7*48 + 32,
+				"pointLightCount": 416,
+			},
+		},
+		{
+			name:  "arrays",
+			block: arraysBlock{},
+			size:  208,
+			want:  map[string]int{"scale": 0, "points": 16, "normals": 64, "w": 160, "last": 192},
+		},
+		{
+			name:  "nested",
+			block: nestedBlock{},
+			size:  80,
+			want: map[string]int{
+				"a": 0, "outer.b": 16, "outer.inner.c": 32, "outer.inner.d": 40, "outer.e": 48, "f": 64,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			size, err := Size(test.block)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if size != test.size {
+				t.Errorf("the size is %v, want %v", size, test.size)
+			}
+
+			offsets, err := Offsets(test.block)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, want := range test.want {
+				if got, ok := offsets[name]; !ok || got != want {
+					t.Errorf("%v is at %v, want %v", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	b := arraysBlock{scale: 2.0, last: true}
+	b.points[1] = mgl32.Vec3{1.0, 2.0, 3.0}
+	b.normals[1] = mgl32.Mat3{1.0, 2.0, 3.0, 4.0, 5.0, 6.0, 7.0, 8.0, 9.0}
+	b.weights = [2]float32{0.25, 0.75}
+
+	buf, err := Encode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buf) != 208 {
+		t.Fatalf("got %v bytes, want 208", len(buf))
+	}
+
+	word := func(off int) uint32 { return binary.LittleEndian.Uint32(buf[off:]) }
+	float := func(off int) float32 { return math.Float32frombits(word(off)) }
+	if float(0) != 2.0 {
+		t.Errorf("scale is %v, want 2", float(0))
+	}
+	for i, want := range []float32{1.0, 2.0, 3.0} {
+		if got := float(32 + i*4); got != want {
+			t.Errorf("points[1][%v] is %v, want %v", i, got, want)
+		}
+	}
+	// The second mat3 starts at 64 + 48, its columns at multiples of 16.
+	for i := 0; i < 9; i++ {
+		if got, want := float(112+i/3*16+i%3*4), float32(i+1); got != want {
+			t.Errorf("normals[1][%v] is %v, want %v", i, got, want)
+		}
+	}
+	if float(160) != 0.25 || float(176) != 0.75 {
+		t.Errorf("the weights are %v and %v, want 0.25 and 0.75", float(160), float(176))
+	}
+	if word(192) != 1 {
+		t.Errorf("last is %v, want 1", word(192))
+	}
+
+	// The padding stays 0.
+	for _, off := range []int{4, 8, 12, 44, 124, 164} {
+		if word(off) != 0 {
+			t.Errorf("the padding at %v is %v", off, word(off))
+		}
+	}
+}
+
+func TestInvalidBlocks(t *testing.T) {
+	for _, block := range []interface{}{
+		struct{ X struct{} }{},
+		struct{}{},
+		struct{ X [2]struct{} }{},
+		struct{ X float64 }{},
+		struct{ X []float32 }{},
+	} {
+		if _, err := Size(block); err == nil {
+			t.Errorf("Size of %v gave no error", reflect.TypeOf(block))
+		}
+		if _, err := Encode(block); err == nil {
+			t.Errorf("Encode of %v gave no error", reflect.TypeOf(block))
+		}
+		if _, err := Offsets(block); err == nil {
+			t.Errorf("Offsets of %v gave no error", reflect.TypeOf(block))
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Package terrain turns heightmaps into chunked meshes with levels of detail, and answers height queries.
+package terrain
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+
+	"github.com/disintegration/imaging"
+)
+
+// Heightmap is a grid of heights from 0 to 1. Row 0 is the bottom row of the image, like OpenGL textures.
+type Heightmap struct {
+	W, H    int
+	Heights []float32
+}
+
+// LoadHeightmap reads a grayscale image, white is the highest.
+func LoadHeightmap(file string) (*Heightmap, error) {
+	// Open the image file.
+	src, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	img, _, err := image.Decode(src)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", file, err)
+	}
+	// Flip it the same way as textures, so the splat map lines up.
+	return CreateHeightmap(imaging.FlipV(img)), nil
+}
+
+// CreateHeightmap reads the heights from an image, which is used as it is.
+func CreateHeightmap(img image.Image) *Heightmap {
+	b := img.Bounds()
+	hm := &Heightmap{W: b.Dx(), H: b.Dy(), Heights: make([]float32, b.Dx()*b.Dy())}
+
+	for y := 0; y < hm.H; y++ {
+		for x := 0; x < hm.W; x++ {
+			// Use 16 bits, so 16 bit PNGs don't end up with steps.
+			g := color.Gray16Model.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.Gray16)
+			hm.Heights[y*hm.W+x] = float32(g.Y) / 65535.0
+		}
+	}
+
+	return hm
+}
+
+// At returns the height at grid point x, y. Points outside of the map are clamped to the edge.
+func (hm *Heightmap) At(x, y int) float32 {
+	if x < 0 {
+		x = 0
+	} else if x >= hm.W {
+		x = hm.W - 1
+	}
+	if y < 0 {
+		y = 0
+	} else if y >= hm.H {
+		y = hm.H - 1
+	}
+
+	return hm.Heights[y*hm.W+x]
+}
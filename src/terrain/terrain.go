@@ -0,0 +1,279 @@
+package terrain
+
+import (
+	"errors"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"GopherGL/src/mesh"
+)
+
+// Terrain places a heightmap in the world, and splits it in chunks.
+type Terrain struct {
+	Map *Heightmap
+	// Origin is the corner of the terrain with the lowest x and z.
+	Origin mgl32.Vec3
+	// Size is the width and depth in world units, Height is how high a height of 1 ends up.
+	Size, Height float32
+
+	// ChunkSize is the amount of quads along the side of a chunk, at the highest detail.
+	ChunkSize int
+	// Levels is the amount of levels of detail, every level has half the quads along a side.
+	Levels int
+	// LODDistance is the distance at which level 1 is used, every next level is used at double the distance.
+	LODDistance float32
+}
+
+// Chunk is a square part of the terrain.
+type Chunk struct {
+	// X and Z are the first grid point of the chunk.
+	X, Z     int
+	Min, Max mgl32.Vec3
+}
+
+// CreateTerrain returns a terrain of size by size world units, with its corner at the origin. The heightmap needs
+// at least 2 by 2 heights, the grid points are at the corners of the terrain.
+func CreateTerrain(hm *Heightmap, size, height float32) (*Terrain, error) {
+	if hm.W < 2 || hm.H < 2 {
+		return nil, errors.New("a heightmap needs at least 2 by 2 heights")
+	}
+
+	return &Terrain{
+		Map:         hm,
+		Size:        size,
+		Height:      height,
+		ChunkSize:   32,
+		Levels:      4,
+		LODDistance: 50.0,
+	}, nil
+}
+
+// spacing returns the distance between grid points along x and z.
+func (t *Terrain) spacing() (float32, float32) {
+	return t.Size / float32(t.Map.W-1), t.Size / float32(t.Map.H-1)
+}
+
+// point returns the world position of grid point x, z.
+func (t *Terrain) point(x, z int) mgl32.Vec3 {
+	sx, sz := t.spacing()
+	return t.Origin.Add(mgl32.Vec3{float32(x) * sx, t.Map.At(x, z) * t.Height, float32(z) * sz})
+}
+
+// gridNormal returns the normal at grid point x, z, using the neighbouring points.
+func (t *Terrain) gridNormal(x, z int) mgl32.Vec3 {
+	sx, sz := t.spacing()
+	dx := (t.Map.At(x+1, z) - t.Map.At(x-1, z)) * t.Height / (2.0 * sx)
+	dz := (t.Map.At(x, z+1) - t.Map.At(x, z-1)) * t.Height / (2.0 * sz)
+
+	return mgl32.Vec3{-dx, 1.0, -dz}.Normalize()
+}
+
+// grid returns the grid coordinates of world x and z, and whether they are on the terrain.
+func (t *Terrain) grid(x, z float32) (float32, float32, bool) {
+	sx, sz := t.spacing()
+	gx := (x - t.Origin.X()) / sx
+	gz := (z - t.Origin.Z()) / sz
+	ok := gx >= 0.0 && gz >= 0.0 && gx <= float32(t.Map.W-1) && gz <= float32(t.Map.H-1)
+
+	return gx, gz, ok
+}
+
+// HeightAt returns the world height of the ground at world x and z, and false if that's outside the terrain.
+func (t *Terrain) HeightAt(x, z float32) (float32, bool) {
+	gx, gz, ok := t.grid(x, z)
+	if !ok {
+		return 0.0, false
+	}
+
+	x0, z0, w := t.weights(gx, gz)
+	var h float32
+	for i, p := range cellCorners {
+		h += t.Map.At(x0+p[0], z0+p[1]) * w[i]
+	}
+
+	return t.Origin.Y() + h*t.Height, true
+}
+
+// cellCorners are the grid points of a cell, relative to the first one.
+var cellCorners = [4][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}}
+
+// weights returns the cell grid coordinates gx, gz are in, and the weights of its corners. They're interpolated on
+// the triangle of the cell they're in, the same ones ChunkMesh makes, so the heights match the ground that's
+// drawn.
+func (t *Terrain) weights(gx, gz float32) (int, int, [4]float32) {
+	x0, z0 := int(gx), int(gz)
+	fx, fz := gx-float32(x0), gz-float32(z0)
+
+	// The diagonal goes from x0+1, z0 to x0, z0+1.
+	if fx+fz <= 1.0 {
+		return x0, z0, [4]float32{1.0 - fx - fz, fx, fz, 0.0}
+	}
+	return x0, z0, [4]float32{0.0, 1.0 - fz, 1.0 - fx, fx + fz - 1.0}
+}
+
+// NormalAt returns the normal of the ground at world x and z, and false if that's outside the terrain.
+func (t *Terrain) NormalAt(x, z float32) (mgl32.Vec3, bool) {
+	gx, gz, ok := t.grid(x, z)
+	if !ok {
+		return mgl32.Vec3{0.0, 1.0, 0.0}, false
+	}
+
+	x0, z0, w := t.weights(gx, gz)
+	var n mgl32.Vec3
+	for i, p := range cellCorners {
+		n = n.Add(t.gridNormal(x0+p[0], z0+p[1]).Mul(w[i]))
+	}
+
+	return n.Normalize(), true
+}
+
+// Chunks returns all chunks of the terrain, with their bounding boxes.
+func (t *Terrain) Chunks() []Chunk {
+	var chunks []Chunk
+	for z := 0; z < t.Map.H-1; z += t.ChunkSize {
+		for x := 0; x < t.Map.W-1; x += t.ChunkSize {
+			c := Chunk{X: x, Z: z}
+
+			minY, maxY := float32(math.MaxFloat32), float32(-math.MaxFloat32)
+			for gz := z; gz <= z+t.ChunkSize && gz < t.Map.H; gz++ {
+				for gx := x; gx <= x+t.ChunkSize && gx < t.Map.W; gx++ {
+					h := t.Map.At(gx, gz) * t.Height
+					if h < minY {
+						minY = h
+					}
+					if h > maxY {
+						maxY = h
+					}
+				}
+			}
+
+			c.Min = t.point(x, z)
+			c.Max = t.point(x+t.ChunkSize, z+t.ChunkSize)
+			if x+t.ChunkSize >= t.Map.W {
+				c.Max[0] = t.point(t.Map.W-1, z)[0]
+			}
+			if z+t.ChunkSize >= t.Map.H {
+				c.Max[2] = t.point(x, t.Map.H-1)[2]
+			}
+			c.Min[1] = t.Origin.Y() + minY
+			c.Max[1] = t.Origin.Y() + maxY
+
+			chunks = append(chunks, c)
+		}
+	}
+
+	return chunks
+}
+
+// SelectLOD returns the level of detail of a chunk, seen from eye.
+func (t *Terrain) SelectLOD(c Chunk, eye mgl32.Vec3) int {
+	// Use the distance to the closest point of the bounding box, so a chunk you're standing on is always detailed.
+	var d float32
+	for i := 0; i < 3; i++ {
+		var v float32
+		if eye[i] < c.Min[i] {
+			v = c.Min[i] - eye[i]
+		} else if eye[i] > c.Max[i] {
+			v = eye[i] - c.Max[i]
+		}
+		d += v * v
+	}
+	d = float32(math.Sqrt(float64(d)))
+
+	level := 0
+	for dist := t.LODDistance; level < t.Levels-1 && d >= dist; dist *= 2.0 {
+		level++
+	}
+
+	return level
+}
+
+// ChunkMesh returns the mesh of a chunk at a level of detail. The edges get skirts that hang down,
+// these hide the cracks between chunks with a different level of detail.
+func (t *Terrain) ChunkMesh(c Chunk, level int) *mesh.Mesh {
+	step := 1 << uint(level)
+	if step > t.ChunkSize {
+		step = t.ChunkSize
+	}
+
+	// The grid points of the chunk, the last one is always on the edge of the chunk or map.
+	coords := func(start, max int) []int {
+		end := start + t.ChunkSize
+		if end > max-1 {
+			end = max - 1
+		}
+		var out []int
+		for i := start; i < end; i += step {
+			out = append(out, i)
+		}
+		return append(out, end)
+	}
+	xs, zs := coords(c.X, t.Map.W), coords(c.Z, t.Map.H)
+
+	m := &mesh.Mesh{}
+	vertex := func(x, z int, drop float32) uint32 {
+		p := t.point(x, z)
+		n := t.gridNormal(x, z)
+		u := float32(x) / float32(t.Map.W-1)
+		v := float32(z) / float32(t.Map.H-1)
+		m.Vertices = append(m.Vertices, p.X(), p.Y()-drop, p.Z(), u, v, n.X(), n.Y(), n.Z())
+		return uint32(m.VertexCount() - 1)
+	}
+
+	for _, z := range zs {
+		for _, x := range xs {
+			vertex(x, z, 0.0)
+		}
+	}
+
+	w := uint32(len(xs))
+	for j := uint32(0); j+1 < uint32(len(zs)); j++ {
+		for i := uint32(0); i+1 < w; i++ {
+			a, b := j*w+i, j*w+i+1
+			c, d := (j+1)*w+i, (j+1)*w+i+1
+			m.Indices = append(m.Indices, a, c, b, b, c, d)
+		}
+	}
+
+	// The skirts should be deep enough to cover the biggest difference a lower level can make.
+	sx, _ := t.spacing()
+	drop := float32(step) * sx
+	if drop < t.Height*0.05 {
+		drop = t.Height * 0.05
+	}
+
+	skirt := func(edge []uint32, coords [][2]int) {
+		prev := uint32(0)
+		for k, e := range edge {
+			s := vertex(coords[k][0], coords[k][1], drop)
+			if k > 0 {
+				// Both sides, so the skirt is never culled away.
+				m.Indices = append(m.Indices, edge[k-1], prev, e, e, prev, s)
+				m.Indices = append(m.Indices, edge[k-1], e, prev, e, s, prev)
+			}
+			prev = s
+		}
+	}
+
+	var edges [4][]uint32
+	var edgeCoords [4][][2]int
+	h := uint32(len(zs))
+	for i := uint32(0); i < w; i++ {
+		edges[0] = append(edges[0], i)
+		edgeCoords[0] = append(edgeCoords[0], [2]int{xs[i], zs[0]})
+		edges[1] = append(edges[1], (h-1)*w+i)
+		edgeCoords[1] = append(edgeCoords[1], [2]int{xs[i], zs[h-1]})
+	}
+	for j := uint32(0); j < h; j++ {
+		edges[2] = append(edges[2], j*w)
+		edgeCoords[2] = append(edgeCoords[2], [2]int{xs[0], zs[j]})
+		edges[3] = append(edges[3], j*w+w-1)
+		edgeCoords[3] = append(edgeCoords[3], [2]int{xs[w-1], zs[j]})
+	}
+	for k := range edges {
+		skirt(edges[k], edgeCoords[k])
+	}
+
+	return m
+}
@@ -0,0 +1,99 @@
+package terrain
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// randomTerrain returns a 9 by 9 terrain with random heights.
+func randomTerrain(t *testing.T) *Terrain {
+	rng := rand.New(rand.NewSource(1))
+	hm := &Heightmap{W: 9, H: 9, Heights: make([]float32, 81)}
+	for i := range hm.Heights {
+		hm.Heights[i] = rng.Float32()
+	}
+
+	tr, err := CreateTerrain(hm, 16.0, 4.0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.Origin = mgl32.Vec3{-8.0, 1.0, -8.0}
+	tr.ChunkSize = 4
+	return tr
+}
+
+// meshHeight returns the height of the triangle of the meshes under x, z.
+func meshHeight(tr *Terrain, x, z float32) (float32, bool) {
+	for _, c := range tr.Chunks() {
+		m := tr.ChunkMesh(c, 0)
+		vertex := func(i uint32) mgl32.Vec3 {
+			v := m.Vertices[i*8:]
+			return mgl32.Vec3{v[0], v[1], v[2]}
+		}
+		for i := 0; i+2 < len(m.Indices); i += 3 {
+			a, b, c := vertex(m.Indices[i]), vertex(m.Indices[i+1]), vertex(m.Indices[i+2])
+			// Barycentric coordinates on the xz plane, skirts are vertical so they're skipped.
+			det := (b[2]-c[2])*(a[0]-c[0]) + (c[0]-b[0])*(a[2]-c[2])
+			if math.Abs(float64(det)) < 1e-6 {
+				continue
+			}
+			wa := ((b[2]-c[2])*(x-c[0]) + (c[0]-b[0])*(z-c[2])) / det
+			wb := ((c[2]-a[2])*(x-c[0]) + (a[0]-c[0])*(z-c[2])) / det
+			wc := 1.0 - wa - wb
+			if wa >= -1e-5 && wb >= -1e-5 && wc >= -1e-5 {
+				return a[1]*wa + b[1]*wb + c[1]*wc, true
+			}
+		}
+	}
+	return 0.0, false
+}
+
+func TestHeightAtMatchesMesh(t *testing.T) {
+	tr := randomTerrain(t)
+	rng := rand.New(rand.NewSource(2))
+	for i := 0; i < 500; i++ {
+		x, z := rng.Float32()*16.0-8.0, rng.Float32()*16.0-8.0
+		h, ok := tr.HeightAt(x, z)
+		if !ok {
+			t.Fatalf("%v, %v isn't on the terrain", x, z)
+		}
+		want, ok := meshHeight(tr, x, z)
+		if !ok {
+			t.Fatalf("no triangle is under %v, %v", x, z)
+		}
+		if math.Abs(float64(h-want)) > 1e-4 {
+			t.Errorf("the height at %v, %v is %v, the mesh is at %v", x, z, h, want)
+		}
+	}
+}
+
+func TestHeightAtGridPoints(t *testing.T) {
+	tr := randomTerrain(t)
+	for z := 0; z < 9; z++ {
+		for x := 0; x < 9; x++ {
+			h, ok := tr.HeightAt(float32(x)*2.0-8.0, float32(z)*2.0-8.0)
+			if want := 1.0 + tr.Map.At(x, z)*4.0; !ok || math.Abs(float64(h-want)) > 1e-5 {
+				t.Errorf("the height at grid point %v, %v is %v, not %v", x, z, h, want)
+			}
+		}
+	}
+
+	if _, ok := tr.HeightAt(8.5, 0.0); ok {
+		t.Error("a point past the edge is on the terrain")
+	}
+	if n, ok := tr.NormalAt(0.5, 0.5); !ok || math.Abs(float64(n.Len()-1.0)) > 1e-5 || n[1] <= 0.0 {
+		t.Errorf("the normal is %v", n)
+	}
+}
+
+func TestCreateTerrainTooSmall(t *testing.T) {
+	for _, size := range [][2]int{{1, 1}, {1, 5}, {5, 1}, {0, 0}} {
+		hm := &Heightmap{W: size[0], H: size[1], Heights: make([]float32, size[0]*size[1])}
+		if _, err := CreateTerrain(hm, 10.0, 1.0); err == nil {
+			t.Errorf("a %vx%v heightmap isn't an error", size[0], size[1])
+		}
+	}
+}
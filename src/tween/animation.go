@@ -0,0 +1,248 @@
+package tween
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// LoopMode is what an animation does when it reaches the end.
+type LoopMode int
+
+// The loop modes of an animation.
+const (
+	// Once stops at the end, and calls OnComplete.
+	Once LoopMode = iota
+	// Loop starts over from the beginning.
+	Loop
+	// PingPong plays backwards when it reaches the end, and forwards again at the beginning.
+	PingPong
+)
+
+// Track applies the value at a time to something, for example the position of an Entity.
+type Track interface {
+	Duration() float32
+	Apply(t float32)
+}
+
+// Animation plays a group of tracks.
+type Animation struct {
+	Tracks []Track
+	Mode   LoopMode
+	// Speed multiplies the time, 1 is normal speed.
+	Speed float32
+
+	// OnComplete is called when a Once animation reaches the end, or the beginning when the speed is negative.
+	OnComplete func()
+	// OnLoop is called every time a Loop or PingPong animation turns around.
+	OnLoop func()
+
+	time     float32
+	backward bool
+	playing  bool
+	done     bool
+}
+
+// CreateAnimation returns an animation which is already playing.
+func CreateAnimation(mode LoopMode, tracks ...Track) *Animation {
+	return &Animation{
+		Tracks:  tracks,
+		Mode:    mode,
+		Speed:   1.0,
+		playing: true,
+	}
+}
+
+// Duration returns the duration of the longest track.
+func (a *Animation) Duration() float32 {
+	var d float32
+	for _, t := range a.Tracks {
+		if t.Duration() > d {
+			d = t.Duration()
+		}
+	}
+
+	return d
+}
+
+// Time returns how far the animation is.
+func (a *Animation) Time() float32 {
+	return a.time
+}
+
+// Playing returns whether the animation is running.
+func (a *Animation) Playing() bool {
+	return a.playing
+}
+
+// Done returns whether a Once animation has reached the end.
+func (a *Animation) Done() bool {
+	return a.done
+}
+
+// Play continues the animation.
+func (a *Animation) Play() {
+	a.playing = true
+}
+
+// Pause stops the animation where it is.
+func (a *Animation) Pause() {
+	a.playing = false
+}
+
+// Restart goes back to the beginning and plays the animation.
+func (a *Animation) Restart() {
+	a.time = 0.0
+	a.backward = false
+	a.playing = true
+	a.done = false
+	a.apply()
+}
+
+// Update moves the animation dt seconds forward and applies all tracks.
+func (a *Animation) Update(dt float32) {
+	if !a.playing {
+		return
+	}
+
+	d := a.Duration()
+	step := dt * a.Speed
+	if a.backward {
+		step = -step
+	}
+	a.time += step
+
+	switch a.Mode {
+	case Once:
+		// Played backwards, the beginning is the end.
+		if step >= 0.0 && a.time >= d || step < 0.0 && a.time <= 0.0 {
+			a.time = mgl32.Clamp(a.time, 0.0, d)
+			a.playing = false
+			a.done = true
+			a.apply()
+			if a.OnComplete != nil {
+				a.OnComplete()
+			}
+			return
+		}
+
+	case Loop:
+		if d > 0.0 && (a.time >= d || a.time < 0.0) {
+			// A negative speed runs below 0, Mod keeps the sign so that wraps around to the end.
+			a.time = float32(math.Mod(float64(a.time), float64(d)))
+			if a.time < 0.0 {
+				a.time += d
+			}
+			if a.OnLoop != nil {
+				a.OnLoop()
+			}
+		}
+
+	case PingPong:
+		// Bounce off both ends, a big dt can bounce more than once.
+		for d > 0.0 && (a.time > d || a.time < 0.0) {
+			if a.time > d {
+				a.time = 2.0*d - a.time
+			} else {
+				a.time = -a.time
+			}
+			a.backward = !a.backward
+			if a.OnLoop != nil {
+				a.OnLoop()
+			}
+		}
+	}
+
+	a.apply()
+}
+
+// apply applies every track at the current time.
+func (a *Animation) apply() {
+	for _, t := range a.Tracks {
+		t.Apply(a.time)
+	}
+}
+
+// Animator updates a group of animations, and drops them when they're done.
+type Animator struct {
+	anims []*Animation
+}
+
+// Add starts updating the animation and returns it.
+func (a *Animator) Add(anim *Animation) *Animation {
+	a.anims = append(a.anims, anim)
+	return anim
+}
+
+// Update updates all animations, finished Once animations are removed.
+func (a *Animator) Update(dt float32) {
+	alive := a.anims[:0]
+	for _, anim := range a.anims {
+		anim.Update(dt)
+		if !anim.Done() {
+			alive = append(alive, anim)
+		}
+	}
+	a.anims = alive
+}
+
+type floatTrack struct {
+	c   *Curve
+	set func(float32)
+}
+
+func (t *floatTrack) Duration() float32 { return t.c.Duration() }
+func (t *floatTrack) Apply(at float32)  { t.set(t.c.Value(at)) }
+
+// Float returns a track that sets a single value with a curve.
+func Float(c *Curve, set func(float32)) Track {
+	return &floatTrack{c, set}
+}
+
+type vecTrack struct {
+	c   []*Curve
+	set func(v mgl32.Vec4)
+}
+
+func (t *vecTrack) Duration() float32 {
+	var d float32
+	for _, c := range t.c {
+		if c.Duration() > d {
+			d = c.Duration()
+		}
+	}
+	return d
+}
+
+func (t *vecTrack) Apply(at float32) {
+	var v mgl32.Vec4
+	for i, c := range t.c {
+		v[i] = c.Value(at)
+	}
+	t.set(v)
+}
+
+// Vec3 returns a track that sets a vector with a curve for every component.
+func Vec3(x, y, z *Curve, set func(mgl32.Vec3)) Track {
+	return &vecTrack{[]*Curve{x, y, z}, func(v mgl32.Vec4) { set(v.Vec3()) }}
+}
+
+// Vec4 returns a track that sets a vector with a curve for every component, colors for example.
+func Vec4(x, y, z, w *Curve, set func(mgl32.Vec4)) Track {
+	return &vecTrack{[]*Curve{x, y, z, w}, set}
+}
+
+// FromTo returns a track that eases a single value from one value to another.
+func FromTo(from, to, duration float32, ease Ease, set func(float32)) Track {
+	return Float(CreateCurve(Key{Time: 0.0, Value: from, Ease: ease}, Key{Time: duration, Value: to}), set)
+}
+
+// FromToVec3 returns a track that eases a vector from one value to another.
+func FromToVec3(from, to mgl32.Vec3, duration float32, ease Ease, set func(mgl32.Vec3)) Track {
+	c := make([]*Curve, 3)
+	for i := range c {
+		c[i] = CreateCurve(Key{Time: 0.0, Value: from[i], Ease: ease}, Key{Time: duration, Value: to[i]})
+	}
+
+	return Vec3(c[0], c[1], c[2], set)
+}
@@ -0,0 +1,75 @@
+package tween
+
+import (
+	"testing"
+)
+
+// timeTrack is a one second track which remembers the last time it was applied at.
+type timeTrack struct {
+	at float32
+}
+
+func (t *timeTrack) Duration() float32 { return 1.0 }
+func (t *timeTrack) Apply(at float32)  { t.at = at }
+
+func TestAnimationModes(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  LoopMode
+		speed float32
+		// The animation is updated with every dt, and is at want after each.
+		dts  []float32
+		want []float32
+		// loops and completes are how often OnLoop and OnComplete were called in the end.
+		loops, completes int
+	}{
+		{"once", Once, 1.0, []float32{0.5, 0.75, 0.5}, []float32{0.5, 1.0, 1.0}, 0, 1},
+		{"once backwards", Once, 1.0, []float32{0.5, -0.25, -0.5}, []float32{0.5, 0.25, 0.0}, 0, 1},
+		{"loop", Loop, 1.0, []float32{0.5, 0.75, 2.0}, []float32{0.5, 0.25, 0.25}, 2, 0},
+		{"loop backwards", Loop, -1.0, []float32{0.25, 0.5, 0.5}, []float32{0.75, 0.25, 0.75}, 2, 0},
+		{"ping pong", PingPong, 1.0, []float32{0.75, 0.5, 1.0}, []float32{0.75, 0.75, 0.25}, 2, 0},
+		// Bouncing off both ends in one update.
+		{"ping pong twice", PingPong, 1.0, []float32{2.5}, []float32{0.5}, 2, 0},
+		{"ping pong backwards", PingPong, -1.0, []float32{0.25, 1.0}, []float32{0.25, 0.75}, 2, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			track := &timeTrack{}
+			a := CreateAnimation(test.mode, track)
+			a.Speed = test.speed
+			loops, completes := 0, 0
+			a.OnLoop = func() { loops++ }
+			a.OnComplete = func() { completes++ }
+
+			for i, dt := range test.dts {
+				// A negative dt plays it backwards from there on, by making the speed negative.
+				if dt < 0.0 {
+					a.Speed, dt = -1.0, -dt
+				}
+				a.Update(dt)
+				if !near(a.Time(), test.want[i]) || !near(track.at, test.want[i]) {
+					t.Errorf("update %v is at %v and applied %v, want %v", i, a.Time(), track.at, test.want[i])
+				}
+			}
+			if loops != test.loops || completes != test.completes {
+				t.Errorf("OnLoop was called %v times and OnComplete %v, want %v and %v", loops, completes,
+					test.loops, test.completes)
+			}
+			if a.Done() != (test.completes > 0) || a.Playing() == a.Done() {
+				t.Errorf("done is %v and playing %v", a.Done(), a.Playing())
+			}
+		})
+	}
+}
+
+func TestAnimatorDropsDone(t *testing.T) {
+	var an Animator
+	once := an.Add(CreateAnimation(Once, &timeTrack{}))
+	loop := an.Add(CreateAnimation(Loop, &timeTrack{}))
+
+	an.Update(1.5)
+	if !once.Done() || len(an.anims) != 1 || an.anims[0] != loop {
+		t.Errorf("the animator has %v animations, want only the loop", len(an.anims))
+	}
+}
@@ -0,0 +1,133 @@
+// Package tween animates values over time, with keyframe curves and easing functions.
+package tween
+
+import (
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Interp is how a key gets to the next key.
+type Interp int
+
+// The interpolation modes of a key.
+const (
+	InterpLinear Interp = iota
+	InterpStep
+	InterpBezier
+	InterpHermite
+)
+
+// Key is a single keyframe of a curve.
+type Key struct {
+	Time, Value float32
+	Interp      Interp
+
+	// Ease is used with InterpLinear, nil means no easing.
+	Ease Ease
+
+	// P1 and P2 are the control points used with InterpBezier, like a CSS cubic-bezier. The x is the time
+	// and y is the value, both from 0 to 1 between this key and the next.
+	P1, P2 mgl32.Vec2
+
+	// InTangent and OutTangent are used with InterpHermite, in value per second.
+	// The out tangent of this key and the in tangent of the next key are used.
+	InTangent, OutTangent float32
+}
+
+// Curve is a list of keys, sorted by time.
+type Curve struct {
+	Keys []Key
+}
+
+// CreateCurve sorts the keys by time and returns a curve.
+func CreateCurve(keys ...Key) *Curve {
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].Time < keys[j].Time })
+	return &Curve{keys}
+}
+
+// Duration returns the time of the last key.
+func (c *Curve) Duration() float32 {
+	if len(c.Keys) == 0 {
+		return 0.0
+	}
+	return c.Keys[len(c.Keys)-1].Time
+}
+
+// Value returns the value of the curve at time t. Before the first and after the last key the value is held.
+func (c *Curve) Value(t float32) float32 {
+	if len(c.Keys) == 0 {
+		return 0.0
+	}
+
+	// Find the first key after t.
+	k := sort.Search(len(c.Keys), func(i int) bool { return c.Keys[i].Time > t })
+	if k == 0 {
+		return c.Keys[0].Value
+	}
+	if k == len(c.Keys) {
+		return c.Keys[k-1].Value
+	}
+
+	k0, k1 := c.Keys[k-1], c.Keys[k]
+	dt := k1.Time - k0.Time
+	s := (t - k0.Time) / dt
+
+	switch k0.Interp {
+	case InterpStep:
+		return k0.Value
+
+	case InterpBezier:
+		return k0.Value + (k1.Value-k0.Value)*bezier(k0.P1, k0.P2, s)
+
+	case InterpHermite:
+		s2, s3 := s*s, s*s*s
+		h00 := 2.0*s3 - 3.0*s2 + 1.0
+		h10 := s3 - 2.0*s2 + s
+		h01 := -2.0*s3 + 3.0*s2
+		h11 := s3 - s2
+		return h00*k0.Value + h10*dt*k0.OutTangent + h01*k1.Value + h11*dt*k1.InTangent
+
+	default:
+		if k0.Ease != nil {
+			s = k0.Ease(s)
+		}
+		return k0.Value + (k1.Value-k0.Value)*s
+	}
+}
+
+// bezier returns the y of the curve from (0, 0) through p1 and p2 to (1, 1), at x.
+func bezier(p1, p2 mgl32.Vec2, x float32) float32 {
+	// The curve is defined by a parameter s, so first find the s where the curve is at x.
+	at := func(a, b, s float32) float32 {
+		return 3.0*(1.0-s)*(1.0-s)*s*a + 3.0*(1.0-s)*s*s*b + s*s*s
+	}
+
+	// Newton's method is quick, but can fail with flat slopes.
+	s := x
+	for i := 0; i < 8; i++ {
+		err := at(p1.X(), p2.X(), s) - x
+		if err > -1e-6 && err < 1e-6 {
+			return at(p1.Y(), p2.Y(), s)
+		}
+		slope := 3.0*(1.0-s)*(1.0-s)*p1.X() + 6.0*(1.0-s)*s*(p2.X()-p1.X()) + 3.0*s*s*(1.0-p2.X())
+		if slope > -1e-6 && slope < 1e-6 {
+			break
+		}
+		s -= err / slope
+	}
+
+	// Bisection always works, since x only goes up when the control points are between 0 and 1.
+	lo, hi := float32(0.0), float32(1.0)
+	s = x
+	for i := 0; i < 32; i++ {
+		if at(p1.X(), p2.X(), s) < x {
+			lo = s
+		} else {
+			hi = s
+		}
+		s = (lo + hi) / 2.0
+	}
+
+	return at(p1.Y(), p2.Y(), s)
+}
@@ -0,0 +1,106 @@
+package tween
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func near(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-4
+}
+
+func TestCurve(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []Key
+		at   float32
+		want float32
+	}{
+		{"linear", []Key{{Time: 0.0, Value: 0.0}, {Time: 2.0, Value: 10.0}}, 1.0, 5.0},
+		{"before the first key", []Key{{Time: 1.0, Value: 3.0}, {Time: 2.0, Value: 10.0}}, 0.0, 3.0},
+		{"after the last key", []Key{{Time: 0.0, Value: 0.0}, {Time: 2.0, Value: 10.0}}, 3.0, 10.0},
+		// The keys are sorted, whatever order they're given in.
+		{"unsorted", []Key{{Time: 2.0, Value: 10.0}, {Time: 0.0, Value: 0.0}}, 0.5, 2.5},
+		{"step", []Key{{Time: 0.0, Value: 1.0, Interp: InterpStep}, {Time: 1.0, Value: 2.0}}, 0.99, 1.0},
+		{"eased", []Key{{Time: 0.0, Value: 0.0, Ease: InQuad}, {Time: 1.0, Value: 4.0}}, 0.5, 1.0},
+		{
+			// Control points on the diagonal are a straight line.
+			"bezier linear",
+			[]Key{{Time: 0.0, Value: 0.0, Interp: InterpBezier, P2: mgl32.Vec2{1.0, 1.0}}, {Time: 1.0, Value: 1.0}},
+			0.3, 0.3,
+		},
+		{
+			// CSS ease, the x of the curve has to be solved for the time first.
+			"bezier ease",
+			[]Key{
+				{Time: 0.0, Value: 0.0, Interp: InterpBezier, P1: mgl32.Vec2{0.25, 0.1}, P2: mgl32.Vec2{0.25, 1.0}},
+				{Time: 2.0, Value: 10.0},
+			},
+			1.0, 8.024034,
+		},
+		{
+			// Flat tangents are a smoothstep.
+			"hermite flat",
+			[]Key{{Time: 0.0, Value: 0.0, Interp: InterpHermite}, {Time: 1.0, Value: 1.0}},
+			0.25, 0.15625,
+		},
+		{
+			// Tangents which match the slope are a straight line, they're per second so dt scales them.
+			"hermite slope",
+			[]Key{
+				{Time: 0.0, Value: 0.0, Interp: InterpHermite, OutTangent: 0.5},
+				{Time: 2.0, Value: 1.0, InTangent: 0.5},
+			},
+			0.5, 0.25,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := CreateCurve(test.keys...).Value(test.at); !near(got, test.want) {
+				t.Errorf("the value at %v is %v, want %v", test.at, got, test.want)
+			}
+		})
+	}
+}
+
+func TestEase(t *testing.T) {
+	tests := []struct {
+		name string
+		ease Ease
+		// want are the values at 0.25 and 0.5.
+		want [2]float32
+	}{
+		{"Linear", Linear, [2]float32{0.25, 0.5}},
+		{"InQuad", InQuad, [2]float32{0.0625, 0.25}},
+		{"OutQuad", OutQuad, [2]float32{0.4375, 0.75}},
+		{"InOutQuad", InOutQuad, [2]float32{0.125, 0.5}},
+		{"InCubic", InCubic, [2]float32{0.015625, 0.125}},
+		{"OutCubic", OutCubic, [2]float32{0.578125, 0.875}},
+		{"InOutCubic", InOutCubic, [2]float32{0.0625, 0.5}},
+		{"InSine", InSine, [2]float32{0.076120, 0.292893}},
+		{"InOutSine", InOutSine, [2]float32{0.146447, 0.5}},
+		{"InExpo", InExpo, [2]float32{0.005524, 0.03125}},
+		{"InOutCirc", InOutCirc, [2]float32{0.066987, 0.5}},
+		{"InBack", InBack, [2]float32{-0.064136, -0.087698}},
+		{"OutBounce", OutBounce, [2]float32{0.472656, 0.765625}},
+		{"InOutElastic", InOutElastic, [2]float32{-0.007813, 0.5}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Every ease starts at 0 and ends at 1.
+			for i, at := range []float32{0.0, 0.25, 0.5, 1.0} {
+				want := at
+				if i == 1 || i == 2 {
+					want = test.want[i-1]
+				}
+				if got := test.ease(at); !near(got, want) {
+					t.Errorf("the value at %v is %v, want %v", at, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package tween
+
+import (
+	"math"
+)
+
+// Ease maps the progress t, from 0 to 1, to an eased progress. It's allowed to go past 0 and 1 for overshoot.
+type Ease func(t float32) float32
+
+// The easing functions, see easings.net for what they look like.
+var (
+	Linear Ease = func(t float32) float32 { return t }
+
+	InQuad    Ease = func(t float32) float32 { return t * t }
+	OutQuad   Ease = func(t float32) float32 { return 1.0 - (1.0-t)*(1.0-t) }
+	InOutQuad Ease = inOut(InQuad)
+
+	InCubic    Ease = func(t float32) float32 { return t * t * t }
+	OutCubic   Ease = out(InCubic)
+	InOutCubic Ease = inOut(InCubic)
+
+	InQuart    Ease = func(t float32) float32 { return t * t * t * t }
+	OutQuart   Ease = out(InQuart)
+	InOutQuart Ease = inOut(InQuart)
+
+	InSine    Ease = func(t float32) float32 { return 1.0 - float32(math.Cos(float64(t)*math.Pi/2.0)) }
+	OutSine   Ease = out(InSine)
+	InOutSine Ease = inOut(InSine)
+
+	InExpo Ease = func(t float32) float32 {
+		if t <= 0.0 {
+			return 0.0
+		}
+		return float32(math.Pow(2.0, 10.0*float64(t)-10.0))
+	}
+	OutExpo   Ease = out(InExpo)
+	InOutExpo Ease = inOut(InExpo)
+
+	InCirc    Ease = func(t float32) float32 { return 1.0 - float32(math.Sqrt(1.0-float64(t*t))) }
+	OutCirc   Ease = out(InCirc)
+	InOutCirc Ease = inOut(InCirc)
+
+	InBack Ease = func(t float32) float32 {
+		const c1 = 1.70158
+		return (c1+1.0)*t*t*t - c1*t*t
+	}
+	OutBack   Ease = out(InBack)
+	InOutBack Ease = inOut(InBack)
+
+	InElastic Ease = func(t float32) float32 {
+		if t <= 0.0 || t >= 1.0 {
+			return t
+		}
+		const c4 = 2.0 * math.Pi / 3.0
+		return float32(-math.Pow(2.0, 10.0*float64(t)-10.0) * math.Sin((float64(t)*10.0-10.75)*c4))
+	}
+	OutElastic   Ease = out(InElastic)
+	InOutElastic Ease = inOut(InElastic)
+
+	OutBounce Ease = func(t float32) float32 {
+		const n1, d1 = 7.5625, 2.75
+		switch {
+		case t < 1.0/d1:
+			return n1 * t * t
+		case t < 2.0/d1:
+			t -= 1.5 / d1
+			return n1*t*t + 0.75
+		case t < 2.5/d1:
+			t -= 2.25 / d1
+			return n1*t*t + 0.9375
+		default:
+			t -= 2.625 / d1
+			return n1*t*t + 0.984375
+		}
+	}
+	InBounce    Ease = out(OutBounce)
+	InOutBounce Ease = inOut(InBounce)
+)
+
+// out turns an ease in into an ease out, by playing it backwards.
+func out(in Ease) Ease {
+	return func(t float32) float32 {
+		return 1.0 - in(1.0-t)
+	}
+}
+
+// inOut uses the ease in for the first half, and the ease out for the second half.
+func inOut(in Ease) Ease {
+	return func(t float32) float32 {
+		if t < 0.5 {
+			return in(t*2.0) / 2.0
+		}
+		return 1.0 - in((1.0-t)*2.0)/2.0
+	}
+}
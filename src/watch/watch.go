@@ -0,0 +1,150 @@
+// Package watch finds the files that changed in directories. It looks at the modification time and size of every
+// file every so often, which works everywhere and is fast enough for a few hundred assets.
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// stamp is what's compared to see if a file changed.
+type stamp struct {
+	mod  time.Time
+	size int64
+}
+
+// Watcher keeps track of the files in directories, and their subdirectories.
+type Watcher struct {
+	dirs  []string
+	files map[string]stamp
+
+	mu      sync.Mutex
+	changed map[string]bool
+	stop    chan struct{}
+}
+
+// CreateWatcher looks at the files in the directories, the changes after this are reported.
+func CreateWatcher(dirs ...string) (*Watcher, error) {
+	w := &Watcher{files: make(map[string]stamp), changed: make(map[string]bool)}
+	for _, d := range dirs {
+		abs, err := filepath.Abs(d)
+		if err != nil {
+			return nil, err
+		}
+		w.dirs = append(w.dirs, abs)
+	}
+
+	var err error
+	w.files, err = w.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// scan returns the stamps of all files in the directories.
+func (w *Watcher) scan() (map[string]stamp, error) {
+	files := make(map[string]stamp)
+	for _, d := range w.dirs {
+		err := filepath.Walk(d, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				// A file that's removed while walking isn't an error.
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if !info.IsDir() {
+				files[path] = stamp{info.ModTime(), info.Size()}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// Poll looks at the files now, and returns the absolute paths of the ones that were changed, added or removed
+// since the last time, sorted.
+func (w *Watcher) Poll() ([]string, error) {
+	files, err := w.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for path, s := range files {
+		if old, ok := w.files[path]; !ok || old != s {
+			changed = append(changed, path)
+		}
+	}
+	for path := range w.files {
+		if _, ok := files[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	w.files = files
+	sort.Strings(changed)
+
+	return changed, nil
+}
+
+// Start polls in the background every interval, until Stop is called. The changes are collected for Changed.
+// Errors, like a directory that's removed, are skipped until the next poll. Don't call Poll while it's started.
+func (w *Watcher) Start(interval time.Duration) {
+	w.Stop()
+	w.stop = make(chan struct{})
+
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				changed, err := w.Poll()
+				if err != nil {
+					continue
+				}
+
+				w.mu.Lock()
+				for _, c := range changed {
+					w.changed[c] = true
+				}
+				w.mu.Unlock()
+			}
+		}
+	}(w.stop)
+}
+
+// Stop stops polling in the background.
+func (w *Watcher) Stop() {
+	if w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+}
+
+// Changed returns the files the background polling found since the last call, sorted. It doesn't wait.
+func (w *Watcher) Changed() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	changed := make([]string, 0, len(w.changed))
+	for c := range w.changed {
+		changed = append(changed, c)
+	}
+	w.changed = make(map[string]bool)
+	sort.Strings(changed)
+
+	return changed
+}
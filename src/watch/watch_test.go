@@ -0,0 +1,123 @@
+package watch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// tempDir returns an absolute temporary directory with the files, and a function that removes it.
+func tempDir(t *testing.T, files ...string) (string, func()) {
+	dir, err := ioutil.TempDir("", "watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		write(t, filepath.Join(dir, f), "a")
+	}
+
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+// write creates or replaces a file and the directories it's in.
+func write(t *testing.T, file, data string) {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPoll(t *testing.T) {
+	dir, remove := tempDir(t, "a.txt", "sub/b.txt", "c.txt")
+	defer remove()
+	path := func(f string) string { return filepath.Join(dir, filepath.FromSlash(f)) }
+
+	w, err := CreateWatcher(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		change func()
+		want   []string
+	}{
+		{"nothing", func() {}, nil},
+		{"added", func() { write(t, path("sub/new.txt"), "a") }, []string{path("sub/new.txt")}},
+		// The size changes, the time might not on file systems with coarse times.
+		{"size", func() { write(t, path("a.txt"), "longer") }, []string{path("a.txt")}},
+		{
+			"time",
+			func() {
+				later := time.Now().Add(time.Hour)
+				if err := os.Chtimes(path("sub/b.txt"), later, later); err != nil {
+					t.Fatal(err)
+				}
+			},
+			[]string{path("sub/b.txt")},
+		},
+		{
+			"removed",
+			func() {
+				if err := os.Remove(path("c.txt")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			[]string{path("c.txt")},
+		},
+		{
+			"several, sorted",
+			func() {
+				write(t, path("c.txt"), "a")
+				write(t, path("a.txt"), "a")
+			},
+			[]string{path("a.txt"), path("c.txt")},
+		},
+	}
+
+	for _, test := range tests {
+		test.change()
+		changed, err := w.Poll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(changed, test.want) {
+			t.Errorf("%v: got %v, want %v", test.name, changed, test.want)
+		}
+	}
+}
+
+func TestStart(t *testing.T) {
+	dir, remove := tempDir(t, "a.txt")
+	defer remove()
+
+	w, err := CreateWatcher(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Start(5 * time.Millisecond)
+	defer w.Stop()
+
+	write(t, filepath.Join(dir, "a.txt"), "changed")
+	var changed []string
+	for deadline := time.Now().Add(2 * time.Second); len(changed) == 0 && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+		changed = w.Changed()
+	}
+	if want := []string{filepath.Join(dir, "a.txt")}; !reflect.DeepEqual(changed, want) {
+		t.Fatalf("got %v, want %v", changed, want)
+	}
+
+	// The changes are only returned once.
+	if changed := w.Changed(); len(changed) != 0 {
+		t.Errorf("got %v again", changed)
+	}
+}
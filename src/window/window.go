@@ -10,17 +10,16 @@ import (
 	"github.com/go-gl/mathgl/mgl32"
 
 	"GopherGL/src/camera"
+	"GopherGL/src/gfx"
 )
 
 var (
 	currTime, prevTime, deltaTime float32
-)
 
-func check(err error) {
-	if err != nil {
-		panic(err)
-	}
-}
+	// The deltas of the last frames, FPS averages them so it doesn't jump around.
+	deltas              [60]float32
+	deltaIdx, deltaNum int
+)
 
 // Window stores a glfw handle, x and y dimensions and if vsync is enables.
 type Window struct {
@@ -31,7 +30,7 @@ type Window struct {
 }
 
 func (w *Window) resizeCallback(glfwWin *glfw.Window, x, y int) {
-	gl.Viewport(0, 0, int32(x), int32(y))
+	gfx.Resize(int32(x), int32(y))
 
 	// This will keep the aspect ratio correct.
 	w.X = uint32(x)
@@ -78,6 +77,7 @@ func CreateWindow(x, y uint32, name string, vsync bool) (*Window, error) {
 
 	w.handle, err = glfw.CreateWindow(int(x), int(y), name, nil, nil)
 	if err != nil {
+		glfw.Terminate()
 		return nil, err
 	}
 	w.handle.MakeContextCurrent()
@@ -89,14 +89,10 @@ func CreateWindow(x, y uint32, name string, vsync bool) (*Window, error) {
 
 	err = gl.Init()
 	if err != nil {
+		w.handle.Destroy()
+		glfw.Terminate()
 		return nil, err
 	}
-	gl.Viewport(0, 0, int32(w.X), int32(w.Y))
-	// This allows us to use transparency with PNG files.
-	gl.Enable(gl.BLEND)
-	gl.Enable(gl.DEPTH_TEST)
-	gl.Enable(gl.CULL_FACE)
-	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
 
 	// Just some information for the users.
 	fmt.Println("OS:", runtime.GOOS, "\nArchitecture:", runtime.GOARCH)
@@ -114,6 +110,12 @@ func (w *Window) Update() {
 	currTime = float32(glfw.GetTime())
 	deltaTime = currTime - prevTime
 
+	deltas[deltaIdx] = deltaTime
+	deltaIdx = (deltaIdx + 1) % len(deltas)
+	if deltaNum < len(deltas) {
+		deltaNum++
+	}
+
 	w.handle.SwapBuffers()
 	glfw.PollEvents()
 }
@@ -134,9 +136,17 @@ func (w *Window) DeltaTime() float32 {
 	return deltaTime
 }
 
-// FPS returns the frames per second.
+// FPS returns the frames per second, averaged over the last 60 frames.
 func (w *Window) FPS() float32 {
-	return 1.0 / deltaTime
+	var total float32
+	for i := 0; i < deltaNum; i++ {
+		total += deltas[i]
+	}
+	if total == 0.0 {
+		return 0.0
+	}
+
+	return float32(deltaNum) / total
 }
 
 // Time returns the time since GLFW has been initialized.
@@ -175,3 +185,13 @@ func (w *Window) HandleInput(movSpd float32, cam *camera.Camera) {
 		cam.Pos = mgl32.Vec3{cam.Pos.X() + movSpd*deltaTime, cam.Pos.Y(), cam.Pos.Z()}
 	}
 }
+
+// Screenshot saves what's currently in the back buffer to a PNG file. Call it before Update, which swaps the buffers.
+func (w *Window) Screenshot(file string) error {
+	img, err := gfx.CaptureFrame(nil)
+	if err != nil {
+		return err
+	}
+
+	return gfx.SavePNG(file, img)
+}